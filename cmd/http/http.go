@@ -1,11 +1,13 @@
 package http
 
 import (
+	"context"
+	"log"
+
 	"github.com/hanifkf12/hanif_skeleton/pkg/app"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
 	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
-	"log"
 )
 
 func Start() {
@@ -23,10 +25,18 @@ func Start() {
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
-	defer cleanup()
 
-	application := app.InitializeApp(cfg)
+	// Tracer is registered first so it is flushed after every other resource is closed
+	lifecycle := app.NewLifecycle()
+	lifecycle.OnShutdown("tracer", func(ctx context.Context) error {
+		cleanup()
+		return nil
+	})
+
+	application := app.InitializeApp(cfg, lifecycle)
 	application.SetupSocket()
+
+	// Run blocks until SIGINT/SIGTERM and drains in-flight requests before returning
 	err = application.Run()
 	if err != nil {
 		log.Fatal(err)
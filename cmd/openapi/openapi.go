@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/router"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+var OpenAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Generate OpenAPI document",
+	Long:  "Generate the OpenAPI 3.1 document for every HTTP route, the same document served at /openapi.json",
+	Run:   runOpenAPI,
+}
+
+func init() {
+	OpenAPICmd.Flags().StringP("output", "o", "openapi.json", "file to write, - for stdout")
+}
+
+func runOpenAPI(cmd *cobra.Command, args []string) {
+	logger.SetupNop()
+
+	cfg, err := config.LoadAllConfigs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	doc := router.OpenAPI(router.ListRoutes(cfg), router.Info(cfg))
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode OpenAPI document: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+
+	output, _ := cmd.Flags().GetString("output")
+	if output == "-" {
+		_, _ = os.Stdout.Write(data)
+		return
+	}
+
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", output, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("OpenAPI document written to %s\n", output)
+}
@@ -62,6 +62,13 @@ func Start() {
 		MaxConcurrent:  10,
 	})
 
+	// Typed usecases are reused as consumers through an adapter, e.g. the logic behind POST /users:
+	// router.RegisterSubscription(pubsubRouter.SubscriptionConfig{
+	//     SubscriptionID: "user-create-subscription",
+	//     Consumer:       handler.PubSubUseCase(usecase.NewCreateUser(userRepository, bootstrap.RegistryBcryptHasher(cfg))),
+	//     MaxConcurrent:  10,
+	// })
+
 	// Add more subscriptions here as needed
 	// router.RegisterSubscription(pubsubRouter.SubscriptionConfig{
 	//     SubscriptionID: "another-subscription",
@@ -5,7 +5,9 @@ import (
 
 	"github.com/hanifkf12/hanif_skeleton/cmd/http"
 	"github.com/hanifkf12/hanif_skeleton/cmd/migration"
+	"github.com/hanifkf12/hanif_skeleton/cmd/openapi"
 	"github.com/hanifkf12/hanif_skeleton/cmd/pubsub"
+	"github.com/hanifkf12/hanif_skeleton/cmd/routes"
 	"github.com/hanifkf12/hanif_skeleton/cmd/worker"
 	"github.com/spf13/cobra"
 )
@@ -43,6 +45,8 @@ func Start() {
 			},
 		},
 		worker.WorkerCmd,
+		routes.RoutesCmd,
+		openapi.OpenAPICmd,
 		migrateCmd,
 	}
 
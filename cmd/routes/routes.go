@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/router"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+var RoutesCmd = &cobra.Command{
+	Use:   "routes",
+	Short: "List HTTP routes",
+	Long:  "List every HTTP route with its middleware chain, without connecting to external services",
+	Run:   runRoutes,
+}
+
+func init() {
+	RoutesCmd.Flags().Bool("json", false, "print routes as JSON")
+}
+
+func runRoutes(cmd *cobra.Command, args []string) {
+	// Stdout is the route list, bootstrap logs would only get in the way
+	logger.SetupNop()
+
+	cfg, err := config.LoadAllConfigs()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	routes := router.ListRoutes(cfg)
+
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(routes); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to encode routes: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tNAME\tMIDDLEWARES")
+	for _, r := range routes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Method, r.Path, r.Name, strings.Join(r.Middlewares, ", "))
+	}
+	_ = w.Flush()
+}
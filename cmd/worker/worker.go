@@ -72,6 +72,9 @@ func runWorker(cmd *cobra.Command, args []string) {
 		jobs.NewSyncDataJob(httpClient, cache),
 	)
 
+	// Typed usecases are reused as jobs through an adapter, e.g. the logic behind POST /users:
+	// registry.Register("user:create", handler.JobUseCase(usecase.NewCreateUser(userRepository, bootstrap.RegistryBcryptHasher(cfg))))
+
 	logger.Info("Job handlers registered", lf)
 
 	// Create Asynq server
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_keys(
+    id SERIAL PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    prefix varchar(16) NOT NULL UNIQUE,
+    key_hash varchar(64) NOT NULL,
+    scopes text NOT NULL DEFAULT '',
+    created_by integer NULL REFERENCES users(id) ON DELETE SET NULL,
+    expires_at timestamp with time zone NULL,
+    last_used_at timestamp with time zone NULL,
+    revoked_at timestamp with time zone NULL,
+    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -1,11 +1,27 @@
 package appctx
 
 import (
+	"fmt"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 )
 
 type Data struct {
 	FiberCtx *fiber.Ctx
 	Cfg      *config.Config
+	Request  interface{} // Bound and validated request, set by handler.BindRequest
+}
+
+// ErrRequestNotBound is returned when a usecase reads a request its route does not bind, see handler.BindRequest
+var ErrRequestNotBound = apperror.Internal("request_not_bound", "Request not bound")
+
+// BoundRequest returns the request bound for the usecase, ErrRequestNotBound when the route bound none or another type
+func BoundRequest[T any](data Data) (*T, error) {
+	req, ok := data.Request.(*T)
+	if !ok || req == nil {
+		return nil, ErrRequestNotBound.Wrap(fmt.Errorf("expected %T, got %T", req, data.Request))
+	}
+	return req, nil
 }
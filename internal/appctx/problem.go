@@ -0,0 +1,94 @@
+package appctx
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
+)
+
+// MIMEProblemJSON is the media type of RFC 7807 problem details
+const MIMEProblemJSON = "application/problem+json"
+
+// problemTypeDefault is used when a problem has no more specific type than its status code
+const problemTypeDefault = "about:blank"
+
+// Problem is an RFC 7807 problem details document
+// Extensions are rendered as additional top level members, e.g. trace_id
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON flattens extension members next to the standard members
+func (p Problem) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		doc[k] = v
+	}
+
+	doc["type"] = p.Type
+	doc["title"] = p.Title
+	doc["status"] = p.Status
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	}
+
+	return json.Marshal(doc)
+}
+
+// WithExtension adds an extension member, empty values are skipped
+func (p *Problem) WithExtension(key string, value interface{}) *Problem {
+	if value == nil || value == "" {
+		return p
+	}
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// Problem renders an error response as a problem document
+// Errors rendered from an apperror get a type of typeBaseURL/<code>, or about:blank when no base URL is set
+func (r *Response) Problem(instance string, typeBaseURL string) *Problem {
+	status := r.Code
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	p := &Problem{
+		Type:     problemTypeDefault,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   r.Message,
+		Instance: instance,
+	}
+
+	switch errs := r.Errors.(type) {
+	case apperror.Body:
+		if typeBaseURL != "" && errs.Code != "" {
+			p.Type = strings.TrimSuffix(typeBaseURL, "/") + "/" + errs.Code
+		}
+		p.Detail = errs.Message
+		p.WithExtension("code", errs.Code)
+		p.WithExtension("kind", string(errs.Kind))
+		p.WithExtension("errors", errs.Details)
+	case string:
+		// Middlewares report a single message
+		p.Detail = errs
+	case nil:
+	default:
+		p.WithExtension("errors", errs)
+	}
+
+	return p
+}
@@ -17,7 +17,11 @@ type Response struct {
 	Message   string      `json:"message,omitempty"`
 	Timestamp time.Time   `json:"timestamp"`
 	Data      interface{} `json:"data,omitempty"`
+	Meta      interface{} `json:"meta,omitempty"` // e.g. pagination cursors of a list
 	Errors    interface{} `json:"errors,omitempty"`
+
+	// Err is rendered by the router, its apperror kind decides the status code unless Code is set
+	Err error `json:"-"`
 }
 
 func (r *Response) WithCode(code int) *Response {
@@ -42,11 +46,22 @@ func (r *Response) WithData(data interface{}) *Response {
 	return r
 }
 
+func (r *Response) WithMeta(meta interface{}) *Response {
+	r.Meta = meta
+	return r
+}
+
 func (r *Response) WithErrors(errors interface{}) *Response {
 	r.Errors = errors
 	return r
 }
 
+// WithError attaches a usecase error, the router maps it to a status code and a client safe body
+func (r *Response) WithError(err error) *Response {
+	r.Err = err
+	return r
+}
+
 func (r *Response) Byte() []byte {
 	bytes, err := json.Marshal(r)
 	if err != nil {
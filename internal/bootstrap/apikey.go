@@ -0,0 +1,55 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/repository"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apikey"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// RegistryAPIKeyResolver creates the resolver APIKeyAuth looks keys up with, keys are cached in store
+func RegistryAPIKeyResolver(cfg *config.Config, repo repository.APIKeyRepository, store cache.Cache) apikey.Resolver {
+	lf := logger.NewFields("RegistryAPIKeyResolver")
+
+	resolver := apikey.NewResolver(apiKeyStore{repo: repo}, store, apikey.ResolverConfig{
+		CacheTTL: cfg.APIKey.CacheTTL,
+	})
+
+	lf.Append(logger.Any("cache_ttl", cfg.APIKey.CacheTTL.String()))
+	logger.Info("API key resolver initialized successfully", lf)
+	return resolver
+}
+
+// apiKeyStore adapts the repository to apikey.Store
+type apiKeyStore struct {
+	repo repository.APIKeyRepository
+}
+
+func (s apiKeyStore) GetByPrefix(ctx context.Context, prefix string) (*apikey.Key, error) {
+	key, err := s.repo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apikey.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &apikey.Key{
+		ID:        key.Id,
+		Name:      key.Name,
+		Hash:      key.KeyHash,
+		Scopes:    key.ScopeList(),
+		ExpiresAt: key.ExpiresAt,
+		RevokedAt: key.RevokedAt,
+	}, nil
+}
+
+func (s apiKeyStore) TouchLastUsed(ctx context.Context, id int64, t time.Time) error {
+	return s.repo.TouchLastUsed(ctx, id, t)
+}
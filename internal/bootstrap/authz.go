@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/authz"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// RegistryAuthorizer creates the permission checker from the configured policy file
+func RegistryAuthorizer(cfg *config.Config) authz.Authorizer {
+	lf := logger.NewFields("RegistryAuthorizer")
+
+	policy := authz.DefaultPolicy()
+	if path := cfg.Authz.PolicyFile; path != "" {
+		loaded, err := authz.LoadPolicy(path)
+		if err != nil {
+			log.Fatalf("Failed to load authorization policy: %v", err)
+		}
+		policy = loaded
+		lf.Append(logger.Any("policy_file", path))
+	}
+
+	authorizer, err := authz.NewAuthorizer(policy)
+	if err != nil {
+		log.Fatalf("Invalid authorization policy: %v", err)
+	}
+
+	lf.Append(logger.Any("roles", len(policy.Roles)))
+	logger.Info("Authorizer initialized successfully", lf)
+	return authorizer
+}
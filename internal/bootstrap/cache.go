@@ -19,6 +19,8 @@ func RegistryCache(cfg *config.Config) cache.Cache {
 	switch cfg.Cache.Driver {
 	case "redis":
 		return registryRedisCache(cfg)
+	case "tiered":
+		return registryTieredCache(cfg)
 	case "memory":
 		return registryMemoryCache(cfg)
 	default:
@@ -31,6 +33,37 @@ func RegistryCache(cfg *config.Config) cache.Cache {
 func registryRedisCache(cfg *config.Config) cache.Cache {
 	lf := logger.NewFields("RegistryRedisCache")
 
+	client := newRedisClient(cfg, lf)
+
+	logger.Info("Redis cache initialized successfully", lf)
+
+	return cache.NewRedisCache(client)
+}
+
+// registryTieredCache creates an in-process LRU in front of Redis, invalidated across instances over pub/sub
+func registryTieredCache(cfg *config.Config) cache.Cache {
+	lf := logger.NewFields("RegistryTieredCache")
+
+	client := newRedisClient(cfg, lf)
+
+	lf.Append(logger.Any("l1_size", cfg.Cache.L1Size))
+	lf.Append(logger.Any("l1_ttl", cfg.Cache.L1TTL.String()))
+
+	c, err := cache.NewTieredCache(context.Background(), client, cache.TieredConfig{
+		L1Size: cfg.Cache.L1Size,
+		L1TTL:  cfg.Cache.L1TTL,
+	})
+	if err != nil {
+		log.Fatalf("Failed to subscribe to cache invalidations: %v", err)
+	}
+
+	logger.Info("Tiered cache initialized successfully", lf)
+
+	return c
+}
+
+// newRedisClient connects to the configured Redis, exiting when it is unreachable
+func newRedisClient(cfg *config.Config, lf *logger.Fields) *redis.Client {
 	// Default values
 	host := cfg.Cache.Host
 	if host == "" {
@@ -66,17 +99,28 @@ func registryRedisCache(cfg *config.Config) cache.Cache {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
 
-	logger.Info("Redis cache initialized successfully", lf)
-
-	return cache.NewRedisCache(client)
+	return client
 }
 
 // registryMemoryCache creates in-memory cache instance
 func registryMemoryCache(cfg *config.Config) cache.Cache {
 	lf := logger.NewFields("RegistryMemoryCache")
 
+	policy, err := cache.NewEvictionPolicy(cfg.Cache.EvictionPolicy)
+	if err != nil {
+		log.Fatalf("Invalid cache eviction policy: %v", err)
+	}
+
+	lf.Append(logger.Any("max_entries", cfg.Cache.MaxEntries))
+	lf.Append(logger.Any("max_bytes", cfg.Cache.MaxBytes))
+	lf.Append(logger.Any("eviction_policy", cfg.Cache.EvictionPolicy))
+
 	logger.Info("Memory cache initialized successfully", lf)
 	logger.Info("⚠️  Memory cache is for development only, use Redis in production", lf)
 
-	return cache.NewMemoryCache()
+	return cache.NewMemoryCacheWithConfig(cache.MemoryConfig{
+		MaxEntries: cfg.Cache.MaxEntries,
+		MaxBytes:   cfg.Cache.MaxBytes,
+		Policy:     policy,
+	})
 }
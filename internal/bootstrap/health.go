@@ -0,0 +1,60 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
+	"github.com/hanifkf12/hanif_skeleton/pkg/health"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/queue"
+	"github.com/hanifkf12/hanif_skeleton/pkg/storage"
+)
+
+// storageHealthCheckPath is probed with Exists, the file does not need to exist
+const storageHealthCheckPath = ".healthcheck"
+
+// RegistryHealth creates the health registry that bootstrapped dependencies register checks with
+func RegistryHealth(cfg *config.Config) health.Health {
+	lf := logger.NewFields("RegistryHealth")
+	lf.Append(logger.Any("check_timeout", cfg.Health.CheckTimeout.String()))
+	lf.Append(logger.Any("cache_ttl", cfg.Health.CacheTTL.String()))
+
+	h := health.NewHealth(health.Config{
+		Timeout:  cfg.Health.CheckTimeout,
+		CacheTTL: cfg.Health.CacheTTL,
+	})
+
+	logger.Info("Health registry initialized successfully", lf)
+	return h
+}
+
+// DatabaseHealthCheck pings the database connection pool
+func DatabaseHealthCheck(db databasex.Database) health.Checker {
+	return func(ctx context.Context) error {
+		return db.Ping(ctx)
+	}
+}
+
+// CacheHealthCheck pings the cache backend
+func CacheHealthCheck(c cache.Cache) health.Checker {
+	return func(ctx context.Context) error {
+		return c.Ping(ctx)
+	}
+}
+
+// StorageHealthCheck verifies the storage backend answers a metadata request
+func StorageHealthCheck(s storage.Storage) health.Checker {
+	return func(ctx context.Context) error {
+		_, err := s.Exists(ctx, storageHealthCheckPath)
+		return err
+	}
+}
+
+// QueueHealthCheck pings the queue broker
+func QueueHealthCheck(q queue.Queue) health.Checker {
+	return func(ctx context.Context) error {
+		return q.Ping(ctx)
+	}
+}
@@ -4,18 +4,31 @@ import (
 	"log"
 	"time"
 
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
 )
 
 // RegistryJWT creates and returns a JWT instance based on configuration
-func RegistryJWT(cfg *config.Config) jwt.JWT {
+// Revoked and rotated tokens are tracked in store, which must be shared by every instance
+func RegistryJWT(cfg *config.Config, store cache.Cache) jwt.JWT {
 	lf := logger.NewFields("RegistryJWT")
 
+	// Asymmetric keys let other services verify tokens through /.well-known/jwks.json
+	var keys []jwt.Key
+	if cfg.JWT.KeysFile != "" {
+		loaded, err := jwt.LoadKeys(cfg.JWT.KeysFile)
+		if err != nil {
+			log.Fatalf("Failed to load JWT keys: %v", err)
+		}
+		keys = loaded
+		lf.Append(logger.Any("keys", len(keys)))
+	}
+
 	secretKey := cfg.JWT.SecretKey
-	if secretKey == "" {
-		log.Fatal("JWT_SECRET_KEY is required. Generate one using: openssl rand -base64 32")
+	if secretKey == "" && len(keys) == 0 {
+		log.Fatal("JWT_SECRET_KEY or JWT_KEYS_FILE is required. Generate a secret using: openssl rand -base64 32")
 	}
 
 	issuer := cfg.JWT.Issuer
@@ -28,13 +41,27 @@ func RegistryJWT(cfg *config.Config) jwt.JWT {
 		expiry = 24 * time.Hour // Default 24 hours
 	}
 
+	refreshExpiry := cfg.JWT.RefreshExpiry
+	if refreshExpiry == 0 {
+		refreshExpiry = 7 * 24 * time.Hour // Default 7 days
+	}
+
 	lf.Append(logger.Any("issuer", issuer))
 	lf.Append(logger.Any("expiry", expiry.String()))
+	lf.Append(logger.Any("refresh_expiry", refreshExpiry.String()))
+	lf.Append(logger.Any("audience", cfg.JWT.Audience))
+	lf.Append(logger.Any("expected_audience", cfg.JWT.ExpectedAudience))
 
 	jwtInstance, err := jwt.NewJWT(jwt.Config{
-		SecretKey: secretKey,
-		Issuer:    issuer,
-		Expiry:    expiry,
+		SecretKey:        secretKey,
+		Keys:             keys,
+		Issuer:           issuer,
+		Expiry:           expiry,
+		RefreshExpiry:    refreshExpiry,
+		Audience:         cfg.JWT.Audience,
+		ExpectedAudience: cfg.JWT.ExpectedAudience,
+		Leeway:           cfg.JWT.Leeway,
+		Store:            jwt.NewRevocationStore(store),
 	})
 
 	if err != nil {
@@ -0,0 +1,40 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/httpclient"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/oidc"
+)
+
+// RegistryOIDCProviders creates the configured OpenID Connect providers by name, empty when none are configured
+func RegistryOIDCProviders(cfg *config.Config, client httpclient.HTTPClient) map[string]oidc.Provider {
+	lf := logger.NewFields("RegistryOIDCProviders")
+
+	providers := map[string]oidc.Provider{}
+
+	path := cfg.OIDC.ProvidersFile
+	if path == "" {
+		logger.Info("No OIDC providers configured", lf)
+		return providers
+	}
+
+	configs, err := oidc.LoadProviders(path)
+	if err != nil {
+		log.Fatalf("Failed to load OIDC providers: %v", err)
+	}
+
+	names := make([]string, 0, len(configs))
+	for _, c := range configs {
+		// Discovery happens on first use, a provider that is down does not stop startup
+		providers[c.Name] = oidc.NewProvider(client, c)
+		names = append(names, c.Name)
+	}
+
+	lf.Append(logger.Any("providers_file", path))
+	lf.Append(logger.Any("providers", names))
+	logger.Info("OIDC providers initialized successfully", lf)
+	return providers
+}
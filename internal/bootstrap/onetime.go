@@ -0,0 +1,23 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/onetime"
+)
+
+// RegistryOneTimeTokens creates the store for password reset and email verification tokens
+func RegistryOneTimeTokens(cfg *config.Config, store cache.Cache) onetime.Store {
+	lf := logger.NewFields("RegistryOneTimeTokens")
+
+	secret := cfg.Account.TokenSecret
+	if secret == "" {
+		log.Fatal("ACCOUNT_TOKEN_SECRET is required. Generate one using: openssl rand -base64 32")
+	}
+
+	logger.Info("One-time token store initialized successfully", lf)
+	return onetime.NewStore(store, secret)
+}
@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"log"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
+)
+
+// RegistryCursorCodec creates the codec signing pagination cursor tokens
+func RegistryCursorCodec(cfg *config.Config) sqlbuilder.CursorCodec {
+	lf := logger.NewFields("RegistryCursorCodec")
+
+	secret := cfg.Pagination.CursorSecret
+	if secret == "" {
+		log.Fatal("PAGINATION_CURSOR_SECRET is required. Generate one using: openssl rand -base64 32")
+	}
+
+	logger.Info("Cursor codec initialized successfully", lf)
+	return sqlbuilder.NewCursorCodec(secret)
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// APIKey is a key for service to service access, only the hash of the secret is stored
+type APIKey struct {
+	Id         int64      `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"` // Identifies the key in lists and logs
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     string     `json:"-" db:"scopes"` // Space separated, like the JWT scope claim
+	CreatedBy  *int64     `json:"created_by" db:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// ScopeList splits the space separated scopes
+func (k APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" validate:"required,max=100"`
+	Scopes    []string `json:"scopes" validate:"required,min=1,dive,required,excludesall= "`
+	ExpiresIn string   `json:"expires_in"` // Duration such as "720h", empty never expires
+}
+
+type APIKeyIDRequest struct {
+	ID int64 `params:"id" validate:"required"`
+}
+
+// APIKeyResponse describes a key without its secret
+type APIKeyResponse struct {
+	APIKey
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse carries the key itself, which is shown once and cannot be recovered
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
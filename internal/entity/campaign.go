@@ -24,4 +24,8 @@ type UpdateCampaignRequest struct {
 	Name           string    `json:"name" validate:"required"`
 	TargetDonation float64   `json:"target_donation" validate:"required,gt=0"`
 	EndDate        time.Time `json:"end_date" validate:"required,gt=now"`
-}
\ No newline at end of file
+}
+
+type DeleteCampaignRequest struct {
+	ID string `params:"id" validate:"required,uuid"`
+}
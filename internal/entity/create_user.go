@@ -1,9 +1,13 @@
 package entity
 
 type CreateUserRequest struct {
+	Name     string `json:"name" validate:"omitempty,max=100"` // Defaults to the username
 	Username string `json:"username" validate:"required"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+
+	// PasswordHash is set by the usecase, Password is never stored
+	PasswordHash string `json:"-"`
 }
 
 type CreateUserResponse struct {
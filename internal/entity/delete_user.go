@@ -1,5 +1,9 @@
 package entity
 
+type DeleteUserRequest struct {
+	ID int64 `params:"id" validate:"required"`
+}
+
 type DeleteUserResponse struct {
 	Message string `json:"message"`
 	ID      int64  `json:"id"`
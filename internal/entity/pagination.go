@@ -0,0 +1,8 @@
+package entity
+
+// PageRequest selects a page of a cursor paginated list
+// Cursor is the next_cursor or prev_cursor of a previous page, empty for the first page
+type PageRequest struct {
+	Cursor string `query:"cursor"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// RecoveryCode is a single use bcrypt hashed code that replaces a TOTP code when the authenticator is lost
+type RecoveryCode struct {
+	Id        int        `json:"id" db:"id"`
+	UserID    int64      `json:"user_id" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	UsedAt    *time.Time `json:"used_at" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
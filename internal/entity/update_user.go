@@ -1,10 +1,13 @@
 package entity
 
 type UpdateUserRequest struct {
-	ID       int64  `json:"id" validate:"required"`
+	ID       int64  `json:"id" params:"id" validate:"required"`
 	Username string `json:"username,omitempty"`
 	Email    string `json:"email,omitempty" validate:"omitempty,email"`
 	Password string `json:"password,omitempty" validate:"omitempty,min=6"`
+
+	// PasswordHash is set by the usecase when Password is given, Password is never stored
+	PasswordHash string `json:"-"`
 }
 
 type UpdateUserResponse struct {
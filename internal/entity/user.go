@@ -3,10 +3,15 @@ package entity
 import "time"
 
 type User struct {
-	Id        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	Username  string    `json:"username" db:"username"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	Id              int        `json:"id" db:"id"`
+	Name            string     `json:"name" db:"name"`
+	Email           string     `json:"email" db:"email"`
+	Username        string     `json:"username" db:"username"`
+	PasswordHash    string     `json:"-" db:"password_hash"`
+	Role            string     `json:"role" db:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at" db:"email_verified_at"`
+	MFASecret       string     `json:"-" db:"mfa_secret"` // Encrypted TOTP secret, set on enrolment
+	MFAEnabledAt    *time.Time `json:"mfa_enabled_at" db:"mfa_enabled_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 }
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// UserIdentity links a user to an account at an external OpenID Connect provider
+type UserIdentity struct {
+	Id        int       `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	Provider  string    `json:"provider" db:"provider"`
+	Subject   string    `json:"subject" db:"subject"` // The sub claim, stable per provider unlike the email
+	Email     string    `json:"email" db:"email"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
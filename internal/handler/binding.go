@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
+	"github.com/hanifkf12/hanif_skeleton/pkg/binding"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// BindRequest binds and validates the request into T before the usecase runs
+// The usecase receives *T in data.Request, invalid requests never reach Serve:
+//
+//	rtr.fiber.Post("/campaigns", rtr.handle(handler.BindRequest[entity.CreateCampaignRequest], createCampaignUseCase))
+func BindRequest[T any](xCtx *fiber.Ctx, svc contract.UseCase, conf *config.Config) appctx.Response {
+	req := new(T)
+	if err := binding.Bind(xCtx, req); err != nil {
+		return bindErrorResponse(xCtx, err)
+	}
+
+	data := appctx.Data{
+		FiberCtx: xCtx,
+		Cfg:      conf,
+		Request:  req,
+	}
+
+	return svc.Serve(data)
+}
+
+// Errors returned for requests and payloads that cannot be bound
+var (
+	ErrValidationFailed = apperror.Validation("validation_failed", "Validation failed")
+	ErrInvalidRequest   = apperror.Validation("invalid_request", "Invalid request")
+	ErrMalformedPayload = apperror.Permanent("malformed_payload", "Malformed payload")
+)
+
+// bindError classifies a binding failure, field errors are returned to the client as details
+func bindError(err error) error {
+	var verrs binding.ValidationErrors
+	if errors.As(err, &verrs) {
+		return ErrValidationFailed.WithDetails(verrs).Wrap(err)
+	}
+
+	var bindErr *binding.BindError
+	if errors.As(err, &bindErr) {
+		return ErrInvalidRequest.WithDetails(bindErr.Error()).Wrap(err)
+	}
+
+	return err
+}
+
+func bindErrorResponse(xCtx *fiber.Ctx, err error) appctx.Response {
+	lf := logger.NewFields("Handler.BindRequest")
+	lf.Append(logger.Any("path", xCtx.Path()))
+	lf.Append(logger.Any("method", xCtx.Method()))
+	lf.Append(logger.Any("error", err.Error()))
+	logger.Info("Failed to bind request", lf)
+
+	resp := appctx.NewResponse().WithError(bindError(err))
+
+	// Keep the parser's status, e.g. 413 for an oversized body
+	var bindErr *binding.BindError
+	if errors.As(err, &bindErr) {
+		resp.WithCode(bindErr.Code)
+	}
+
+	return *resp
+}
@@ -0,0 +1,15 @@
+package handler
+
+import "reflect"
+
+// Description is what a usecase tells API docs about itself
+type Description struct {
+	Request     reflect.Type
+	Response    reflect.Type
+	SuccessCode int
+}
+
+// Describer is implemented by usecases that know their request and response types, e.g. HttpUseCase
+type Describer interface {
+	Describe() Description
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/binding"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/queue"
+)
+
+// HttpUseCase adapts a TypedUseCase to contract.UseCase so it can be routed with handler.HttpRequest
+// The request is bound and validated like handler.BindRequest, successCode is returned with the result
+//
+//	rtr.fiber.Post("/campaigns", rtr.handle(handler.HttpRequest, handler.HttpUseCase(createCampaignUseCase, fiber.StatusCreated)))
+func HttpUseCase[Req, Resp any](uc contract.TypedUseCase[Req, Resp], successCode int) contract.UseCase {
+	return &httpUseCase[Req, Resp]{
+		uc:          uc,
+		successCode: successCode,
+	}
+}
+
+type httpUseCase[Req, Resp any] struct {
+	uc          contract.TypedUseCase[Req, Resp]
+	successCode int
+}
+
+// Describe exposes the request and response types for API docs
+func (h *httpUseCase[Req, Resp]) Describe() Description {
+	return Description{
+		Request:     reflect.TypeOf((*Req)(nil)).Elem(),
+		Response:    reflect.TypeOf((*Resp)(nil)).Elem(),
+		SuccessCode: h.successCode,
+	}
+}
+
+func (h *httpUseCase[Req, Resp]) Serve(data appctx.Data) appctx.Response {
+	// Already bound when routed with BindRequest
+	req, ok := data.Request.(*Req)
+	if !ok {
+		req = new(Req)
+		if err := binding.Bind(data.FiberCtx, req); err != nil {
+			return bindErrorResponse(data.FiberCtx, err)
+		}
+	}
+
+	resp, err := h.uc.Execute(data.FiberCtx.UserContext(), *req)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	return *appctx.NewResponse().WithCode(h.successCode).WithData(resp)
+}
+
+// PubSubUseCase adapts a TypedUseCase to contract.PubSubConsumer, the message data is decoded as JSON into Req
+func PubSubUseCase[Req, Resp any](uc contract.TypedUseCase[Req, Resp]) contract.PubSubConsumer {
+	return &pubSubUseCase[Req, Resp]{
+		uc: uc,
+	}
+}
+
+type pubSubUseCase[Req, Resp any] struct {
+	uc contract.TypedUseCase[Req, Resp]
+}
+
+func (p *pubSubUseCase[Req, Resp]) Consume(data appctx.PubSubData) appctx.PubSubResponse {
+	req, err := decodePayload[Req](data.Message.Data)
+	if err != nil {
+		lf := logger.NewFields("Handler.PubSubUseCase")
+		lf.Append(logger.Any("message_id", data.Message.ID))
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Invalid message data", lf)
+		return *appctx.NewPubSubResponse().WithError(err)
+	}
+
+	if _, err := p.uc.Execute(data.Ctx, *req); err != nil {
+		return *appctx.NewPubSubResponse().WithError(err)
+	}
+
+	return *appctx.NewPubSubResponse()
+}
+
+// JobUseCase adapts a TypedUseCase to queue.JobHandler, the job payload is decoded as JSON into Req
+// The result is discarded, a returned error lets the queue retry the job unless apperror reports it as not retryable
+func JobUseCase[Req, Resp any](uc contract.TypedUseCase[Req, Resp]) queue.JobHandler {
+	return func(ctx context.Context, payload []byte) error {
+		req, err := decodePayload[Req](payload)
+		if err != nil {
+			lf := logger.NewFields("Handler.JobUseCase")
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Invalid job payload", lf)
+			return err
+		}
+
+		_, err = uc.Execute(ctx, *req)
+		return err
+	}
+}
+
+// decodePayload unmarshals and validates a JSON encoded request
+// Both failures are permanent, redelivering the same payload can never succeed
+func decodePayload[Req any](payload []byte) (*Req, error) {
+	req := new(Req)
+	if err := json.Unmarshal(payload, req); err != nil {
+		return nil, ErrMalformedPayload.Wrap(err)
+	}
+
+	if err := binding.Validate(req); err != nil {
+		return nil, bindError(err)
+	}
+
+	return req, nil
+}
@@ -47,7 +47,7 @@ func (j *GenerateReportJob) Handle(ctx context.Context, payload []byte) error {
 	if err := json.Unmarshal(payload, &data); err != nil {
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to unmarshal payload", lf)
-		return fmt.Errorf("failed to unmarshal payload: %w", err)
+		return ErrMalformedPayload.Wrap(err)
 	}
 
 	lf.Append(logger.Any("report_type", data.ReportType))
@@ -1,5 +1,10 @@
 package jobs
 
+import "github.com/hanifkf12/hanif_skeleton/pkg/apperror"
+
+// ErrMalformedPayload is returned for payloads that cannot be decoded, the job is not retried
+var ErrMalformedPayload = apperror.Permanent("malformed_payload", "Malformed payload")
+
 // Job type constants
 const (
 	// JobTypeSendEmail is the job type for sending emails
@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"errors"
+	"slices"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apikey"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
@@ -13,6 +16,20 @@ import (
 // JWTAuth validates JWT token from Authorization header
 // Returns 200 if valid, 401 if invalid
 func JWTAuth(jwtInstance jwt.JWT) Middleware {
+	authenticate := JWTAuthAs[jwt.Claims](jwtInstance)
+
+	// Own closure so the route table lists it as JWTAuth
+	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
+		return authenticate(ctx, cfg)
+	}
+}
+
+// JWTAuthAs validates a JWT token carrying custom claims, which are stored as "claims" in context
+// Standard claims are stored under their own keys as with JWTAuth
+func JWTAuthAs[T any, P interface {
+	*T
+	jwt.CustomClaims
+}](jwtInstance jwt.JWT) Middleware {
 	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
 		lf := logger.NewFields("Middleware.JWTAuth")
 
@@ -46,7 +63,7 @@ func JWTAuth(jwtInstance jwt.JWT) Middleware {
 		}
 
 		// Parse and validate JWT token
-		claims, err := jwtInstance.Parse(token)
+		custom, err := jwt.ParseAs[T, P](jwtInstance, token)
 		if err != nil {
 			lf.Append(logger.Any("error", err.Error()))
 			logger.Error("JWT auth validation failed", lf)
@@ -62,12 +79,34 @@ func JWTAuth(jwtInstance jwt.JWT) Middleware {
 				WithErrors(errorMsg)
 		}
 
+		claims := P(custom).Base()
+
+		// Logged out tokens and reused refresh token families are denied until they expire
+		revoked, err := jwtInstance.IsRevoked(ctx.UserContext(), claims)
+		if err != nil {
+			// Fail closed, unlike rate limiting a revoked token must never pass during a cache outage
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("JWT revocation check failed", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusServiceUnavailable).
+				WithErrors("Authentication temporarily unavailable")
+		}
+		if revoked {
+			lf.Append(logger.Any("error", "token revoked"))
+			lf.Append(logger.Any("user_id", claims.UserID))
+			logger.Error("JWT auth validation failed", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusUnauthorized).
+				WithErrors("Token revoked")
+		}
+
 		// Store claims in context for later use in handlers
 		ctx.Locals("user_id", claims.UserID)
 		ctx.Locals("username", claims.Username)
 		ctx.Locals("email", claims.Email)
 		ctx.Locals("role", claims.Role)
-		ctx.Locals("claims", claims)
+		ctx.Locals("scopes", claims.Scopes())
+		ctx.Locals("claims", P(custom))
 
 		lf.Append(logger.Any("user_id", claims.UserID))
 		lf.Append(logger.Any("username", claims.Username))
@@ -81,6 +120,8 @@ func JWTAuth(jwtInstance jwt.JWT) Middleware {
 
 // RequireRole validates user role from JWT claims
 // Must be used after JWTAuth middleware
+//
+// Deprecated: Use RequirePermission, roles map to permissions in the authorization policy
 func RequireRole(allowedRoles []string) Middleware {
 	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
 		lf := logger.NewFields("Middleware.RequireRole")
@@ -120,6 +161,74 @@ func RequireRole(allowedRoles []string) Middleware {
 	}
 }
 
+// RequireScope validates that the JWT or API key grants every scope
+// Must be used after JWTAuth or APIKeyAuth middleware
+func RequireScope(scopes ...string) Middleware {
+	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
+		lf := logger.NewFields("Middleware.RequireScope")
+
+		// Get scopes from context (set by JWTAuth or APIKeyAuth middleware)
+		granted, ok := ctx.Locals("scopes").([]string)
+		if !ok {
+			lf.Append(logger.Any("error", "scopes not found in context"))
+			logger.Error("Scope validation failed", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusForbidden).
+				WithErrors("Scopes not found")
+		}
+
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				lf.Append(logger.Any("granted_scopes", granted))
+				lf.Append(logger.Any("required_scopes", scopes))
+				logger.Error("Scope validation failed - insufficient scope", lf)
+				return *appctx.NewResponse().
+					WithCode(fiber.StatusForbidden).
+					WithErrors("Insufficient scope")
+			}
+		}
+
+		lf.Append(logger.Any("scopes", scopes))
+		logger.Info("Scope validation successful", lf)
+
+		return *appctx.NewResponse().WithCode(fiber.StatusOK)
+	}
+}
+
+// RequireMFA validates that the JWT was issued after a two-factor login
+// Must be used after JWTAuth middleware
+func RequireMFA() Middleware {
+	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
+		lf := logger.NewFields("Middleware.RequireMFA")
+
+		// Get claims from context (set by JWTAuth middleware)
+		custom, ok := ctx.Locals("claims").(jwt.CustomClaims)
+		if !ok {
+			lf.Append(logger.Any("error", "claims not found in context"))
+			logger.Error("MFA validation failed", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusForbidden).
+				WithErrors("Claims not found")
+		}
+
+		claims := custom.Base()
+		// A recovery code passes the second factor too, amr keeps which one was used
+		if !claims.AuthenticatedWith(jwt.AMROTP) && !claims.AuthenticatedWith(jwt.AMRRecovery) {
+			lf.Append(logger.Any("user_id", claims.UserID))
+			lf.Append(logger.Any("amr", claims.AMR))
+			logger.Error("MFA validation failed - two-factor login required", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusForbidden).
+				WithErrors("Two-factor authentication required")
+		}
+
+		lf.Append(logger.Any("user_id", claims.UserID))
+		logger.Info("MFA validation successful", lf)
+
+		return *appctx.NewResponse().WithCode(fiber.StatusOK)
+	}
+}
+
 // BearerAuth validates Bearer token from Authorization header (Simple version without JWT)
 // Returns 200 if valid, 401 if invalid
 // Note: Use JWTAuth for JWT-based authentication
@@ -183,15 +292,16 @@ func BearerAuth(validTokens []string) Middleware {
 	}
 }
 
-// APIKeyAuth validates API key from header
-// Returns 200 if valid, 401 if invalid
-func APIKeyAuth(headerName string, validKeys []string) Middleware {
+// APIKeyAuth validates an API key from header against the stored keys
+// The key's scopes are stored in context like JWT scopes, so RequireScope applies to both
+// Returns 200 if valid, 401 if invalid, expired or revoked
+func APIKeyAuth(headerName string, resolver apikey.Resolver) Middleware {
 	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
 		lf := logger.NewFields("Middleware.APIKeyAuth")
 
 		// Get API key from header
-		apiKey := ctx.Get(headerName)
-		if apiKey == "" {
+		token := ctx.Get(headerName)
+		if token == "" {
 			lf.Append(logger.Any("error", "missing API key header"))
 			lf.Append(logger.Any("header", headerName))
 			logger.Error("API key validation failed", lf)
@@ -200,28 +310,43 @@ func APIKeyAuth(headerName string, validKeys []string) Middleware {
 				WithErrors("Missing API key")
 		}
 
-		// Validate API key
-		valid := false
-		for _, validKey := range validKeys {
-			if apiKey == validKey {
-				valid = true
-				break
+		key, err := resolver.Resolve(ctx.UserContext(), token)
+		if err != nil {
+			lf.Append(logger.Any("error", err.Error()))
+
+			switch {
+			case errors.Is(err, apikey.ErrKeyExpired):
+				logger.Error("API key validation failed", lf)
+				return *appctx.NewResponse().
+					WithCode(fiber.StatusUnauthorized).
+					WithErrors("API key expired")
+			case errors.Is(err, apikey.ErrKeyRevoked):
+				logger.Error("API key validation failed", lf)
+				return *appctx.NewResponse().
+					WithCode(fiber.StatusUnauthorized).
+					WithErrors("API key revoked")
+			case errors.Is(err, apikey.ErrInvalidKey):
+				logger.Error("API key validation failed", lf)
+				return *appctx.NewResponse().
+					WithCode(fiber.StatusUnauthorized).
+					WithErrors("Invalid API key")
 			}
-		}
 
-		if !valid {
-			lf.Append(logger.Any("error", "invalid API key"))
-			logger.Error("API key validation failed", lf)
+			// Fail closed, the key store being down must not let unknown keys through
+			logger.Error("API key lookup failed", lf)
 			return *appctx.NewResponse().
-				WithCode(fiber.StatusUnauthorized).
-				WithErrors("Invalid API key")
+				WithCode(fiber.StatusServiceUnavailable).
+				WithErrors("Authentication temporarily unavailable")
 		}
 
+		lf.Append(logger.Any("api_key_id", key.ID))
 		lf.Append(logger.Any("path", ctx.Path()))
 		logger.Info("API key validation successful", lf)
 
-		// Store API key in context for later use
-		ctx.Locals("api_key", apiKey)
+		// Store key in context for later use, the raw key is never stored
+		ctx.Locals("api_key_id", key.ID)
+		ctx.Locals("api_key_name", key.Name)
+		ctx.Locals("scopes", key.Scopes)
 
 		return *appctx.NewResponse().WithCode(fiber.StatusOK)
 	}
@@ -1,72 +1,159 @@
 package middleware
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/signature"
 )
 
-// HMACAuth validates HMAC signature from request headers
-// Expects headers:
-//   - X-Signature: HMAC signature
-//   - X-Timestamp: Request timestamp
-//
-// Returns 200 if valid, 401 if invalid
-func HMACAuth(secretKey string) Middleware {
-	return func(ctx *fiber.Ctx, cfg *config.Config) appctx.Response {
+// Defaults when none are configured
+const (
+	defaultHMACTolerance = 5 * time.Minute
+
+	// Schemes without a timestamp never expire, their nonces are remembered this long
+	defaultHMACReplayWindow = 24 * time.Hour
+)
+
+// HMACConfig configures HMAC signature verification
+type HMACConfig struct {
+	// Scheme is the signature format, signature.Default, signature.GitHub or signature.Stripe
+	Scheme signature.Scheme
+
+	// Secrets are accepted for every sender, several while a secret is rotated
+	Secrets []string
+
+	// ClientHeader selects the sender's secrets from Clients, e.g. "X-Client-ID"
+	ClientHeader string
+	Clients      map[string][]string
+
+	// Tolerance is how far the timestamp may be from now, in either direction
+	Tolerance time.Duration
+
+	// ReplayWindow is how long nonces are remembered, defaults to twice Tolerance
+	ReplayWindow time.Duration
+}
+
+// HMACAuth validates the HMAC signature of a request, e.g. from a webhook sender
+// Requests are rejected when the timestamp is outside the tolerance or the nonce was seen before,
+// nonces are remembered in store which must be shared across instances (Redis)
+// Returns 200 if valid, 401 if invalid or replayed, 503 if the replay store is unavailable
+func HMACAuth(store cache.Cache, cfg HMACConfig) Middleware {
+	scheme := cfg.Scheme
+	if scheme.Canonical == nil {
+		scheme = signature.Default
+	}
+
+	tolerance := cfg.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultHMACTolerance
+	}
+
+	replayWindow := cfg.ReplayWindow
+	if replayWindow <= 0 {
+		replayWindow = 2 * tolerance
+		if !scheme.HasTimestamp() {
+			replayWindow = defaultHMACReplayWindow
+		}
+	}
+
+	seen := cache.NewCacheKey("hmac:seen")
+
+	return func(ctx *fiber.Ctx, config *config.Config) appctx.Response {
 		lf := logger.NewFields("Middleware.HMACAuth")
+		lf.Append(logger.Any("path", ctx.Path()))
 
-		// Get signature from header
-		signature := ctx.Get("X-Signature")
-		if signature == "" {
-			lf.Append(logger.Any("error", "missing X-Signature header"))
+		// Pick the secrets of the sender
+		client := ""
+		secrets := cfg.Secrets
+		if cfg.ClientHeader != "" {
+			client = ctx.Get(cfg.ClientHeader)
+			secrets = cfg.Clients[client]
+			lf.Append(logger.Any("client", client))
+		}
+		if len(secrets) == 0 {
+			lf.Append(logger.Any("error", "unknown client"))
 			logger.Error("HMAC validation failed", lf)
 			return *appctx.NewResponse().
 				WithCode(fiber.StatusUnauthorized).
-				WithErrors("Missing signature")
+				WithErrors("Unknown client")
 		}
 
-		// Get timestamp
-		timestamp := ctx.Get("X-Timestamp")
-		if timestamp == "" {
-			lf.Append(logger.Any("error", "missing X-Timestamp header"))
+		signed, err := scheme.Parse(func(name string) string { return ctx.Get(name) })
+		if err != nil {
+			lf.Append(logger.Any("error", err.Error()))
 			logger.Error("HMAC validation failed", lf)
+
+			errorMsg := "Missing signature"
+			if errors.Is(err, signature.ErrMissingTimestamp) {
+				errorMsg = "Missing timestamp"
+			}
 			return *appctx.NewResponse().
 				WithCode(fiber.StatusUnauthorized).
-				WithErrors("Missing timestamp")
+				WithErrors(errorMsg)
 		}
 
-		// Get request body
-		body := ctx.Body()
-
-		// Create message to sign: method + path + timestamp + body
-		message := ctx.Method() + ctx.Path() + timestamp + string(body)
+		if scheme.HasTimestamp() {
+			if err := signature.CheckTimestamp(signed.Timestamp, time.Now(), tolerance); err != nil {
+				lf.Append(logger.Any("error", err.Error()))
+				lf.Append(logger.Any("timestamp", signed.Timestamp))
+				logger.Error("HMAC validation failed", lf)
+				return *appctx.NewResponse().
+					WithCode(fiber.StatusUnauthorized).
+					WithErrors("Invalid or expired timestamp")
+			}
+		}
 
-		// Calculate HMAC
-		h := hmac.New(sha256.New, []byte(secretKey))
-		h.Write([]byte(message))
-		expectedSignature := hex.EncodeToString(h.Sum(nil))
+		req := signature.Request{
+			Method:    ctx.Method(),
+			Path:      ctx.Path(),
+			Timestamp: signed.Timestamp,
+			Nonce:     signed.Nonce,
+			Body:      ctx.Body(),
+		}
 
-		// Compare signatures
-		if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+		// Signatures are never logged, a logged expected signature is a valid one
+		if !scheme.Verify(req, signed.Signatures, secrets) {
 			lf.Append(logger.Any("error", "invalid signature"))
-			lf.Append(logger.Any("expected", expectedSignature))
-			lf.Append(logger.Any("received", signature))
 			logger.Error("HMAC validation failed", lf)
 			return *appctx.NewResponse().
 				WithCode(fiber.StatusUnauthorized).
 				WithErrors("Invalid signature")
 		}
 
+		// Only verified requests are remembered, so forged ones cannot block a real nonce
+		// The key covers only what is signed, a nonce the scheme does not sign cannot make a replay look new
+		key := seen.Build(client, scheme.ReplayKey(req))
+
+		n, err := store.IncrByWithExpiry(ctx.UserContext(), key, 1, replayWindow)
+		if err != nil {
+			// Fail closed, senders retry on 5xx while a replay would be processed twice
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("HMAC replay check failed", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusServiceUnavailable).
+				WithErrors("Signature verification temporarily unavailable")
+		}
+		if n > 1 {
+			lf.Append(logger.Any("error", "replayed request"))
+			logger.Error("HMAC validation failed", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusUnauthorized).
+				WithErrors("Request already processed")
+		}
+
 		lf.Append(logger.Any("method", ctx.Method()))
-		lf.Append(logger.Any("path", ctx.Path()))
 		logger.Info("HMAC validation successful", lf)
 
+		if client != "" {
+			ctx.Locals("hmac_client", client)
+		}
+
 		return *appctx.NewResponse().WithCode(fiber.StatusOK)
 	}
 }
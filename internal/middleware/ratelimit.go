@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/ratelimit"
+)
+
+// RateLimitKeyFunc extracts the identity a request is limited by
+type RateLimitKeyFunc func(ctx *fiber.Ctx) string
+
+// RateLimitConfig holds rate limit configuration
+type RateLimitConfig struct {
+	Name        string           // Limit scope, routes sharing a name share counters (default "global")
+	MaxRequests int              // Maximum requests per window
+	WindowSize  int              // Window size in seconds
+	Algorithm   string           // sliding_window (default) or token_bucket
+	Burst       int              // Token bucket capacity, defaults to MaxRequests
+	KeyFunc     RateLimitKeyFunc // Identity extractor, defaults to KeyByIP
+}
+
+// KeyByIP limits requests per client IP
+func KeyByIP() RateLimitKeyFunc {
+	return func(ctx *fiber.Ctx) string {
+		return "ip:" + ctx.IP()
+	}
+}
+
+// KeyByUserID limits requests per authenticated user, falling back to client IP
+// Must be used after JWTAuth middleware
+func KeyByUserID() RateLimitKeyFunc {
+	return func(ctx *fiber.Ctx) string {
+		if userID, ok := ctx.Locals("user_id").(int64); ok {
+			return "user:" + strconv.FormatInt(userID, 10)
+		}
+		return "ip:" + ctx.IP()
+	}
+}
+
+// KeyByAPIKey limits requests per API key from header, falling back to client IP
+// The key is hashed so raw API keys never end up in cache key names
+func KeyByAPIKey(headerName string) RateLimitKeyFunc {
+	return func(ctx *fiber.Ctx) string {
+		apiKey := ctx.Get(headerName)
+		if apiKey == "" {
+			return "ip:" + ctx.IP()
+		}
+		sum := sha256.Sum256([]byte(apiKey))
+		return "apikey:" + hex.EncodeToString(sum[:8])
+	}
+}
+
+// RateLimit limits requests using the given cache as shared counter store
+// Sets RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// plus Retry-After when the limit is exceeded
+// Returns 200 if within limit, 429 if exceeded
+func RateLimit(store cache.Cache, cfg RateLimitConfig) Middleware {
+	name := cfg.Name
+	if name == "" {
+		name = "global"
+	}
+
+	keyFunc := cfg.KeyFunc
+	if keyFunc == nil {
+		keyFunc = KeyByIP()
+	}
+
+	limiter, err := ratelimit.New(store, ratelimit.Config{
+		Algorithm: cfg.Algorithm,
+		Limit:     cfg.MaxRequests,
+		Window:    time.Duration(cfg.WindowSize) * time.Second,
+		Burst:     cfg.Burst,
+		Prefix:    cache.NewCacheKey("ratelimit").Build(name),
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize rate limiter %s: %v", name, err)
+	}
+
+	return func(ctx *fiber.Ctx, config *config.Config) appctx.Response {
+		lf := logger.NewFields("Middleware.RateLimit")
+
+		key := keyFunc(ctx)
+		lf.Append(logger.Any("limiter", name))
+		lf.Append(logger.Any("key", key))
+
+		result, err := limiter.Allow(ctx.UserContext(), key)
+		if err != nil {
+			// Fail open so a cache outage does not take the API down
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Rate limit check failed, allowing request", lf)
+			return *appctx.NewResponse().WithCode(fiber.StatusOK)
+		}
+
+		ctx.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		ctx.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Set("RateLimit-Reset", formatSeconds(result.ResetAfter))
+
+		if !result.Allowed {
+			ctx.Set("Retry-After", formatSeconds(result.RetryAfter))
+			lf.Append(logger.Any("limit", result.Limit))
+			lf.Append(logger.Any("retry_after", result.RetryAfter.String()))
+			logger.Error("Rate limit exceeded", lf)
+			return *appctx.NewResponse().
+				WithCode(fiber.StatusTooManyRequests).
+				WithErrors("Rate limit exceeded")
+		}
+
+		logger.Info("Rate limit check passed", lf)
+		return *appctx.NewResponse().WithCode(fiber.StatusOK)
+	}
+}
+
+// formatSeconds rounds a duration up to whole seconds for rate limit headers
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -7,44 +7,6 @@ import (
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
 )
 
-// RateLimitConfig holds rate limit configuration
-type RateLimitConfig struct {
-	MaxRequests int
-	WindowSize  int // in seconds
-}
-
-// Simple in-memory rate limiter (for demo - use Redis in production)
-var requestCounts = make(map[string]int)
-
-// RateLimit limits requests per IP
-// Returns 200 if within limit, 429 if exceeded
-func RateLimit(cfg RateLimitConfig) Middleware {
-	return func(ctx *fiber.Ctx, config *config.Config) appctx.Response {
-		lf := logger.NewFields("Middleware.RateLimit")
-
-		// Get client IP
-		clientIP := ctx.IP()
-		lf.Append(logger.Any("client_ip", clientIP))
-
-		// Check rate limit (simplified - use Redis with expiry in production)
-		count := requestCounts[clientIP]
-		count++
-		requestCounts[clientIP] = count
-
-		if count > cfg.MaxRequests {
-			lf.Append(logger.Any("count", count))
-			lf.Append(logger.Any("max", cfg.MaxRequests))
-			logger.Error("Rate limit exceeded", lf)
-			return *appctx.NewResponse().
-				WithCode(fiber.StatusTooManyRequests).
-				WithErrors("Rate limit exceeded")
-		}
-
-		logger.Info("Rate limit check passed", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusOK)
-	}
-}
-
 // ContentTypeValidator validates Content-Type header
 // Returns 200 if valid, 415 if invalid
 func ContentTypeValidator(allowedTypes []string) Middleware {
@@ -0,0 +1,173 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/internal/repository"
+	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+)
+
+type apiKeyRepository struct {
+	db databasex.Database
+}
+
+// Create stores a key and returns its id
+func (a *apiKeyRepository) Create(ctx context.Context, key entity.APIKey) (int64, error) {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.Create")
+	defer span.End()
+
+	return insertAPIKey(ctx, a.db, key)
+}
+
+// List returns every key, revoked ones included, newest first
+func (a *apiKeyRepository) List(ctx context.Context) ([]entity.APIKey, error) {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.List")
+	defer span.End()
+
+	var keys []entity.APIKey
+
+	model := sqlbuilder.NewModel(a.db, &entity.APIKey{})
+	err := model.
+		Table("api_keys").
+		OrderBy("id", "DESC").
+		GetAll(ctx, &keys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetByID returns a key, sql.ErrNoRows when it does not exist
+func (a *apiKeyRepository) GetByID(ctx context.Context, id int64) (*entity.APIKey, error) {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.GetByID")
+	defer span.End()
+
+	return a.getBy(ctx, "id", id)
+}
+
+// GetByPrefix returns the key a presented token belongs to, sql.ErrNoRows when there is none
+func (a *apiKeyRepository) GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.GetByPrefix")
+	defer span.End()
+
+	return a.getBy(ctx, "prefix", prefix)
+}
+
+func (a *apiKeyRepository) getBy(ctx context.Context, column string, value interface{}) (*entity.APIKey, error) {
+	var key entity.APIKey
+
+	model := sqlbuilder.NewModel(a.db, &key)
+	err := model.
+		Table("api_keys").
+		Where(column+" = ?", value).
+		First(ctx, &key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// Revoke revokes a key, false when it does not exist or was already revoked
+func (a *apiKeyRepository) Revoke(ctx context.Context, id int64) (bool, error) {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.Revoke")
+	defer span.End()
+
+	now := time.Now()
+
+	model := sqlbuilder.NewModel(a.db, &entity.APIKey{})
+	result, err := model.
+		Table("api_keys").
+		Where("id = ?", id).
+		WhereNull("revoked_at").
+		UpdateWithFields(ctx, &entity.APIKey{RevokedAt: &now}, "revoked_at")
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// Rotate stores replacement and lets the old key expire at oldExpiresAt, so clients can switch over
+// sql.ErrNoRows when the old key is revoked or already expires by then, e.g. because it was rotated before
+func (a *apiKeyRepository) Rotate(ctx context.Context, id int64, replacement entity.APIKey, oldExpiresAt time.Time) (int64, error) {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.Rotate")
+	defer span.End()
+
+	var newID int64
+
+	err := a.db.Transact(ctx, sql.LevelDefault, func(tx databasex.Database) error {
+		// Concurrent rotations of the same key leave only one replacement
+		result, err := sqlbuilder.NewModel(tx, &entity.APIKey{}).
+			Table("api_keys").
+			Where("id = ?", id).
+			Where("revoked_at IS NULL").
+			Where("(expires_at IS NULL OR expires_at > ?)", oldExpiresAt).
+			UpdateWithFields(ctx, &entity.APIKey{ExpiresAt: &oldExpiresAt}, "expires_at")
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return sql.ErrNoRows
+		}
+
+		newID, err = insertAPIKey(ctx, tx, replacement)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// TouchLastUsed records when a key was last used
+func (a *apiKeyRepository) TouchLastUsed(ctx context.Context, id int64, t time.Time) error {
+	ctx, span := telemetry.StartSpan(ctx, "apiKeyRepository.TouchLastUsed")
+	defer span.End()
+
+	model := sqlbuilder.NewModel(a.db, &entity.APIKey{})
+	_, err := model.
+		Table("api_keys").
+		Where("id = ?", id).
+		UpdateWithFields(ctx, &entity.APIKey{LastUsedAt: &t}, "last_used_at")
+
+	return err
+}
+
+func insertAPIKey(ctx context.Context, db databasex.Database, key entity.APIKey) (int64, error) {
+	var id int64
+
+	query := "INSERT INTO api_keys (name, prefix, key_hash, scopes, created_by, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	if err := db.Get(ctx, &id, query, key.Name, key.Prefix, key.KeyHash, key.Scopes, key.CreatedBy, key.ExpiresAt); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func NewAPIKeyRepository(db databasex.Database) repository.APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
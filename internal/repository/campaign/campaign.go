@@ -98,6 +98,25 @@ func (c *campaignRepository) GetAll(ctx context.Context) ([]entity.Campaign, err
 	return campaigns, nil
 }
 
+// GetPage returns campaigns newest first using keyset pagination, id breaks ties between equal timestamps
+func (c *campaignRepository) GetPage(ctx context.Context, cursor *sqlbuilder.Cursor, limit int) ([]entity.Campaign, *sqlbuilder.CursorPage, error) {
+	ctx, span := telemetry.StartSpan(ctx, "CampaignRepository.GetPage")
+	defer span.End()
+
+	var campaigns []entity.Campaign
+
+	model := sqlbuilder.NewModel(c.db, &entity.Campaign{})
+	page, err := model.
+		Table("campaigns").
+		GetWithCursor(ctx, &campaigns, cursor, limit, sqlbuilder.Desc("created_at"), sqlbuilder.Desc("id"))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return campaigns, page, nil
+}
+
 func NewCampaignRepository(db databasex.Database) repository.CampaignRepository {
 	return &campaignRepository{
 		db: db,
@@ -164,6 +164,21 @@ func (c *campaignRepositoryV2) GetCampaignsPaginated(ctx context.Context, page,
 	return result, err
 }
 
+// GetPage - Get campaigns with keyset pagination, no COUNT query and deep pages stay fast
+func (c *campaignRepositoryV2) GetPage(ctx context.Context, cursor *sqlbuilder.Cursor, limit int) ([]entity.Campaign, *sqlbuilder.CursorPage, error) {
+	ctx, span := telemetry.StartSpan(ctx, "CampaignRepository.GetPage")
+	defer span.End()
+
+	var campaigns []entity.Campaign
+
+	model := sqlbuilder.NewModel(c.db, &entity.Campaign{})
+	page, err := model.
+		Table("campaigns").
+		GetWithCursor(ctx, &campaigns, cursor, limit, sqlbuilder.Desc("created_at"), sqlbuilder.Desc("id"))
+
+	return campaigns, page, err
+}
+
 // GetCampaignsByIDs - Get multiple campaigns by IDs
 func (c *campaignRepositoryV2) GetCampaignsByIDs(ctx context.Context, ids []string) ([]entity.Campaign, error) {
 	ctx, span := telemetry.StartSpan(ctx, "CampaignRepository.GetCampaignsByIDs")
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
 )
 
 type HomeRepository interface {
@@ -11,9 +14,22 @@ type HomeRepository interface {
 
 type UserRepository interface {
 	GetUsers(ctx context.Context) ([]entity.User, error)
+	GetUsersPage(ctx context.Context, cursor *sqlbuilder.Cursor, limit int) ([]entity.User, *sqlbuilder.CursorPage, error)
+	GetUserByID(ctx context.Context, id int64) (*entity.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	CreateUser(ctx context.Context, user entity.CreateUserRequest) (int64, error)
 	UpdateUser(ctx context.Context, user entity.UpdateUserRequest) error
 	DeleteUser(ctx context.Context, id int64) error
+	MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error)
+	SetMFASecret(ctx context.Context, id int64, secret string) error
+	EnableMFA(ctx context.Context, id int64, codeHashes []string) error
+	DisableMFA(ctx context.Context, id int64) error
+	GetRecoveryCodes(ctx context.Context, userID int64) ([]entity.RecoveryCode, error)
+	UseRecoveryCode(ctx context.Context, id int) (bool, error)
+	GetUserByIdentity(ctx context.Context, provider string, subject string) (*entity.User, error)
+	LinkIdentity(ctx context.Context, identity entity.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user entity.User, identity entity.UserIdentity) (int64, error)
 }
 
 type CampaignRepository interface {
@@ -22,4 +38,15 @@ type CampaignRepository interface {
 	Delete(ctx context.Context, id string) error
 	GetByID(ctx context.Context, id string) (*entity.Campaign, error)
 	GetAll(ctx context.Context) ([]entity.Campaign, error)
+	GetPage(ctx context.Context, cursor *sqlbuilder.Cursor, limit int) ([]entity.Campaign, *sqlbuilder.CursorPage, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key entity.APIKey) (int64, error)
+	List(ctx context.Context) ([]entity.APIKey, error)
+	GetByID(ctx context.Context, id int64) (*entity.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	Revoke(ctx context.Context, id int64) (bool, error)
+	Rotate(ctx context.Context, id int64, replacement entity.APIKey, oldExpiresAt time.Time) (int64, error)
+	TouchLastUsed(ctx context.Context, id int64, t time.Time) error
 }
@@ -2,22 +2,21 @@ package user
 
 import (
 	"context"
+
 	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
 )
 
+// CreateUser inserts a user with a password and returns its id
+// Postgres drivers do not report LastInsertId, the id is read back with RETURNING
 func (u *userRepository) CreateUser(ctx context.Context, user entity.CreateUserRequest) (int64, error) {
-	// Define insert query
-	query := "INSERT INTO users (username, email, password) VALUES ($1, $2, $3)"
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.CreateUser")
+	defer span.End()
 
-	// Execute query and get result
-	result, err := u.db.Exec(ctx, query, user.Username, user.Email, user.Password)
-	if err != nil {
-		return 0, err
-	}
+	var id int64
 
-	// Get last inserted ID
-	id, err := result.LastInsertId()
-	if err != nil {
+	query := "INSERT INTO users (name, username, email, password_hash) VALUES ($1, $2, $3, $4) RETURNING id"
+	if err := u.db.Get(ctx, &id, query, user.Name, user.Username, user.Email, user.PasswordHash); err != nil {
 		return 0, err
 	}
 
@@ -0,0 +1,114 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDB answers Get like Postgres answers INSERT ... RETURNING id, Exec results have no LastInsertId
+type recordingDB struct {
+	databasex.Database
+	query string
+	args  []interface{}
+}
+
+func (d *recordingDB) Get(ctx context.Context, dst interface{}, query string, args ...interface{}) error {
+	d.query, d.args = query, args
+	if !strings.Contains(query, "RETURNING id") {
+		return sql.ErrNoRows
+	}
+	*dst.(*int64) = 7
+	return nil
+}
+
+func (d *recordingDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.query, d.args = query, args
+	return noLastInsertID{}, nil
+}
+
+type noLastInsertID struct{}
+
+func (noLastInsertID) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by this driver")
+}
+
+func (noLastInsertID) RowsAffected() (int64, error) { return 1, nil }
+
+var (
+	tableStatement = regexp.MustCompile(`(?i)^\s*(?:CREATE|ALTER)\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(\w+)`)
+	columnLine     = regexp.MustCompile(`(?i)^\s*(?:ADD\s+COLUMN\s+)?(\w+)\s+\w`)
+	insertColumns  = regexp.MustCompile(`(?i)INSERT\s+INTO\s+users\s*\(([^)]*)\)`)
+)
+
+// requiredUserColumns lists the NOT NULL columns of users without a default, read from the Up migrations
+func requiredUserColumns(t *testing.T) []string {
+	files, err := filepath.Glob("../../../database/migration/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	var required []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		up, _, _ := strings.Cut(string(content), "-- +goose Down")
+		table := ""
+		for _, line := range strings.Split(up, "\n") {
+			if m := tableStatement.FindStringSubmatch(line); m != nil {
+				table = strings.ToLower(m[1])
+				continue
+			}
+
+			upper := strings.ToUpper(line)
+			if table != "users" || !strings.Contains(upper, "NOT NULL") || strings.Contains(upper, "DEFAULT") || strings.Contains(upper, "SERIAL") {
+				continue
+			}
+			if m := columnLine.FindStringSubmatch(line); m != nil {
+				required = append(required, strings.ToLower(m[1]))
+			}
+		}
+	}
+
+	return required
+}
+
+func TestCreateUser(t *testing.T) {
+	db := &recordingDB{}
+	repo := NewUserRepository(db)
+
+	id, err := repo.CreateUser(context.Background(), entity.CreateUserRequest{
+		Name:         "Jane Doe",
+		Username:     "jane",
+		Email:        "jane@example.com",
+		PasswordHash: "hash",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), id, "the id is read back with RETURNING")
+
+	m := insertColumns.FindStringSubmatch(db.query)
+	require.NotNil(t, m, "CreateUser inserts into users: %s", db.query)
+
+	columns := map[string]bool{}
+	for _, column := range strings.Split(m[1], ",") {
+		columns[strings.ToLower(strings.TrimSpace(column))] = true
+	}
+
+	required := requiredUserColumns(t)
+	require.Contains(t, required, "name")
+	for _, column := range required {
+		assert.True(t, columns[column], "NOT NULL column %q without default is not inserted", column)
+	}
+
+	assert.Contains(t, db.args, "Jane Doe")
+}
@@ -0,0 +1,73 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+)
+
+// GetUserByIdentity returns the user linked to subject at provider, sql.ErrNoRows when none is
+func (u *userRepository) GetUserByIdentity(ctx context.Context, provider string, subject string) (*entity.User, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.GetUserByIdentity")
+	defer span.End()
+
+	var identity entity.UserIdentity
+
+	model := sqlbuilder.NewModel(u.db, &identity)
+	err := model.
+		Table("user_identities").
+		Where("provider = ?", provider).
+		Where("subject = ?", subject).
+		First(ctx, &identity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return u.getUserBy(ctx, "id", identity.UserID)
+}
+
+// LinkIdentity links an external identity to an existing user
+func (u *userRepository) LinkIdentity(ctx context.Context, identity entity.UserIdentity) error {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.LinkIdentity")
+	defer span.End()
+
+	return insertIdentity(ctx, u.db, identity)
+}
+
+// CreateUserWithIdentity creates a user without a password together with its external identity
+// Name, username, email and email_verified_at are taken from user
+func (u *userRepository) CreateUserWithIdentity(ctx context.Context, user entity.User, identity entity.UserIdentity) (int64, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.CreateUserWithIdentity")
+	defer span.End()
+
+	var id int64
+
+	err := u.db.Transact(ctx, sql.LevelDefault, func(tx databasex.Database) error {
+		query := "INSERT INTO users (name, username, email, password_hash, email_verified_at) VALUES ($1, $2, $3, '', $4) RETURNING id"
+		if err := tx.Get(ctx, &id, query, user.Name, user.Username, user.Email, user.EmailVerifiedAt); err != nil {
+			return err
+		}
+
+		identity.UserID = id
+		return insertIdentity(ctx, tx, identity)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func insertIdentity(ctx context.Context, db databasex.Database, identity entity.UserIdentity) error {
+	_, err := sqlbuilder.NewModel(db, &entity.UserIdentity{}).
+		Table("user_identities").
+		InsertWithFields(ctx, &identity, "user_id", "provider", "subject", "email")
+
+	return err
+}
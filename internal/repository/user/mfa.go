@@ -0,0 +1,132 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+)
+
+// SetMFASecret stores the encrypted TOTP secret of a pending enrolment, MFA stays disabled until EnableMFA
+func (u *userRepository) SetMFASecret(ctx context.Context, id int64, secret string) error {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.SetMFASecret")
+	defer span.End()
+
+	model := sqlbuilder.NewModel(u.db, &entity.User{})
+	_, err := model.
+		Table("users").
+		Where("id = ?", id).
+		UpdateWithFields(ctx, &entity.User{MFASecret: secret}, "mfa_secret")
+
+	return err
+}
+
+// EnableMFA enables MFA and replaces the recovery codes with codeHashes
+func (u *userRepository) EnableMFA(ctx context.Context, id int64, codeHashes []string) error {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.EnableMFA")
+	defer span.End()
+
+	now := time.Now()
+
+	return u.db.Transact(ctx, sql.LevelDefault, func(tx databasex.Database) error {
+		_, err := sqlbuilder.NewModel(tx, &entity.User{}).
+			Table("users").
+			Where("id = ?", id).
+			UpdateWithFields(ctx, &entity.User{MFAEnabledAt: &now}, "mfa_enabled_at")
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, id, codeHashes)
+	})
+}
+
+// DisableMFA removes the TOTP secret and every recovery code
+func (u *userRepository) DisableMFA(ctx context.Context, id int64) error {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.DisableMFA")
+	defer span.End()
+
+	return u.db.Transact(ctx, sql.LevelDefault, func(tx databasex.Database) error {
+		_, err := sqlbuilder.NewModel(tx, &entity.User{}).
+			Table("users").
+			Where("id = ?", id).
+			UpdateWithFields(ctx, &entity.User{}, "mfa_secret", "mfa_enabled_at")
+		if err != nil {
+			return err
+		}
+
+		return replaceRecoveryCodes(ctx, tx, id, nil)
+	})
+}
+
+// GetRecoveryCodes returns the unused recovery codes of a user
+func (u *userRepository) GetRecoveryCodes(ctx context.Context, userID int64) ([]entity.RecoveryCode, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.GetRecoveryCodes")
+	defer span.End()
+
+	var codes []entity.RecoveryCode
+
+	model := sqlbuilder.NewModel(u.db, &entity.RecoveryCode{})
+	err := model.
+		Table("user_recovery_codes").
+		Where("user_id = ?", userID).
+		WhereNull("used_at").
+		GetAll(ctx, &codes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// UseRecoveryCode marks a recovery code used, false when it was already used concurrently
+func (u *userRepository) UseRecoveryCode(ctx context.Context, id int) (bool, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.UseRecoveryCode")
+	defer span.End()
+
+	now := time.Now()
+
+	model := sqlbuilder.NewModel(u.db, &entity.RecoveryCode{})
+	result, err := model.
+		Table("user_recovery_codes").
+		Where("id = ?", id).
+		WhereNull("used_at").
+		UpdateWithFields(ctx, &entity.RecoveryCode{UsedAt: &now}, "used_at")
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx databasex.Database, userID int64, codeHashes []string) error {
+	_, err := sqlbuilder.NewModel(tx, nil).
+		Table("user_recovery_codes").
+		Where("user_id = ?", userID).
+		Delete(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range codeHashes {
+		_, err := sqlbuilder.NewModel(tx, &entity.RecoveryCode{}).
+			Table("user_recovery_codes").
+			InsertWithFields(ctx, &entity.RecoveryCode{UserID: userID, CodeHash: hash}, "user_id", "code_hash")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
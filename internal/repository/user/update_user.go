@@ -22,9 +22,9 @@ func (u *userRepository) UpdateUser(ctx context.Context, user entity.UpdateUserR
 		args = append(args, user.Email)
 	}
 
-	if user.Password != "" {
-		setClauses = append(setClauses, "password = ?")
-		args = append(args, user.Password)
+	if user.PasswordHash != "" {
+		setClauses = append(setClauses, "password_hash = ?")
+		args = append(args, user.PasswordHash)
 	}
 
 	// If no fields to update
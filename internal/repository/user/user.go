@@ -2,6 +2,8 @@ package user
 
 import (
 	"context"
+	"time"
+
 	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
@@ -23,7 +25,7 @@ func (u *userRepository) GetUsers(ctx context.Context) ([]entity.User, error) {
 	model := sqlbuilder.NewModel(u.db, &entity.User{})
 	err := model.
 		Table("users").
-		Select("id", "name", "email", "username", "created_at", "updated_at").
+		Select("id", "name", "email", "username", "role", "email_verified_at", "mfa_enabled_at", "created_at", "updated_at").
 		GetAll(ctx, &users)
 
 	if err != nil {
@@ -33,6 +35,92 @@ func (u *userRepository) GetUsers(ctx context.Context) ([]entity.User, error) {
 	return users, nil
 }
 
+// GetUsersPage returns users newest first using keyset pagination, id breaks ties between equal timestamps
+func (u *userRepository) GetUsersPage(ctx context.Context, cursor *sqlbuilder.Cursor, limit int) ([]entity.User, *sqlbuilder.CursorPage, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.GetUsersPage")
+	defer span.End()
+
+	var users []entity.User
+
+	model := sqlbuilder.NewModel(u.db, &entity.User{})
+	page, err := model.
+		Table("users").
+		Select("id", "name", "email", "username", "role", "email_verified_at", "mfa_enabled_at", "created_at", "updated_at").
+		GetWithCursor(ctx, &users, cursor, limit, sqlbuilder.Desc("created_at"), sqlbuilder.Desc("id"))
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return users, page, nil
+}
+
+// GetUserByID returns the user together with its password hash, sql.ErrNoRows when it does not exist
+func (u *userRepository) GetUserByID(ctx context.Context, id int64) (*entity.User, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.GetUserByID")
+	defer span.End()
+
+	return u.getUserBy(ctx, "id", id)
+}
+
+// GetUserByUsername returns the user together with its password hash, sql.ErrNoRows when it does not exist
+func (u *userRepository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.GetUserByUsername")
+	defer span.End()
+
+	return u.getUserBy(ctx, "username", username)
+}
+
+// GetUserByEmail returns the user together with its password hash, sql.ErrNoRows when it does not exist
+func (u *userRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.GetUserByEmail")
+	defer span.End()
+
+	return u.getUserBy(ctx, "email", email)
+}
+
+func (u *userRepository) getUserBy(ctx context.Context, column string, value interface{}) (*entity.User, error) {
+	var user entity.User
+
+	model := sqlbuilder.NewModel(u.db, &user)
+	err := model.
+		Table("users").
+		Where(column+" = ?", value).
+		First(ctx, &user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// MarkEmailVerified marks email as verified, false when the user no longer has that email
+func (u *userRepository) MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error) {
+	ctx, span := telemetry.StartSpan(ctx, "userRepository.MarkEmailVerified")
+	defer span.End()
+
+	now := time.Now()
+
+	model := sqlbuilder.NewModel(u.db, &entity.User{})
+	result, err := model.
+		Table("users").
+		Where("id = ?", id).
+		Where("email = ?", email).
+		UpdateWithFields(ctx, &entity.User{EmailVerifiedAt: &now}, "email_verified_at")
+
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
 func NewUserRepository(db databasex.Database) repository.UserRepository {
 	return &userRepository{
 		db: db,
@@ -1,6 +1,8 @@
 package router
 
 import (
+	"context"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
@@ -11,4 +13,12 @@ type httpHandlerFunc func(xCtx *fiber.Ctx, svc contract.UseCase, cfg *config.Con
 
 type Router interface {
 	Route()
+	Routes() []RouteInfo
+}
+
+// Lifecycle registers resources that must be released when the server shuts down
+// and reports whether the server currently accepts traffic
+type Lifecycle interface {
+	OnShutdown(name string, fn func(ctx context.Context) error)
+	IsReady() bool
 }
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
+)
+
+// jwksMaxAge lets verifiers cache the key set, keys are published before they sign so a short cache is enough
+const jwksMaxAge = "public, max-age=300"
+
+// mountJWKS serves the public verification keys at /.well-known/jwks.json, outside the response envelope
+func (rtr *router) mountJWKS(jwtInstance jwt.JWT) {
+	const jwksPath = "/.well-known/jwks.json"
+
+	rtr.routes = append(rtr.routes, RouteInfo{
+		Method:      fiber.MethodGet,
+		Path:        jwksPath,
+		Name:        "JSON Web Key Set",
+		Response:    reflect.TypeOf(jwt.JWKS{}),
+		SuccessCode: fiber.StatusOK,
+		Raw:         true,
+	})
+
+	rtr.fiber.Get(jwksPath, func(ctx *fiber.Ctx) error {
+		// Built per request, scheduled and expired keys come and go without a restart
+		doc, err := ctx.App().Config().JSONEncoder(jwtInstance.JWKS())
+		if err != nil {
+			return rtr.response(ctx, *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error()))
+		}
+
+		ctx.Set(fiber.HeaderCacheControl, jwksMaxAge)
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return ctx.Send(doc)
+	})
+}
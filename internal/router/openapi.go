@@ -0,0 +1,292 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
+	"github.com/hanifkf12/hanif_skeleton/pkg/binding"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/openapi"
+)
+
+// jwtScheme is shared by JWTAuth and JWTAuthAs, which only differ in the claims struct
+var jwtScheme = &openapi.SecurityScheme{
+	Type:         "http",
+	Scheme:       "bearer",
+	BearerFormat: "JWT",
+}
+
+// securitySchemes documents the auth middlewares by the name the registry lists them under
+var securitySchemes = map[string]*openapi.SecurityScheme{
+	"JWTAuth":   jwtScheme,
+	"JWTAuthAs": jwtScheme,
+	"BearerAuth": {
+		Type:   "http",
+		Scheme: "bearer",
+	},
+	"APIKeyAuth": {
+		Type: "apiKey",
+		In:   "header",
+		Name: "X-API-Key",
+	},
+	"HMACAuth": {
+		Type:        "apiKey",
+		In:          "header",
+		Name:        "X-Signature",
+		Description: "HMAC-SHA256 of method, path, timestamp, nonce and body, sent with X-Timestamp and X-Nonce",
+	},
+}
+
+// middlewareResponses documents the failures a middleware can short circuit with
+var middlewareResponses = map[string]map[int]string{
+	"JWTAuth":                   {fiber.StatusUnauthorized: "Missing, invalid or revoked token", fiber.StatusServiceUnavailable: "Revocation store unavailable"},
+	"JWTAuthAs":                 {fiber.StatusUnauthorized: "Missing, invalid or revoked token", fiber.StatusServiceUnavailable: "Revocation store unavailable"},
+	"BearerAuth":                {fiber.StatusUnauthorized: "Missing or invalid token"},
+	"APIKeyAuth":                {fiber.StatusUnauthorized: "Missing, invalid, expired or revoked API key", fiber.StatusServiceUnavailable: "Key store unavailable"},
+	"HMACAuth":                  {fiber.StatusUnauthorized: "Missing, invalid, expired or replayed signature", fiber.StatusServiceUnavailable: "Replay store unavailable"},
+	"RequireRole":               {fiber.StatusForbidden: "Insufficient role"},
+	"RequireScope":              {fiber.StatusForbidden: "Insufficient scope"},
+	"RequirePermission":         {fiber.StatusForbidden: "Insufficient permissions"},
+	"RequireResourcePermission": {fiber.StatusForbidden: "Insufficient permissions"},
+	"RequireMFA":                {fiber.StatusForbidden: "Two-factor authentication required"},
+	"IPWhitelist":               {fiber.StatusForbidden: "IP address not allowed"},
+	"RateLimit":                 {fiber.StatusTooManyRequests: "Rate limit exceeded"},
+	"ContentTypeValidator":      {fiber.StatusUnsupportedMediaType: "Unsupported Content-Type"},
+}
+
+const (
+	envelopeComponent = "Response"
+	problemComponent  = "Problem"
+)
+
+// Info describes this API in generated documents
+func Info(cfg *config.Config) openapi.Info {
+	title := cfg.App.Name
+	if title == "" {
+		title = "hanif-skeleton"
+	}
+
+	return openapi.Info{
+		Title:   title,
+		Version: apiVersion,
+	}
+}
+
+// mountOpenAPI serves the document for every route mounted so far at /openapi.json
+func (rtr *router) mountOpenAPI() {
+	const openAPIPath = "/openapi.json"
+
+	var (
+		once sync.Once
+		doc  []byte
+		err  error
+	)
+
+	rtr.routes = append(rtr.routes, RouteInfo{
+		Method:      fiber.MethodGet,
+		Path:        openAPIPath,
+		Name:        "OpenAPI document",
+		SuccessCode: fiber.StatusOK,
+		Raw:         true,
+		RawSchema:   &openapi.Schema{Ref: openapi.SchemaURL},
+	})
+	routes := rtr.routes
+
+	rtr.fiber.Get(openAPIPath, func(ctx *fiber.Ctx) error {
+		// The route table is fixed once Route returns, generate on first request only
+		once.Do(func() {
+			doc, err = json.Marshal(OpenAPI(routes, Info(rtr.cfg)))
+		})
+		if err != nil {
+			return rtr.response(ctx, *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error()))
+		}
+
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+		return ctx.Send(doc)
+	})
+}
+
+// OpenAPI generates the OpenAPI document for registered routes, every response uses the appctx.Response envelope
+func OpenAPI(routes []RouteInfo, info openapi.Info) *openapi.Document {
+	g := openapi.NewGenerator(info)
+
+	envelope := g.AddComponent(envelopeComponent, envelopeSchema(g))
+	errorBody := g.Schema(reflect.TypeOf(apperror.Body{}))
+	problem := g.AddComponent(problemComponent, problemSchema())
+	validationErrors := &openapi.Schema{Type: "array", Items: g.Schema(reflect.TypeOf(binding.FieldError{}))}
+
+	for _, r := range routes {
+		op := &openapi.Operation{
+			Summary:     r.Name,
+			OperationID: operationID(r),
+			Parameters:  g.Parameters(r.Path, r.Request),
+			Responses:   map[string]*openapi.Response{},
+			Deprecated:  r.Deprecated,
+		}
+		if r.Tag != "" {
+			op.Tags = []string{r.Tag}
+		}
+
+		if r.Request != nil && r.Method != fiber.MethodGet && r.Method != fiber.MethodDelete {
+			if body := g.Body(r.Request); body != nil {
+				op.RequestBody = &openapi.RequestBody{Required: true, Content: openapi.JSON(body)}
+			}
+		}
+
+		success := envelope
+		narrowed := map[string]*openapi.Schema{}
+		if r.Response != nil {
+			narrowed["data"] = g.Schema(r.Response)
+		}
+		if r.Meta != nil {
+			narrowed["meta"] = g.Schema(r.Meta)
+		}
+		switch {
+		case r.RawSchema != nil:
+			success = r.RawSchema
+		case r.Raw && r.Response != nil:
+			success = g.Schema(r.Response)
+		case r.Raw:
+			success = &openapi.Schema{}
+		case len(narrowed) > 0:
+			success = &openapi.Schema{
+				AllOf: []*openapi.Schema{envelope, {Type: "object", Properties: narrowed}},
+			}
+		}
+		op.Responses[strconv.Itoa(r.SuccessCode)] = &openapi.Response{
+			Description: statusText(r.SuccessCode),
+			Content:     openapi.JSON(success),
+		}
+
+		if r.Request != nil {
+			op.Responses[strconv.Itoa(fiber.StatusBadRequest)] = appErrorResponse(envelope, errorBody, problem, "Malformed request")
+			op.Responses[strconv.Itoa(fiber.StatusUnprocessableEntity)] = &openapi.Response{
+				Description: "Validation failed",
+				Content: withProblem(
+					openapi.JSON(withProperty(envelope, "errors", withProperty(errorBody, "details", validationErrors))),
+					withProperty(problem, "errors", validationErrors),
+				),
+			}
+		}
+
+		// Every auth middleware in the chain must pass, so they form a single requirement
+		security := openapi.SecurityRequirement{}
+		for _, mw := range r.Middlewares {
+			if scheme, ok := securitySchemes[mw]; ok {
+				g.AddSecurityScheme(mw, scheme)
+				security[mw] = []string{}
+			}
+			for code, desc := range middlewareResponses[mw] {
+				op.Responses[strconv.Itoa(code)] = errorResponse(envelope, problem, desc)
+			}
+		}
+		if len(security) > 0 {
+			op.Security = []openapi.SecurityRequirement{security}
+		}
+
+		op.Responses[strconv.Itoa(fiber.StatusInternalServerError)] = appErrorResponse(envelope, errorBody, problem, "Internal server error")
+
+		g.AddOperation(r.Method, r.Path, op)
+	}
+
+	return g.Document()
+}
+
+// envelopeSchema documents appctx.Response with untyped data and errors, operations narrow them
+func envelopeSchema(g *openapi.Generator) *openapi.Schema {
+	schema := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{}}
+
+	t := reflect.TypeOf(appctx.Response{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		schema.Properties[name] = g.Schema(sf.Type)
+	}
+
+	return schema
+}
+
+func withProperty(envelope *openapi.Schema, name string, schema *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		AllOf: []*openapi.Schema{
+			envelope,
+			{Type: "object", Properties: map[string]*openapi.Schema{name: schema}},
+		},
+	}
+}
+
+func errorResponse(envelope *openapi.Schema, problem *openapi.Schema, description string) *openapi.Response {
+	return &openapi.Response{Description: description, Content: withProblem(openapi.JSON(envelope), problem)}
+}
+
+// appErrorResponse documents a failure rendered from an apperror, see router.errorResponse
+func appErrorResponse(envelope *openapi.Schema, errorBody *openapi.Schema, problem *openapi.Schema, description string) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content:     withProblem(openapi.JSON(withProperty(envelope, "errors", errorBody)), problem),
+	}
+}
+
+// withProblem adds the RFC 7807 alternative every error response can be negotiated to
+func withProblem(content map[string]openapi.MediaType, problem *openapi.Schema) map[string]openapi.MediaType {
+	content[appctx.MIMEProblemJSON] = openapi.MediaType{Schema: problem}
+	return content
+}
+
+// problemSchema documents appctx.Problem, extension members are optional
+func problemSchema() *openapi.Schema {
+	return &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"type":     {Type: "string", Format: "uri-reference"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer", Format: "int32"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string", Format: "uri-reference"},
+			"code":     {Type: "string"},
+			"kind":     {Type: "string"},
+			"trace_id": {Type: "string"},
+			"errors":   {},
+		},
+		Required: []string{"type", "title", "status"},
+	}
+}
+
+// operationID derives a stable id such as "postApiV1Campaigns" or "deleteApiV1UsersById"
+func operationID(r RouteInfo) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(r.Method))
+
+	for _, segment := range strings.Split(r.Path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") {
+			b.WriteString("By")
+			segment = strings.TrimPrefix(segment, ":")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+
+	return b.String()
+}
+
+func statusText(code int) string {
+	if text := http.StatusText(code); text != "" {
+		return text
+	}
+	return fmt.Sprintf("HTTP %d", code)
+}
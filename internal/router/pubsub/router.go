@@ -6,6 +6,7 @@ import (
 	"cloud.google.com/go/pubsub"
 	"github.com/hanifkf12/hanif_skeleton/internal/handler"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
 )
@@ -74,10 +75,17 @@ func (r *router) Start(ctx context.Context) error {
 				// Call the handler (similar to HTTP handler pattern)
 				resp := handler.PubSubHandler(ctx, msg, sc.Consumer, r.cfg)
 
-				if resp.Success {
+				switch {
+				case resp.Success:
 					msg.Ack()
 					logger.Info("Message processed successfully", msgLogger)
-				} else {
+				case resp.Error != nil && !apperror.Retryable(resp.Error):
+					// Redelivery would fail the same way, ack so the message is not retried forever
+					msg.Ack()
+					msgLogger.Append(logger.Any("error", resp.Error))
+					msgLogger.Append(logger.Any("kind", apperror.KindOf(resp.Error)))
+					logger.Error("Message dropped, error is not retryable", msgLogger)
+				default:
 					msg.Nack()
 					msgLogger.Append(logger.Any("error", resp.Error))
 					logger.Error("Message processing failed", msgLogger)
@@ -1,23 +1,40 @@
 package router
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
 	"github.com/hanifkf12/hanif_skeleton/internal/bootstrap"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/handler"
 	"github.com/hanifkf12/hanif_skeleton/internal/middleware"
+	apikeyRepo "github.com/hanifkf12/hanif_skeleton/internal/repository/apikey"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository/campaign"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository/home"
 	userRepo "github.com/hanifkf12/hanif_skeleton/internal/repository/user"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/health"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
 )
 
+// apiVersion is the version prefix of the current API, routes are served under /api/v1
+const apiVersion = "v1"
+
 type router struct {
-	cfg   *config.Config
-	fiber fiber.Router
+	cfg       *config.Config
+	fiber     fiber.Router
+	lifecycle Lifecycle
+	dryRun    bool // Use a mock database, only the route table is needed
+	routes    []RouteInfo
 }
 
 // handle registers a handler without middleware
@@ -53,143 +70,532 @@ func (rtr *router) handleWithMiddleware(hfn httpHandlerFunc, svc contract.UseCas
 func (rtr *router) response(ctx *fiber.Ctx, resp appctx.Response) error {
 	ctx.Set("Content-Type", "application/json; charset=utf-8")
 
+	if resp.Err != nil {
+		resp = rtr.errorResponse(ctx, resp)
+	}
+
 	// Use the response code from appctx.Response
 	statusCode := resp.Code
 	if statusCode == 0 {
 		statusCode = 200
 	}
 
+	if statusCode >= fiber.StatusBadRequest && rtr.wantsProblem(ctx) {
+		problem := resp.Problem(ctx.Path(), rtr.cfg.ProblemTypeBaseURL).
+			WithExtension("trace_id", telemetry.GetTraceID(ctx.UserContext()))
+
+		body, err := json.Marshal(problem)
+		if err != nil {
+			return err
+		}
+
+		ctx.Set("Content-Type", appctx.MIMEProblemJSON)
+		return ctx.Status(statusCode).Send(body)
+	}
+
 	return ctx.Status(statusCode).Send(resp.Byte())
 }
 
+// wantsProblem reports whether errors are rendered as RFC 7807 problem details instead of the envelope
+func (rtr *router) wantsProblem(ctx *fiber.Ctx) bool {
+	if rtr.cfg.ErrorFormat == config.ErrorFormatProblem {
+		return true
+	}
+
+	accept := ctx.Get(fiber.HeaderAccept)
+	if !strings.Contains(accept, appctx.MIMEProblemJSON) {
+		return false
+	}
+
+	return ctx.Accepts(fiber.MIMEApplicationJSON, appctx.MIMEProblemJSON) == appctx.MIMEProblemJSON
+}
+
+// errorResponse maps an apperror to its status code and body, fields already set on resp are kept
+// Causes of 5xx errors are logged here because they are never sent to the client
+func (rtr *router) errorResponse(ctx *fiber.Ctx, resp appctx.Response) appctx.Response {
+	body := apperror.ToBody(resp.Err)
+
+	if resp.Code == 0 {
+		resp.Code = apperror.HTTPStatus(resp.Err)
+	}
+	if resp.Message == "" {
+		resp.Message = body.Message
+	}
+	if resp.Errors == nil {
+		resp.Errors = body
+	}
+
+	if resp.Code >= fiber.StatusInternalServerError {
+		lf := logger.NewFields("Router.Error")
+		lf.Append(logger.Any("path", ctx.Path()))
+		lf.Append(logger.Any("method", ctx.Method()))
+		lf.Append(logger.Any("code", resp.Code))
+		lf.Append(logger.Any("error", resp.Err.Error()))
+		logger.Error("Request failed", lf)
+	}
+
+	return resp
+}
+
 func (rtr *router) Route() {
-	db := bootstrap.RegistryDatabase(rtr.cfg, false)
+	healthRegistry := bootstrap.RegistryHealth(rtr.cfg)
+
+	db := bootstrap.RegistryDatabase(rtr.cfg, rtr.dryRun)
+	rtr.lifecycle.OnShutdown("database", func(ctx context.Context) error {
+		return db.Close()
+	})
+	healthRegistry.Register("database", bootstrap.DatabaseHealthCheck(db))
+
 	homeRepo := home.NewHomeRepository(db)
 	userRepository := userRepo.NewUserRepository(db)
 	campaignRepository := campaign.NewCampaignRepository(db)
+	cursorCodec := bootstrap.RegistryCursorCodec(rtr.cfg)
+
+	// Shared cache used as rate limit and token revocation store
+	cacheInstance := bootstrap.RegistryCache(rtr.cfg)
+	rtr.lifecycle.OnShutdown("cache", func(ctx context.Context) error {
+		return cacheInstance.Close()
+	})
+	healthRegistry.Register("cache", bootstrap.CacheHealthCheck(cacheInstance))
 
 	// Initialize JWT
-	jwtInstance := bootstrap.RegistryJWT(rtr.cfg)
+	jwtInstance := bootstrap.RegistryJWT(rtr.cfg, cacheInstance)
 	hasher := bootstrap.RegistryBcryptHasher(rtr.cfg)
+	authorizer := bootstrap.RegistryAuthorizer(rtr.cfg)
+	accountTokens := bootstrap.RegistryOneTimeTokens(rtr.cfg, cacheInstance)
+	cryptoInstance := bootstrap.RegistryCrypto(rtr.cfg)
+	apiKeyRepository := apikeyRepo.NewAPIKeyRepository(db)
+	apiKeys := bootstrap.RegistryAPIKeyResolver(rtr.cfg, apiKeyRepository, cacheInstance)
+
+	// Sign in with external OpenID Connect providers, none unless OIDC_PROVIDERS_FILE is set
+	httpClient := bootstrap.RegistryHTTPClient(rtr.cfg)
+	oidcProviders := bootstrap.RegistryOIDCProviders(rtr.cfg, httpClient)
+
+	// Account emails are handed to the worker, without a queue driver they answer 503
+	queueClient := bootstrap.RegistryQueue(rtr.cfg)
+	if queueClient != nil {
+		rtr.lifecycle.OnShutdown("queue", func(ctx context.Context) error {
+			return queueClient.Close()
+		})
+		healthRegistry.Register("queue", bootstrap.QueueHealthCheck(queueClient), health.NonCritical())
+	}
 
-	// Public routes - no middleware
-	healthUseCase := usecase.NewHealth(homeRepo)
-	rtr.fiber.Get("/health", rtr.handle(
-		handler.HttpRequest,
-		healthUseCase,
-	))
-
-	// Auth routes - public
-	loginUseCase := usecase.NewLogin(userRepository, hasher, jwtInstance)
-	rtr.fiber.Post("/auth/login", rtr.handle(
-		handler.HttpRequest,
-		loginUseCase,
-	))
-
-	refreshTokenUseCase := usecase.NewRefreshToken(jwtInstance)
-	rtr.fiber.Post("/auth/refresh", rtr.handle(
-		handler.HttpRequest,
-		refreshTokenUseCase,
-	))
-
-	// Protected routes with JWT Auth
-	userUseCase := usecase.NewUser(userRepository)
-	rtr.fiber.Get("/users", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		userUseCase,
-		middleware.JWTAuth(jwtInstance),
-	))
-
-	// Protected route with API Key (alternative auth method)
-	campaignUseCase := usecase.NewCampaign(campaignRepository)
-	rtr.fiber.Get("/campaigns", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		campaignUseCase,
-		middleware.APIKeyAuth("X-API-Key", []string{"api-key-123", "api-key-456"}),
-	))
-
-	// Protected route with JWT + Content Type validation
-	createCampaignUseCase := usecase.NewCreateCampaign(campaignRepository)
-	rtr.fiber.Post("/campaigns", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		createCampaignUseCase,
-		middleware.JWTAuth(jwtInstance),
-		middleware.ContentTypeValidator([]string{"application/json"}),
-	))
-
-	// Protected route with JWT
-	updateCampaignUseCase := usecase.NewUpdateCampaign(campaignRepository)
-	rtr.fiber.Put("/campaigns", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		updateCampaignUseCase,
-		middleware.JWTAuth(jwtInstance),
-		middleware.ContentTypeValidator([]string{"application/json"}),
-	))
-
-	deleteCampaignUseCase := usecase.NewDeleteCampaign(campaignRepository)
-	rtr.fiber.Delete("/campaigns/:id", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		deleteCampaignUseCase,
-		middleware.JWTAuth(jwtInstance),
-	))
-
-	// User routes with JWT + Role-based access control
-	createUserUseCase := usecase.NewCreateUser(userRepository)
-	rtr.fiber.Post("/users", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		createUserUseCase,
-		middleware.JWTAuth(jwtInstance),
-		middleware.RequireRole([]string{"admin"}), // Only admin can create users
-		middleware.ContentTypeValidator([]string{"application/json"}),
-	))
-
-	updateUserUseCase := usecase.NewUpdateUser(userRepository)
-	rtr.fiber.Put("/users/:id", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		updateUserUseCase,
-		middleware.JWTAuth(jwtInstance),
-		middleware.ContentTypeValidator([]string{"application/json"}),
-	))
-
-	deleteUserUseCase := usecase.NewDeleteUser(userRepository)
-	rtr.fiber.Delete("/users/:id", rtr.handleWithMiddleware(
-		handler.HttpRequest,
-		deleteUserUseCase,
-		middleware.JWTAuth(jwtInstance),
-		middleware.RequireRole([]string{"admin"}), // Only admin can delete
-	))
+	// Storage being down only degrades readiness, routes not touching files keep working
+	store := bootstrap.RegistryStorage(rtr.cfg)
+	rtr.lifecycle.OnShutdown("storage", func(ctx context.Context) error {
+		return store.Close()
+	})
+	healthRegistry.Register("storage", bootstrap.StorageHealthCheck(store), health.NonCritical())
+
+	authRateLimit := middleware.RateLimit(cacheInstance, middleware.RateLimitConfig{
+		Name:        "auth",
+		MaxRequests: 10,
+		WindowSize:  60, // 10 requests per minute per IP
+	})
+	jwtAuth := middleware.JWTAuth(jwtInstance)
+	requireMFA := middleware.RequireMFA()
+	jsonOnly := middleware.ContentTypeValidator([]string{"application/json"})
+
+	// Probes and public routes - no middleware
+	rtr.mount(Group{
+		Routes: []Route{
+			// Liveness never depends on external services, readiness checks every dependency
+			{Method: fiber.MethodGet, Path: "/livez", Name: "Liveness probe", UseCase: usecase.NewLiveness(healthRegistry), Response: health.Report{}},
+			{Method: fiber.MethodGet, Path: "/readyz", Name: "Readiness probe", UseCase: usecase.NewReadiness(healthRegistry, rtr.lifecycle.IsReady), Response: health.Report{}},
+			{Method: fiber.MethodGet, Path: "/health", Name: "Health check", UseCase: usecase.NewHealth(homeRepo), Response: []entity.Admin{}},
+		},
+	}, "", "", nil)
+
+	rtr.mount(Group{
+		Prefix:  "/api",
+		Version: apiVersion,
+		Groups: []Group{
+			{
+				// Auth routes - public, rate limited
+				Prefix:      "/auth",
+				Middlewares: []middleware.Middleware{authRateLimit},
+				Routes: []Route{
+					{
+						Method:   fiber.MethodPost,
+						Path:     "/login",
+						Legacy:   "/auth/login",
+						Name:     "Login",
+						Handler:  handler.BindRequest[usecase.LoginRequest],
+						UseCase:  usecase.NewLogin(userRepository, hasher, jwtInstance, cacheInstance, accountTokens, rtr.cfg.Login),
+						Request:  usecase.LoginRequest{},
+						Response: usecase.LoginResponse{},
+					},
+					{
+						Method:   fiber.MethodPost,
+						Path:     "/refresh",
+						Legacy:   "/auth/refresh",
+						Name:     "Refresh token",
+						Handler:  handler.BindRequest[usecase.RefreshTokenRequest],
+						UseCase:  usecase.NewRefreshToken(jwtInstance),
+						Request:  usecase.RefreshTokenRequest{},
+						Response: usecase.RefreshTokenResponse{},
+					},
+					{
+						// Revokes the access token and every refresh token of the same login
+						Method:      fiber.MethodPost,
+						Path:        "/logout",
+						Name:        "Logout",
+						UseCase:     usecase.NewLogout(jwtInstance),
+						Middlewares: []middleware.Middleware{jwtAuth},
+					},
+					{
+						// Always succeeds so the response does not reveal which emails are registered
+						Method:  fiber.MethodPost,
+						Path:    "/password/forgot",
+						Name:    "Forgot password",
+						Handler: handler.BindRequest[usecase.ForgotPasswordRequest],
+						UseCase: usecase.NewForgotPassword(userRepository, accountTokens, queueClient, rtr.cfg.Account),
+						Request: usecase.ForgotPasswordRequest{},
+					},
+					{
+						Method:  fiber.MethodPost,
+						Path:    "/password/reset",
+						Name:    "Reset password",
+						Handler: handler.BindRequest[usecase.ResetPasswordRequest],
+						UseCase: usecase.NewResetPassword(userRepository, accountTokens, hasher, jwtInstance, cacheInstance, rtr.cfg.Login),
+						Request: usecase.ResetPasswordRequest{},
+					},
+					{
+						Method:      fiber.MethodPost,
+						Path:        "/email/verification",
+						Name:        "Request email verification",
+						UseCase:     usecase.NewRequestEmailVerification(userRepository, accountTokens, queueClient, rtr.cfg.Account),
+						Middlewares: []middleware.Middleware{jwtAuth},
+					},
+					{
+						Method:  fiber.MethodPost,
+						Path:    "/email/verify",
+						Name:    "Verify email",
+						Handler: handler.BindRequest[usecase.VerifyEmailRequest],
+						UseCase: usecase.NewVerifyEmail(userRepository, accountTokens),
+						Request: usecase.VerifyEmailRequest{},
+					},
+					{
+						// Second step of a login answering mfa_required
+						Method:   fiber.MethodPost,
+						Path:     "/mfa/verify",
+						Name:     "Verify MFA",
+						Handler:  handler.BindRequest[usecase.VerifyMFARequest],
+						UseCase:  usecase.NewVerifyMFA(userRepository, hasher, accountTokens, jwtInstance, cryptoInstance, cacheInstance, rtr.cfg.Login),
+						Request:  usecase.VerifyMFARequest{},
+						Response: usecase.LoginResponse{},
+					},
+					{
+						// Returns the provider URL to send the user to, its state is sent back to the callback
+						Method:   fiber.MethodGet,
+						Path:     "/oidc/:provider/authorize",
+						Name:     "OIDC authorize",
+						Handler:  handler.BindRequest[usecase.OIDCAuthorizeRequest],
+						UseCase:  usecase.NewOIDCAuthorize(oidcProviders, accountTokens),
+						Request:  usecase.OIDCAuthorizeRequest{},
+						Response: usecase.OIDCAuthorizeResponse{},
+					},
+					{
+						// Answers like login, including mfa_required for users with MFA
+						Method:   fiber.MethodPost,
+						Path:     "/oidc/:provider/callback",
+						Name:     "OIDC callback",
+						Handler:  handler.BindRequest[usecase.OIDCCallbackRequest],
+						UseCase:  usecase.NewOIDCCallback(oidcProviders, userRepository, accountTokens, jwtInstance, rtr.cfg.Login),
+						Request:  usecase.OIDCCallbackRequest{},
+						Response: usecase.LoginResponse{},
+					},
+					{
+						Method:      fiber.MethodPost,
+						Path:        "/mfa/enroll",
+						Name:        "Enroll MFA",
+						UseCase:     usecase.NewEnrollMFA(userRepository, cryptoInstance, rtr.cfg.MFA),
+						Middlewares: []middleware.Middleware{jwtAuth},
+						Response:    usecase.EnrollMFAResponse{},
+					},
+					{
+						Method:      fiber.MethodPost,
+						Path:        "/mfa/activate",
+						Name:        "Activate MFA",
+						Handler:     handler.BindRequest[usecase.MFACodeRequest],
+						UseCase:     usecase.NewActivateMFA(userRepository, hasher, cryptoInstance, cacheInstance, rtr.cfg.MFA, rtr.cfg.Login),
+						Middlewares: []middleware.Middleware{jwtAuth},
+						Request:     usecase.MFACodeRequest{},
+						Response:    usecase.ActivateMFAResponse{},
+					},
+					{
+						Method:      fiber.MethodPost,
+						Path:        "/mfa/disable",
+						Name:        "Disable MFA",
+						Handler:     handler.BindRequest[usecase.MFACodeRequest],
+						UseCase:     usecase.NewDisableMFA(userRepository, cryptoInstance, cacheInstance, rtr.cfg.Login),
+						Middlewares: []middleware.Middleware{jwtAuth},
+						Request:     usecase.MFACodeRequest{},
+					},
+				},
+			},
+			{
+				Prefix: "/campaigns",
+				Routes: []Route{
+					// API Key (alternative auth method), keys are issued at /api-keys
+					{
+						Method:      fiber.MethodGet,
+						Name:        "List campaigns",
+						Legacy:      "/campaigns",
+						Handler:     handler.BindRequest[entity.PageRequest],
+						UseCase:     usecase.NewCampaign(campaignRepository, cursorCodec),
+						Middlewares: []middleware.Middleware{middleware.APIKeyAuth("X-API-Key", apiKeys), middleware.RequireScope("campaign:read")},
+						Request:     entity.PageRequest{},
+						Response:    []entity.Campaign{},
+						Meta:        sqlbuilder.CursorResult{},
+					},
+				},
+				Groups: []Group{
+					{
+						Middlewares: []middleware.Middleware{jwtAuth},
+						Routes: []Route{
+							// 201 as the usecase answered before it was typed, create user has always answered 200
+							{
+								Method:      fiber.MethodPost,
+								Name:        "Create campaign",
+								Legacy:      "/campaigns",
+								UseCase:     handler.HttpUseCase(usecase.NewCreateCampaign(campaignRepository), fiber.StatusCreated),
+								Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "campaign:write"), jsonOnly},
+							},
+							{
+								Method:      fiber.MethodPut,
+								Name:        "Update campaign",
+								Legacy:      "/campaigns",
+								Handler:     handler.BindRequest[entity.UpdateCampaignRequest],
+								UseCase:     usecase.NewUpdateCampaign(campaignRepository),
+								Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "campaign:write"), jsonOnly},
+								Request:     entity.UpdateCampaignRequest{},
+								Response:    entity.Campaign{},
+							},
+							{
+								Method:      fiber.MethodDelete,
+								Path:        "/:id",
+								Name:        "Delete campaign",
+								Legacy:      "/campaigns/:id",
+								Handler:     handler.BindRequest[entity.DeleteCampaignRequest],
+								UseCase:     usecase.NewDeleteCampaign(campaignRepository),
+								Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "campaign:delete")},
+								Request:     entity.DeleteCampaignRequest{},
+							},
+						},
+					},
+				},
+			},
+			{
+				Prefix:      "/users",
+				Middlewares: []middleware.Middleware{jwtAuth},
+				Routes: []Route{
+					{
+						Method:      fiber.MethodGet,
+						Name:        "List users",
+						Legacy:      "/users",
+						Handler:     handler.BindRequest[entity.PageRequest],
+						UseCase:     usecase.NewUser(userRepository, cursorCodec),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "user:read")},
+						Request:     entity.PageRequest{},
+						Response:    []entity.User{},
+						Meta:        sqlbuilder.CursorResult{},
+					},
+					{
+						// Users with user:update:own may only update themselves
+						Method:  fiber.MethodPut,
+						Path:    "/:id",
+						Name:    "Update user",
+						Legacy:  "/users/:id",
+						Handler: handler.BindRequest[entity.UpdateUserRequest],
+						UseCase: usecase.NewUpdateUser(userRepository, hasher),
+						Middlewares: []middleware.Middleware{
+							middleware.RequireResourcePermission(authorizer, "user:update", middleware.OwnerParam("id")),
+							jsonOnly,
+						},
+						Request:  entity.UpdateUserRequest{},
+						Response: entity.UpdateUserResponse{},
+					},
+					{
+						// Admin actions require a two-factor login
+						Method:      fiber.MethodPost,
+						Name:        "Create user",
+						Legacy:      "/users",
+						UseCase:     handler.HttpUseCase(usecase.NewCreateUser(userRepository, hasher), fiber.StatusOK),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "user:create"), requireMFA, jsonOnly},
+					},
+					{
+						Method:      fiber.MethodDelete,
+						Path:        "/:id",
+						Name:        "Delete user",
+						Legacy:      "/users/:id",
+						Handler:     handler.BindRequest[entity.DeleteUserRequest],
+						UseCase:     usecase.NewDeleteUser(userRepository),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "user:delete"), requireMFA},
+						Request:     entity.DeleteUserRequest{},
+						Response:    entity.DeleteUserResponse{},
+					},
+				},
+			},
+			{
+				// Keys for service to service access, the key is only returned on create and rotate
+				Prefix:      "/api-keys",
+				Middlewares: []middleware.Middleware{jwtAuth},
+				Routes: []Route{
+					{
+						Method:      fiber.MethodGet,
+						Name:        "List API keys",
+						UseCase:     usecase.NewListAPIKeys(apiKeyRepository),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "apikey:read")},
+						Response:    []entity.APIKeyResponse{},
+					},
+					{
+						Method:      fiber.MethodPost,
+						Name:        "Create API key",
+						Handler:     handler.BindRequest[entity.CreateAPIKeyRequest],
+						UseCase:     usecase.NewCreateAPIKey(apiKeyRepository, authorizer),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "apikey:create"), requireMFA, jsonOnly},
+						Request:     entity.CreateAPIKeyRequest{},
+						Response:    entity.CreateAPIKeyResponse{},
+					},
+					{
+						// The old key keeps working for APIKEY_ROTATION_GRACE
+						Method:      fiber.MethodPost,
+						Path:        "/:id/rotate",
+						Name:        "Rotate API key",
+						Handler:     handler.BindRequest[entity.APIKeyIDRequest],
+						UseCase:     usecase.NewRotateAPIKey(apiKeyRepository, apiKeys, rtr.cfg.APIKey),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "apikey:create"), requireMFA},
+						Request:     entity.APIKeyIDRequest{},
+						Response:    entity.CreateAPIKeyResponse{},
+					},
+					{
+						Method:      fiber.MethodDelete,
+						Path:        "/:id",
+						Name:        "Revoke API key",
+						Handler:     handler.BindRequest[entity.APIKeyIDRequest],
+						UseCase:     usecase.NewRevokeAPIKey(apiKeyRepository, apiKeys),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "apikey:revoke")},
+						Request:     entity.APIKeyIDRequest{},
+					},
+				},
+			},
+			{
+				// Hit ratios per tier for CACHE_DRIVER=tiered, hits and evictions for memory
+				Prefix:      "/cache",
+				Middlewares: []middleware.Middleware{jwtAuth},
+				Routes: []Route{
+					{
+						Method:      fiber.MethodGet,
+						Path:        "/stats",
+						Name:        "Cache stats",
+						UseCase:     usecase.NewCacheStats(cacheInstance),
+						Middlewares: []middleware.Middleware{middleware.RequirePermission(authorizer, "cache:read")},
+						Response:    usecase.CacheStatsResponse{},
+					},
+				},
+			},
+		},
+	}, "", "", nil)
+
+	// Public keys for services verifying our tokens, empty while signing with JWT_SECRET_KEY
+	rtr.mountJWKS(jwtInstance)
+
+	// Generated from the registry, so it always matches the routes mounted above
+	rtr.mountOpenAPI()
 
 	// Example: HMAC protected endpoint (for webhooks, external APIs, etc.)
-	// rtr.fiber.Post("/webhooks/payment", rtr.handleWithMiddleware(
-	// 	handler.HttpRequest,
-	// 	paymentWebhookUseCase,
-	// 	middleware.HMACAuth("your-hmac-secret-key"),
-	// 	middleware.ContentTypeValidator([]string{"application/json"}),
-	// ))
-
-	// Example: IP whitelist for admin endpoints
-	// rtr.fiber.Get("/admin/stats", rtr.handleWithMiddleware(
-	// 	handler.HttpRequest,
-	// 	statsUseCase,
-	// 	middleware.IPWhitelist([]string{"127.0.0.1", "10.0.0.1"}),
-	// 	middleware.JWTAuth(jwtInstance),
-	// 	middleware.RequireRole([]string{"admin"}),
-	// ))
+	// {
+	// 	Prefix:      "/webhooks",
+	// 	Middlewares: []middleware.Middleware{
+	// 		middleware.HMACAuth(cacheInstance, middleware.HMACConfig{Secrets: []string{"your-hmac-secret-key"}}),
+	// 		jsonOnly,
+	// 	},
+	// 	Routes: []Route{
+	// 		{Method: fiber.MethodPost, Path: "/payment", Name: "Payment webhook", UseCase: paymentWebhookUseCase},
+	// 	},
+	// }
+
+	// Example: IP whitelisted admin group, every route inherits the whole chain
+	// {
+	// 	Prefix: "/admin",
+	// 	Middlewares: []middleware.Middleware{
+	// 		middleware.IPWhitelist([]string{"127.0.0.1", "10.0.0.1"}),
+	// 		jwtAuth,
+	// 		middleware.RequirePermission(authorizer, "stats:read"),
+	// 	},
+	// 	Routes: []Route{
+	// 		{Method: fiber.MethodGet, Path: "/stats", Name: "Admin stats", UseCase: statsUseCase},
+	// 	},
+	// }
 
 	// Example: Rate limited public endpoint
-	// rtr.fiber.Post("/public/contact", rtr.handleWithMiddleware(
-	// 	handler.HttpRequest,
-	// 	contactUseCase,
-	// 	middleware.RateLimit(middleware.RateLimitConfig{
-	// 		MaxRequests: 10,
-	// 		WindowSize:  60, // 10 requests per 60 seconds
-	// 	}),
-	// ))
+	// {
+	// 	Method:  fiber.MethodPost,
+	// 	Path:    "/public/contact",
+	// 	UseCase: contactUseCase,
+	// 	Middlewares: []middleware.Middleware{
+	// 		middleware.RateLimit(cacheInstance, middleware.RateLimitConfig{
+	// 			Name:        "contact",
+	// 			MaxRequests: 10,
+	// 			WindowSize:  60, // 10 requests per 60 seconds
+	// 		}),
+	// 	},
+	// }
+
+	// Example: Token bucket per authenticated user
+	// {
+	// 	Method:  fiber.MethodPost,
+	// 	Path:    "/reports",
+	// 	UseCase: reportUseCase,
+	// 	Middlewares: []middleware.Middleware{
+	// 		jwtAuth,
+	// 		middleware.RateLimit(cacheInstance, middleware.RateLimitConfig{
+	// 			Name:        "reports",
+	// 			MaxRequests: 5,
+	// 			WindowSize:  60,
+	// 			Algorithm:   ratelimit.AlgorithmTokenBucket,
+	// 			Burst:       10,
+	// 			KeyFunc:     middleware.KeyByUserID(),
+	// 		}),
+	// 	},
+	// }
+}
+
+// Routes lists every route registered by Route
+func (rtr *router) Routes() []RouteInfo {
+	return rtr.routes
 }
 
-func NewRouter(cfg *config.Config, fiber fiber.Router) Router {
+func NewRouter(cfg *config.Config, fiber fiber.Router, lifecycle Lifecycle) Router {
 	return &router{
-		cfg:   cfg,
-		fiber: fiber,
+		cfg:       cfg,
+		fiber:     fiber,
+		lifecycle: lifecycle,
 	}
 }
+
+// ListRoutes builds the route table without connecting to the database, Redis or a storage bucket
+func ListRoutes(cfg *config.Config) []RouteInfo {
+	dryCfg := *cfg
+	dryCfg.Cache.Driver = "memory"
+	dryCfg.Storage.Driver = "local"
+	dryCfg.Storage.LocalBasePath = os.TempDir()
+
+	rtr := &router{
+		cfg:       &dryCfg,
+		fiber:     fiber.New(),
+		lifecycle: noopLifecycle{},
+		dryRun:    true,
+	}
+	rtr.Route()
+
+	return rtr.Routes()
+}
+
+// noopLifecycle is used when routes are only listed, nothing is ever served
+type noopLifecycle struct{}
+
+func (noopLifecycle) OnShutdown(string, func(ctx context.Context) error) {}
+
+func (noopLifecycle) IsReady() bool { return false }
@@ -0,0 +1,182 @@
+package router
+
+import (
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/handler"
+	"github.com/hanifkf12/hanif_skeleton/internal/middleware"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/openapi"
+)
+
+// Route is a single endpoint in the route table
+type Route struct {
+	Method      string
+	Path        string
+	Name        string          // Short description, listed by the routes command
+	Handler     httpHandlerFunc // Defaults to handler.HttpRequest
+	UseCase     contract.UseCase
+	Middlewares []middleware.Middleware // Run after the middlewares inherited from enclosing groups
+
+	// Legacy is the path the route had before the API was versioned, e.g. "/auth/login"
+	// It is still served, marked deprecated, until LEGACY_ROUTES_DISABLED is set
+	Legacy string
+
+	// Documentation only, filled from the usecase when it implements handler.Describer
+	Request     interface{} // Zero value of the bound request, e.g. entity.UpdateUserRequest{}
+	Response    interface{} // Zero value of the response data
+	Meta        interface{} // Zero value of the response meta, e.g. sqlbuilder.CursorResult{}
+	SuccessCode int         // Defaults to 200
+}
+
+// Group shares a path prefix and middleware chain with its routes and nested groups
+type Group struct {
+	Prefix      string
+	Version     string // API version appended to Prefix, e.g. "/api" + "v1" = "/api/v1"
+	Middlewares []middleware.Middleware
+	Routes      []Route
+	Groups      []Group
+}
+
+// RouteInfo describes a registered route
+type RouteInfo struct {
+	Method      string       `json:"method"`
+	Path        string       `json:"path"`
+	Name        string       `json:"name,omitempty"`
+	Version     string       `json:"version,omitempty"`
+	Tag         string       `json:"tag,omitempty"` // Prefix of the innermost named group, e.g. "users"
+	Middlewares []string     `json:"middlewares,omitempty"`
+	Request     reflect.Type `json:"-"`
+	Response    reflect.Type `json:"-"`
+	Meta        reflect.Type `json:"-"`
+	SuccessCode int          `json:"-"`
+	Deprecated  bool         `json:"deprecated,omitempty"` // Legacy path of a versioned route
+
+	// Raw routes send Response as is, outside the appctx.Response envelope
+	// RawSchema documents a raw body that has no Go type describing it
+	Raw       bool            `json:"raw,omitempty"`
+	RawSchema *openapi.Schema `json:"-"`
+}
+
+// mount registers every route of a group and its nested groups on Fiber and in the registry
+func (rtr *router) mount(g Group, prefix string, version string, chain []middleware.Middleware) {
+	rtr.mountGroup(g, prefix, version, "", chain)
+}
+
+func (rtr *router) mountGroup(g Group, prefix string, version string, tag string, chain []middleware.Middleware) {
+	prefix = joinPath(prefix, g.Prefix, g.Version)
+	if g.Version != "" {
+		version = g.Version
+	}
+	if name := strings.Trim(g.Prefix, "/"); name != "" {
+		tag = name
+	}
+
+	// Copy so sibling groups never share a backing array
+	chain = append(append([]middleware.Middleware{}, chain...), g.Middlewares...)
+
+	for _, r := range g.Routes {
+		hfn := r.Handler
+		if hfn == nil {
+			hfn = handler.HttpRequest
+		}
+
+		mws := append(append([]middleware.Middleware{}, chain...), r.Middlewares...)
+		fullPath := joinPath(prefix, r.Path)
+
+		rtr.fiber.Add(r.Method, fullPath, rtr.handleWithMiddleware(hfn, r.UseCase, mws...))
+
+		info := RouteInfo{
+			Method:      r.Method,
+			Path:        fullPath,
+			Name:        r.Name,
+			Version:     version,
+			Tag:         tag,
+			Middlewares: middlewareNames(mws),
+			Request:     typeOf(r.Request),
+			Response:    typeOf(r.Response),
+			Meta:        typeOf(r.Meta),
+			SuccessCode: r.SuccessCode,
+		}
+		if d, ok := r.UseCase.(handler.Describer); ok {
+			desc := d.Describe()
+			if info.Request == nil {
+				info.Request = desc.Request
+			}
+			if info.Response == nil {
+				info.Response = desc.Response
+			}
+			if info.SuccessCode == 0 {
+				info.SuccessCode = desc.SuccessCode
+			}
+		}
+		if info.SuccessCode == 0 {
+			info.SuccessCode = fiber.StatusOK
+		}
+
+		rtr.routes = append(rtr.routes, info)
+
+		if r.Legacy != "" && !rtr.cfg.LegacyRoutesDisabled {
+			rtr.fiber.Add(r.Method, r.Legacy, deprecated(fullPath, rtr.handleWithMiddleware(hfn, r.UseCase, mws...)))
+
+			legacy := info
+			legacy.Path = r.Legacy
+			legacy.Version = ""
+			legacy.Deprecated = true
+			rtr.routes = append(rtr.routes, legacy)
+		}
+	}
+
+	for _, sub := range g.Groups {
+		rtr.mountGroup(sub, prefix, version, tag, chain)
+	}
+}
+
+// deprecated marks responses of a legacy path and links the versioned path replacing it (RFC 8594, RFC 9745)
+func deprecated(successor string, next fiber.Handler) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		link := successor
+		for _, name := range ctx.Route().Params {
+			link = strings.Replace(link, ":"+name, ctx.Params(name), 1)
+		}
+
+		ctx.Set("Deprecation", "true")
+		ctx.Set(fiber.HeaderLink, "<"+link+">; rel=\"successor-version\"")
+		return next(ctx)
+	}
+}
+
+func typeOf(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+	return reflect.TypeOf(v)
+}
+
+func joinPath(parts ...string) string {
+	return path.Join(append([]string{"/"}, parts...)...)
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+)+$`)
+
+// middlewareNames resolves each middleware to the constructor that built it, e.g. "JWTAuth"
+func middlewareNames(mws []middleware.Middleware) []string {
+	names := make([]string, 0, len(mws))
+	for _, mw := range mws {
+		fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+		if fn == nil {
+			names = append(names, "unknown")
+			continue
+		}
+
+		// Generic constructors are reported as e.g. "JWTAuthAs[...]"
+		name := closureSuffix.ReplaceAllString(strings.ReplaceAll(fn.Name(), "[...]", ""), "")
+		names = append(names, name[strings.LastIndex(name, ".")+1:])
+	}
+	return names
+}
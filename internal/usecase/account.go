@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/internal/jobs"
+	"github.com/hanifkf12/hanif_skeleton/internal/repository"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/crypto"
+	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/onetime"
+	"github.com/hanifkf12/hanif_skeleton/pkg/queue"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+)
+
+// One-time token purposes, a token issued for one flow is rejected by the other
+const (
+	purposePasswordReset     onetime.Purpose = "password_reset"
+	purposeEmailVerification onetime.Purpose = "email_verification"
+)
+
+// Default link lifetimes when none are configured
+const (
+	defaultPasswordResetExpiry     = time.Hour
+	defaultEmailVerificationExpiry = 24 * time.Hour
+)
+
+// ForgotPassword usecase emailing a password reset link
+type forgotPassword struct {
+	userRepo repository.UserRepository
+	tokens   onetime.Store
+	queue    queue.Queue
+	cfg      config.Account
+}
+
+// ForgotPasswordRequest represents forgot password request
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func NewForgotPassword(userRepo repository.UserRepository, tokens onetime.Store, queue queue.Queue, cfg config.Account) contract.UseCase {
+	if cfg.PasswordResetExpiry <= 0 {
+		cfg.PasswordResetExpiry = defaultPasswordResetExpiry
+	}
+
+	return &forgotPassword{
+		userRepo: userRepo,
+		tokens:   tokens,
+		queue:    queue,
+		cfg:      cfg,
+	}
+}
+
+func (u *forgotPassword) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "forgotPassword.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("ForgotPassword").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[ForgotPasswordRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	// The same answer is returned whether or not the email is registered
+	accepted := *appctx.NewResponse().
+		WithCode(fiber.StatusOK).
+		WithMessage("If the email is registered, a password reset link has been sent")
+
+	user, err := u.userRepo.GetUserByEmail(ctx, strings.TrimSpace(req.Email))
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("Password reset requested for unknown email", lf)
+		return accepted
+	}
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	lf.Append(logger.Any("user_id", user.Id))
+
+	token, err := u.tokens.Issue(ctx, purposePasswordReset, strconv.Itoa(user.Id), u.cfg.PasswordResetExpiry)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to issue password reset token", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	payload := jobs.SendEmailPayload{
+		UserID:  int64(user.Id),
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Use the link below to choose a new password, it expires in %s:\n\n%s\n\nIf you did not ask for a password reset you can ignore this email.",
+			u.cfg.PasswordResetExpiry, accountLink(u.cfg.LinkBaseURL, "/reset-password", token),
+		),
+	}
+
+	// Failures are only logged, answering differently would reveal that the email is registered
+	if err := enqueueEmail(ctx, u.queue, payload); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to enqueue password reset email", lf)
+		return accepted
+	}
+
+	logger.Info("Password reset email enqueued", lf)
+	return accepted
+}
+
+// ResetPassword usecase setting a new password from a reset link
+// Existing sessions and other reset links are revoked and a login lockout is lifted
+type resetPassword struct {
+	userRepo repository.UserRepository
+	tokens   onetime.Store
+	hasher   *crypto.BcryptHasher
+	jwt      jwt.JWT
+	lockout  lockout
+}
+
+// ResetPasswordRequest represents reset password request
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+func NewResetPassword(userRepo repository.UserRepository, tokens onetime.Store, hasher *crypto.BcryptHasher, jwtInstance jwt.JWT, store cache.Cache, cfg config.Login) contract.UseCase {
+	return &resetPassword{
+		userRepo: userRepo,
+		tokens:   tokens,
+		hasher:   hasher,
+		jwt:      jwtInstance,
+		lockout:  newLockout(store, cfg),
+	}
+}
+
+func (u *resetPassword) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "resetPassword.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("ResetPassword").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[ResetPasswordRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	subject, err := u.tokens.Consume(ctx, purposePasswordReset, req.Token)
+	if err != nil {
+		return accountTokenError(ctx, lf, err)
+	}
+
+	userID, err := strconv.ParseInt(subject, 10, 64)
+	if err != nil {
+		return accountTokenError(ctx, lf, err)
+	}
+	lf.Append(logger.Any("user_id", userID))
+
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted after the link was sent
+		return accountTokenError(ctx, lf, onetime.ErrInvalidToken)
+	}
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	hash, err := u.hasher.HashPassword(req.Password)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to hash password", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	// Whoever knew the old password or got hold of another link is shut out before the new password applies
+	if err := u.jwt.RevokeUser(ctx, userID); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to revoke sessions", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	if err := u.tokens.Revoke(ctx, purposePasswordReset, subject); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to revoke password reset tokens", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	if err := u.userRepo.UpdateUser(ctx, entity.UpdateUserRequest{ID: userID, PasswordHash: hash}); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to update password", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	for _, identifier := range userIdentifiers(user) {
+		u.lockout.unlock(ctx, identifier)
+	}
+
+	logger.Info("Password reset successful", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithMessage("Password has been reset")
+}
+
+// RequestEmailVerification usecase emailing a verification link to the caller
+type requestEmailVerification struct {
+	userRepo repository.UserRepository
+	tokens   onetime.Store
+	queue    queue.Queue
+	cfg      config.Account
+}
+
+func NewRequestEmailVerification(userRepo repository.UserRepository, tokens onetime.Store, queue queue.Queue, cfg config.Account) contract.UseCase {
+	if cfg.EmailVerificationExpiry <= 0 {
+		cfg.EmailVerificationExpiry = defaultEmailVerificationExpiry
+	}
+
+	return &requestEmailVerification{
+		userRepo: userRepo,
+		tokens:   tokens,
+		queue:    queue,
+		cfg:      cfg,
+	}
+}
+
+func (u *requestEmailVerification) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "requestEmailVerification.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("RequestEmailVerification").WithTrace(ctx)
+
+	// Set by JWTAuth middleware
+	userID, ok := data.FiberCtx.Locals("user_id").(int64)
+	if !ok {
+		logger.Error("User not found in context", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidToken)
+	}
+	lf.Append(logger.Any("user_id", userID))
+
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		if errors.Is(err, sql.ErrNoRows) {
+			return *appctx.NewResponse().WithError(ErrUserNotFound)
+		}
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	if user.EmailVerifiedAt != nil {
+		logger.Info("Email already verified", lf)
+		return *appctx.NewResponse().WithError(ErrEmailAlreadyVerified)
+	}
+
+	// The token is bound to the current email, changing it invalidates links sent to the old one
+	token, err := u.tokens.Issue(ctx, purposeEmailVerification, verificationSubject(userID, user.Email), u.cfg.EmailVerificationExpiry)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to issue email verification token", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	payload := jobs.SendEmailPayload{
+		UserID:  userID,
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Use the link below to verify your email, it expires in %s:\n\n%s",
+			u.cfg.EmailVerificationExpiry, accountLink(u.cfg.LinkBaseURL, "/verify-email", token),
+		),
+	}
+
+	if err := enqueueEmail(ctx, u.queue, payload); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to enqueue email verification email", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	logger.Info("Email verification email enqueued", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithMessage("Verification email has been sent")
+}
+
+// VerifyEmail usecase marking an email verified from a verification link
+type verifyEmail struct {
+	userRepo repository.UserRepository
+	tokens   onetime.Store
+}
+
+// VerifyEmailRequest represents verify email request
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func NewVerifyEmail(userRepo repository.UserRepository, tokens onetime.Store) contract.UseCase {
+	return &verifyEmail{
+		userRepo: userRepo,
+		tokens:   tokens,
+	}
+}
+
+func (u *verifyEmail) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "verifyEmail.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("VerifyEmail").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[VerifyEmailRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	subject, err := u.tokens.Consume(ctx, purposeEmailVerification, req.Token)
+	if err != nil {
+		return accountTokenError(ctx, lf, err)
+	}
+
+	id, email, ok := strings.Cut(subject, ":")
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if !ok || err != nil {
+		return accountTokenError(ctx, lf, onetime.ErrInvalidToken)
+	}
+	lf.Append(logger.Any("user_id", userID))
+
+	verified, err := u.userRepo.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to mark email verified", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	if !verified {
+		// The email changed after the link was sent
+		logger.Error("Email verification failed, email changed", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidAccountToken)
+	}
+
+	logger.Info("Email verified", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithMessage("Email has been verified")
+}
+
+// enqueueEmail hands an email to the worker, which sends it through jobs.SendEmailJob
+func enqueueEmail(ctx context.Context, q queue.Queue, payload jobs.SendEmailPayload) error {
+	if q == nil {
+		return ErrEmailUnavailable
+	}
+	return q.Enqueue(ctx, jobs.JobTypeSendEmail, payload)
+}
+
+// accountLink builds the link sent by email, e.g. https://app.example.com/reset-password?token=...
+func accountLink(baseURL string, path string, token string) string {
+	return strings.TrimSuffix(baseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// verificationSubject binds an email verification token to the user and the email it was sent to
+func verificationSubject(userID int64, email string) string {
+	return strconv.FormatInt(userID, 10) + ":" + email
+}
+
+// accountTokenError maps a failed token consumption, store failures are not the client's fault
+func accountTokenError(ctx context.Context, lf *logger.Fields, err error) appctx.Response {
+	telemetry.SpanError(ctx, err)
+	lf.Append(logger.Any("error", err.Error()))
+	logger.Error("Failed to consume account token", lf)
+
+	if errors.Is(err, onetime.ErrInvalidToken) || errors.Is(err, strconv.ErrSyntax) || errors.Is(err, strconv.ErrRange) {
+		return *appctx.NewResponse().WithError(ErrInvalidAccountToken.Wrap(err))
+	}
+	return *appctx.NewResponse().WithError(err)
+}
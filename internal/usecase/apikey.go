@@ -0,0 +1,310 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/internal/repository"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/apikey"
+	"github.com/hanifkf12/hanif_skeleton/pkg/authz"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+)
+
+// CreateAPIKey usecase issuing a key, the key itself is only in this response
+type createAPIKey struct {
+	repo       repository.APIKeyRepository
+	authorizer authz.Authorizer
+}
+
+func NewCreateAPIKey(repo repository.APIKeyRepository, authorizer authz.Authorizer) contract.UseCase {
+	return &createAPIKey{
+		repo:       repo,
+		authorizer: authorizer,
+	}
+}
+
+func (u *createAPIKey) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "createAPIKey.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("CreateAPIKey").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[entity.CreateAPIKeyRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("name", req.Name))
+	lf.Append(logger.Any("scopes", req.Scopes))
+
+	userID, _ := data.FiberCtx.Locals("user_id").(int64)
+	role, _ := data.FiberCtx.Locals("role").(string)
+	lf.Append(logger.Any("user_id", userID))
+
+	// Keys cannot grant more than their creator holds
+	subject := authz.Subject{ID: userID, Roles: []string{role}}
+	for _, scope := range req.Scopes {
+		if !u.authorizer.Can(subject, scope) {
+			lf.Append(logger.Any("scope", scope))
+			logger.Error("API key scope not granted to creator", lf)
+			return *appctx.NewResponse().WithError(ErrScopeNotGranted.WithDetails(map[string]interface{}{
+				"scope": scope,
+			}))
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn != "" {
+		ttl, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			logger.Error("Invalid API key expiry", lf)
+			return *appctx.NewResponse().WithError(ErrInvalidAPIKeyExpiry)
+		}
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+
+	key := entity.APIKey{
+		Name:      req.Name,
+		Scopes:    strings.Join(req.Scopes, " "),
+		ExpiresAt: expiresAt,
+	}
+	if userID != 0 {
+		key.CreatedBy = &userID
+	}
+
+	response, err := storeAPIKey(ctx, key, func(key entity.APIKey) (int64, error) {
+		return u.repo.Create(ctx, key)
+	})
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to create API key", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	lf.Append(logger.Any("api_key_id", response.Id))
+	logger.Info("API key created", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusCreated).WithData(response)
+}
+
+// ListAPIKeys usecase listing keys without their secrets
+type listAPIKeys struct {
+	repo repository.APIKeyRepository
+}
+
+func NewListAPIKeys(repo repository.APIKeyRepository) contract.UseCase {
+	return &listAPIKeys{repo: repo}
+}
+
+func (u *listAPIKeys) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "listAPIKeys.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("ListAPIKeys").WithTrace(ctx)
+
+	keys, err := u.repo.List(ctx)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to list API keys", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	response := make([]entity.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, apiKeyResponse(key))
+	}
+
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
+}
+
+// RevokeAPIKey usecase revoking a key, it stops working immediately
+type revokeAPIKey struct {
+	repo     repository.APIKeyRepository
+	resolver apikey.Resolver
+}
+
+func NewRevokeAPIKey(repo repository.APIKeyRepository, resolver apikey.Resolver) contract.UseCase {
+	return &revokeAPIKey{
+		repo:     repo,
+		resolver: resolver,
+	}
+}
+
+func (u *revokeAPIKey) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "revokeAPIKey.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("RevokeAPIKey").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[entity.APIKeyIDRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+	id := req.ID
+	lf.Append(logger.Any("api_key_id", id))
+
+	key, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return apiKeyLookupError(ctx, lf, err)
+	}
+
+	revoked, err := u.repo.Revoke(ctx, id)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to revoke API key", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	invalidateAPIKey(ctx, u.resolver, key.Prefix)
+
+	// Revoking twice is not an error, the key is revoked either way
+	lf.Append(logger.Any("already_revoked", !revoked))
+	logger.Info("API key revoked", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithMessage("API key revoked")
+}
+
+// RotateAPIKey usecase replacing a key with a new one of the same name, scopes and expiry
+type rotateAPIKey struct {
+	repo     repository.APIKeyRepository
+	resolver apikey.Resolver
+	cfg      config.APIKey
+}
+
+func NewRotateAPIKey(repo repository.APIKeyRepository, resolver apikey.Resolver, cfg config.APIKey) contract.UseCase {
+	return &rotateAPIKey{
+		repo:     repo,
+		resolver: resolver,
+		cfg:      cfg,
+	}
+}
+
+func (u *rotateAPIKey) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "rotateAPIKey.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("RotateAPIKey").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[entity.APIKeyIDRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+	id := req.ID
+	lf.Append(logger.Any("api_key_id", id))
+
+	old, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return apiKeyLookupError(ctx, lf, err)
+	}
+
+	now := time.Now()
+	if old.RevokedAt != nil || (old.ExpiresAt != nil && !now.Before(*old.ExpiresAt)) {
+		logger.Error("Cannot rotate revoked or expired API key", lf)
+		return *appctx.NewResponse().WithError(ErrAPIKeyInactive)
+	}
+
+	replacement := entity.APIKey{
+		Name:      old.Name,
+		Scopes:    old.Scopes,
+		CreatedBy: old.CreatedBy,
+		ExpiresAt: old.ExpiresAt,
+	}
+	if userID, ok := data.FiberCtx.Locals("user_id").(int64); ok {
+		replacement.CreatedBy = &userID
+	}
+
+	// The old key keeps working for the grace period so clients can switch without downtime
+	oldExpiresAt := now.Add(u.cfg.RotationGrace)
+
+	// A key expiring within the grace period, e.g. one already rotated, would hand its expiry to the replacement
+	if old.ExpiresAt != nil && !old.ExpiresAt.After(oldExpiresAt) {
+		logger.Error("Cannot rotate API key scheduled to expire", lf)
+		return *appctx.NewResponse().WithError(ErrAPIKeyExpiring)
+	}
+
+	response, err := storeAPIKey(ctx, replacement, func(key entity.APIKey) (int64, error) {
+		return u.repo.Rotate(ctx, id, key, oldExpiresAt)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Rotated or revoked meanwhile
+		logger.Error("Cannot rotate API key scheduled to expire", lf)
+		return *appctx.NewResponse().WithError(ErrAPIKeyExpiring)
+	}
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to rotate API key", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	invalidateAPIKey(ctx, u.resolver, old.Prefix)
+
+	lf.Append(logger.Any("new_api_key_id", response.Id))
+	lf.Append(logger.Any("old_expires_at", oldExpiresAt))
+	logger.Info("API key rotated", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusCreated).WithData(response)
+}
+
+// storeAPIKey generates the secret of key and stores it with insert
+func storeAPIKey(ctx context.Context, key entity.APIKey, insert func(entity.APIKey) (int64, error)) (entity.CreateAPIKeyResponse, error) {
+	generated, err := apikey.Generate()
+	if err != nil {
+		return entity.CreateAPIKeyResponse{}, err
+	}
+
+	key.Prefix = generated.Prefix
+	key.KeyHash = generated.Hash
+	key.CreatedAt = time.Now()
+
+	id, err := insert(key)
+	if err != nil {
+		return entity.CreateAPIKeyResponse{}, err
+	}
+	key.Id = id
+
+	return entity.CreateAPIKeyResponse{
+		APIKeyResponse: apiKeyResponse(key),
+		Key:            generated.Token,
+	}, nil
+}
+
+func apiKeyResponse(key entity.APIKey) entity.APIKeyResponse {
+	return entity.APIKeyResponse{
+		APIKey: key,
+		Scopes: key.ScopeList(),
+	}
+}
+
+// invalidateAPIKey drops the cached key, failures only delay the change until the cache entry expires
+func invalidateAPIKey(ctx context.Context, resolver apikey.Resolver, prefix string) {
+	if err := resolver.Invalidate(ctx, prefix); err != nil {
+		lf := logger.NewFields("APIKey.invalidate").WithTrace(ctx)
+		lf.Append(logger.Any("prefix", prefix))
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to invalidate cached API key", lf)
+	}
+}
+
+func apiKeyLookupError(ctx context.Context, lf *logger.Fields, err error) appctx.Response {
+	telemetry.SpanError(ctx, err)
+	lf.Append(logger.Any("error", err.Error()))
+	logger.Error("Failed to look up API key", lf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return *appctx.NewResponse().WithError(ErrAPIKeyNotFound)
+	}
+	return *appctx.NewResponse().WithError(err)
+}
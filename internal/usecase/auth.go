@@ -1,13 +1,24 @@
 package usecase
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
 	"github.com/hanifkf12/hanif_skeleton/pkg/crypto"
 	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/onetime"
 	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
 )
 
@@ -16,29 +27,53 @@ type login struct {
 	userRepo repository.UserRepository
 	hasher   *crypto.BcryptHasher
 	jwt      jwt.JWT
+	tokens   onetime.Store
+	cfg      config.Login
+	lockout  lockout
+
+	// dummyHash is compared against for unknown users, so they take as long as a wrong password
+	dummyHash string
 }
 
-// LoginRequest represents login request
+// LoginRequest represents login request, username also accepts the user's email
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
 }
 
 // LoginResponse represents login response
+// Users with MFA enabled only get MFARequired and MFAToken, which is exchanged for the tokens at /auth/mfa/verify
 type LoginResponse struct {
-	Token     string `json:"token"`
-	UserID    int64  `json:"user_id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
-	ExpiresIn string `json:"expires_in"`
+	Token            string `json:"token,omitempty"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	UserID           int64  `json:"user_id,omitempty"`
+	Username         string `json:"username,omitempty"`
+	Email            string `json:"email,omitempty"`
+	Role             string `json:"role,omitempty"`
+	ExpiresIn        string `json:"expires_in"`
+	RefreshExpiresIn string `json:"refresh_expires_in,omitempty"`
+	MFARequired      bool   `json:"mfa_required,omitempty"`
+	MFAToken         string `json:"mfa_token,omitempty"`
 }
 
-func NewLogin(userRepo repository.UserRepository, hasher *crypto.BcryptHasher, jwtInstance jwt.JWT) contract.UseCase {
+func NewLogin(userRepo repository.UserRepository, hasher *crypto.BcryptHasher, jwtInstance jwt.JWT, store cache.Cache, tokens onetime.Store, cfg config.Login) contract.UseCase {
+	lf := logger.NewFields("NewLogin")
+
+	// Hashed with the configured cost so comparing against it costs the same as a real user
+	dummyHash, err := hasher.HashPassword("dummy-password-for-unknown-users")
+	if err != nil {
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to hash dummy password, unknown users are rejected faster", lf)
+	}
+
 	return &login{
-		userRepo: userRepo,
-		hasher:   hasher,
-		jwt:      jwtInstance,
+		userRepo:  userRepo,
+		hasher:    hasher,
+		jwt:       jwtInstance,
+		tokens:    tokens,
+		cfg:       cfg,
+		lockout:   newLockout(store, cfg),
+		dummyHash: dummyHash,
 	}
 }
 
@@ -49,53 +84,64 @@ func (u *login) Serve(data appctx.Data) appctx.Response {
 
 	lf := logger.NewFields("Login").WithTrace(ctx)
 
-	// Parse request
-	var req LoginRequest
-	if err := data.FiberCtx.BodyParser(&req); err != nil {
+	req, err := appctx.BoundRequest[LoginRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	// Failures are counted per identifier, unknown ones included, so lockout does not reveal which accounts exist
+	username := strings.TrimSpace(req.Username)
+	identifier := strings.ToLower(username)
+	lf.Append(logger.Any("username", identifier))
+
+	if retryAfter, locked := u.lockout.locked(ctx, identifier); locked {
+		lf.Append(logger.Any("retry_after", retryAfter.String()))
+		logger.Error("Login rejected, account locked", lf)
+		return accountLocked(data, retryAfter)
+	}
+
+	user, err := u.findUser(ctx, username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		telemetry.SpanError(ctx, err)
 		lf.Append(logger.Any("error", err.Error()))
-		logger.Error("Invalid login request", lf)
-		return *appctx.NewResponse().
-			WithCode(fiber.StatusBadRequest).
-			WithErrors("Invalid request body")
-	}
-
-	lf.Append(logger.Any("username", req.Username))
-
-	// TODO: Implement user lookup by username from database
-	// For now, this is a placeholder - you need to implement GetUserByUsername in repository
-	// user, err := u.userRepo.GetUserByUsername(ctx, req.Username)
-	// if err != nil {
-	//     logger.Error("User not found", lf)
-	//     return *appctx.NewResponse().
-	//         WithCode(fiber.StatusUnauthorized).
-	//         WithErrors("Invalid credentials")
-	// }
-
-	// TODO: Verify password
-	// if !u.hasher.ComparePassword(req.Password, user.HashedPassword) {
-	//     logger.Error("Invalid password", lf)
-	//     return *appctx.NewResponse().
-	//         WithCode(fiber.StatusUnauthorized).
-	//         WithErrors("Invalid credentials")
-	// }
-
-	// For demo purposes, using hardcoded user data
-	// Replace this with actual database lookup
-	userID := int64(1)
-	username := req.Username
-	email := "user@example.com"
-	role := "user"
-
-	// Generate JWT token
-	claims := jwt.Claims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
+		logger.Error("Failed to look up user", lf)
+		return *appctx.NewResponse().WithError(err)
 	}
 
-	token, err := u.jwt.Generate(claims)
+	// Unknown users still pay for a bcrypt comparison
+	hash := u.dummyHash
+	if user != nil {
+		hash = user.PasswordHash
+	}
+	valid := u.hasher.ComparePassword(req.Password, hash)
+
+	if user == nil || !valid {
+		u.lockout.recordFailure(ctx, identifier)
+		lf.Append(logger.Any("user_found", user != nil))
+		logger.Error("Invalid credentials", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidCredentials)
+	}
+
+	lf.Append(logger.Any("user_id", user.Id))
+
+	// The password step alone does not issue tokens for users with MFA,
+	// failures are kept until the second factor passes so they also limit guessing codes
+	if user.MFAEnabledAt != nil {
+		response, err := issueMFAChallenge(ctx, u.tokens, u.cfg, user, jwt.AMRPassword)
+		if err != nil {
+			telemetry.SpanError(ctx, err)
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Failed to issue MFA challenge", lf)
+			return *appctx.NewResponse().WithError(err)
+		}
+
+		logger.Info("Password verified, MFA required", lf)
+		return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
+	}
+
+	u.lockout.resetFailures(ctx, identifier)
+
+	response, err := issueLoginTokens(u.jwt, user, jwt.AMRPassword)
 	if err != nil {
 		telemetry.SpanError(ctx, err)
 		lf.Append(logger.Any("error", err.Error()))
@@ -105,19 +151,183 @@ func (u *login) Serve(data appctx.Data) appctx.Response {
 			WithErrors("Failed to generate token")
 	}
 
-	response := LoginResponse{
-		Token:     token,
-		UserID:    userID,
-		Username:  username,
-		Email:     email,
-		Role:      role,
-		ExpiresIn: "24h",
-	}
-
 	logger.Info("Login successful", lf)
 	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
 }
 
+// issueLoginTokens generates the token pair of a completed login, amr records the methods used
+func issueLoginTokens(jwtInstance jwt.JWT, user *entity.User, amr ...string) (LoginResponse, error) {
+	claims := jwt.Claims{
+		UserID:   int64(user.Id),
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		AMR:      amr,
+	}
+
+	pair, err := jwtInstance.GeneratePair(&claims)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{
+		Token:            pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		UserID:           claims.UserID,
+		Username:         claims.Username,
+		Email:            claims.Email,
+		Role:             claims.Role,
+		ExpiresIn:        expiresIn(pair.AccessExpiresAt),
+		RefreshExpiresIn: expiresIn(pair.RefreshExpiresAt),
+	}, nil
+}
+
+// findUser looks the identifier up as an email when it contains "@", as a username otherwise
+func (u *login) findUser(ctx context.Context, identifier string) (*entity.User, error) {
+	if strings.Contains(identifier, "@") {
+		return u.userRepo.GetUserByEmail(ctx, identifier)
+	}
+	return u.userRepo.GetUserByUsername(ctx, identifier)
+}
+
+// lockout counts failed sign in attempts per identifier and locks it once MaxAttempts is reached
+// Login and the MFA step share it, so guessing a second factor is limited like guessing a password
+type lockout struct {
+	cache cache.Cache
+	cfg   config.Login
+
+	failures *cache.CacheKey
+	locks    *cache.CacheKey
+}
+
+func newLockout(store cache.Cache, cfg config.Login) lockout {
+	return lockout{
+		cache:    store,
+		cfg:      cfg,
+		failures: cache.NewCacheKey("login:failures"),
+		locks:    cache.NewCacheKey("login:locked"),
+	}
+}
+
+// userIdentifiers are the identifiers a user signs in with, failures of the MFA step count against all of them
+func userIdentifiers(user *entity.User) []string {
+	return []string{strings.ToLower(user.Username), strings.ToLower(user.Email)}
+}
+
+// lockedUser reports whether any identifier of user is locked out and for how long
+func (l lockout) lockedUser(ctx context.Context, user *entity.User) (time.Duration, bool) {
+	for _, identifier := range userIdentifiers(user) {
+		if retryAfter, locked := l.locked(ctx, identifier); locked {
+			return retryAfter, true
+		}
+	}
+	return 0, false
+}
+
+// recordUserFailure counts a wrong second factor against every identifier of user
+func (l lockout) recordUserFailure(ctx context.Context, user *entity.User) {
+	for _, identifier := range userIdentifiers(user) {
+		l.recordFailure(ctx, identifier)
+	}
+}
+
+// accountLocked answers a request for a locked identifier
+func accountLocked(data appctx.Data, retryAfter time.Duration) appctx.Response {
+	data.FiberCtx.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())))
+	return *appctx.NewResponse().WithError(ErrAccountLocked.WithDetails(map[string]interface{}{
+		"retry_after": int(retryAfter.Seconds()),
+	}))
+}
+
+// locked reports whether identifier is locked out and for how long
+// Cache failures let the login through, as with rate limiting the password check still applies
+func (l lockout) locked(ctx context.Context, identifier string) (time.Duration, bool) {
+	if l.cfg.MaxAttempts <= 0 {
+		return 0, false
+	}
+
+	key := l.locks.Build(identifier)
+	exists, err := l.cache.Exists(ctx, key)
+	if err != nil || !exists {
+		return 0, false
+	}
+
+	// The lock stores when it ends, fall back to the full duration if it cannot be read
+	retryAfter := l.cfg.LockoutDuration
+	if value, err := l.cache.Get(ctx, key); err == nil {
+		if until, err := strconv.ParseInt(value, 10, 64); err == nil {
+			retryAfter = time.Until(time.Unix(until, 0))
+		}
+	}
+
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return retryAfter, true
+}
+
+// recordFailure counts a failed attempt and locks identifier once MaxAttempts is reached
+func (l lockout) recordFailure(ctx context.Context, identifier string) {
+	if l.cfg.MaxAttempts <= 0 {
+		return
+	}
+
+	lf := logger.NewFields("Login.recordFailure").WithTrace(ctx)
+	lf.Append(logger.Any("username", identifier))
+
+	// Failures are counted within a window starting at the first one
+	key := l.failures.Build(identifier)
+	n, err := l.cache.IncrByWithExpiry(ctx, key, 1, l.cfg.LockoutDuration)
+	if err != nil {
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to count login failure", lf)
+		return
+	}
+
+	if n < int64(l.cfg.MaxAttempts) {
+		return
+	}
+
+	until := time.Now().Add(l.cfg.LockoutDuration).Unix()
+	if err := l.cache.Set(ctx, l.locks.Build(identifier), until, l.cfg.LockoutDuration); err != nil {
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to lock account", lf)
+		return
+	}
+	_ = l.cache.Delete(ctx, key)
+
+	lf.Append(logger.Any("attempts", n))
+	logger.Error("Account locked after repeated login failures", lf)
+}
+
+// resetFailures forgets earlier failures after a completed login
+func (l lockout) resetFailures(ctx context.Context, identifier string) {
+	if l.cfg.MaxAttempts <= 0 {
+		return
+	}
+
+	if err := l.cache.Delete(ctx, l.failures.Build(identifier)); err != nil {
+		lf := logger.NewFields("Login.resetFailures").WithTrace(ctx)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to reset login failures", lf)
+	}
+}
+
+// unlock lifts a lockout and forgets earlier failures, e.g. after a password reset
+func (l lockout) unlock(ctx context.Context, identifier string) {
+	if l.cfg.MaxAttempts <= 0 {
+		return
+	}
+
+	for _, key := range []string{l.locks.Build(identifier), l.failures.Build(identifier)} {
+		if err := l.cache.Delete(ctx, key); err != nil {
+			lf := logger.NewFields("Login.unlock").WithTrace(ctx)
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Failed to lift login lockout", lf)
+		}
+	}
+}
+
 // RefreshToken usecase for refreshing JWT token
 type refreshToken struct {
 	jwt jwt.JWT
@@ -125,13 +335,16 @@ type refreshToken struct {
 
 // RefreshTokenRequest represents refresh token request
 type RefreshTokenRequest struct {
-	Token string `json:"token" validate:"required"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 // RefreshTokenResponse represents refresh token response
+// The refresh token is rotated, the one sent in the request can no longer be used
 type RefreshTokenResponse struct {
-	Token     string `json:"token"`
-	ExpiresIn string `json:"expires_in"`
+	Token            string `json:"token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        string `json:"expires_in"`
+	RefreshExpiresIn string `json:"refresh_expires_in"`
 }
 
 func NewRefreshToken(jwtInstance jwt.JWT) contract.UseCase {
@@ -147,33 +360,90 @@ func (u *refreshToken) Serve(data appctx.Data) appctx.Response {
 
 	lf := logger.NewFields("RefreshToken").WithTrace(ctx)
 
-	// Parse request
-	var req RefreshTokenRequest
-	if err := data.FiberCtx.BodyParser(&req); err != nil {
-		telemetry.SpanError(ctx, err)
-		lf.Append(logger.Any("error", err.Error()))
-		logger.Error("Invalid refresh token request", lf)
-		return *appctx.NewResponse().
-			WithCode(fiber.StatusBadRequest).
-			WithErrors("Invalid request body")
+	req, err := appctx.BoundRequest[RefreshTokenRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
 	}
 
-	// Refresh token
-	newToken, err := u.jwt.Refresh(req.Token)
+	// Rotate refresh token
+	pair, err := u.jwt.Refresh(ctx, req.RefreshToken)
 	if err != nil {
 		telemetry.SpanError(ctx, err)
 		lf.Append(logger.Any("error", err.Error()))
-		logger.Error("Failed to refresh token", lf)
-		return *appctx.NewResponse().
-			WithCode(fiber.StatusUnauthorized).
-			WithErrors("Invalid or expired token")
+
+		if errors.Is(err, jwt.ErrTokenReused) {
+			// A rotated token came back, someone else holds a copy of it
+			logger.Error("Refresh token reuse detected, token family revoked", lf)
+		} else {
+			logger.Error("Failed to refresh token", lf)
+		}
+
+		// Revocation store failures are not the client's fault
+		if !isTokenError(err) {
+			return *appctx.NewResponse().WithError(err)
+		}
+		return *appctx.NewResponse().WithError(ErrInvalidToken.Wrap(err))
 	}
 
 	response := RefreshTokenResponse{
-		Token:     newToken,
-		ExpiresIn: "24h",
+		Token:            pair.AccessToken,
+		RefreshToken:     pair.RefreshToken,
+		ExpiresIn:        expiresIn(pair.AccessExpiresAt),
+		RefreshExpiresIn: expiresIn(pair.RefreshExpiresAt),
 	}
 
 	logger.Info("Token refreshed successfully", lf)
 	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
 }
+
+// Logout usecase revoking the caller's token family
+type logout struct {
+	jwt jwt.JWT
+}
+
+func NewLogout(jwtInstance jwt.JWT) contract.UseCase {
+	return &logout{
+		jwt: jwtInstance,
+	}
+}
+
+func (u *logout) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "logout.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("Logout").WithTrace(ctx)
+
+	// Set by JWTAuth middleware
+	custom, ok := data.FiberCtx.Locals("claims").(jwt.CustomClaims)
+	if !ok {
+		logger.Error("Claims not found in context", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidToken)
+	}
+	claims := custom.Base()
+
+	lf.Append(logger.Any("user_id", claims.UserID))
+
+	if err := u.jwt.Revoke(ctx, claims); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to revoke token", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	logger.Info("Logout successful", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithMessage("Logged out")
+}
+
+// isTokenError reports whether err is caused by the token itself rather than the revocation store
+func isTokenError(err error) bool {
+	return errors.Is(err, jwt.ErrInvalidToken) ||
+		errors.Is(err, jwt.ErrTokenExpired) ||
+		errors.Is(err, jwt.ErrTokenRevoked) ||
+		errors.Is(err, jwt.ErrTokenReused)
+}
+
+// expiresIn renders the lifetime left until t, e.g. "24h0m0s"
+func expiresIn(t time.Time) string {
+	return time.Until(t).Round(time.Second).String()
+}
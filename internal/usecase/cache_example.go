@@ -37,24 +37,9 @@ func (u *userWithCache) Serve(data appctx.Data) appctx.Response {
 	cacheKey := cache.NewCacheKey("users").Build("list")
 	lf.Append(logger.Any("cache_key", cacheKey))
 
-	// Try to get from cache first
-	cachedData, err := u.cache.Get(ctx, cacheKey)
-	if err == nil {
-		// Cache hit
-		lf.Append(logger.Any("cache", "hit"))
-		logger.Info("Data retrieved from cache", lf)
-
-		// Note: In production, you'd unmarshal JSON here
-		// For now, return cached data as string for demo
-		_ = cachedData // Use the cached data here in production
-	} else {
-		// Cache miss
-		lf.Append(logger.Any("cache", "miss"))
-		logger.Info("Cache miss, querying database", lf)
-	}
-
-	// Get from database
-	users, err := u.userRepo.GetUsers(ctx)
+	// Cache-aside: only a miss reaches the database, concurrent misses share one query
+	// and an expired list is served for another minute while it is reloaded in the background
+	users, err := cache.Remember(ctx, u.cache, cacheKey, 5*time.Minute, u.userRepo.GetUsers, cache.WithStale(time.Minute))
 	if err != nil {
 		telemetry.SpanError(ctx, err)
 		lf.Append(logger.Any("error", err.Error()))
@@ -64,12 +49,6 @@ func (u *userWithCache) Serve(data appctx.Data) appctx.Response {
 			WithErrors(err.Error())
 	}
 
-	// Store in cache (5 minutes expiry)
-	// Note: In production, marshal to JSON first
-	// jsonData, _ := json.Marshal(users)
-	// u.cache.Set(ctx, cacheKey, jsonData, 5*time.Minute)
-	u.cache.Set(ctx, cacheKey, fmt.Sprintf("%v", users), 5*time.Minute)
-
 	logger.Info("Users retrieved successfully", lf)
 	return *appctx.NewResponse().WithData(users)
 }
@@ -80,9 +59,11 @@ type cacheStats struct {
 }
 
 type CacheStatsResponse struct {
-	Status string   `json:"status"`
-	Keys   []string `json:"keys,omitempty"`
-	Count  int      `json:"count"`
+	Status string             `json:"status"`
+	Keys   []string           `json:"keys,omitempty"`
+	Count  int                `json:"count"`
+	Tiers  *cache.TieredStats `json:"tiers,omitempty"`  // Hit ratios per tier, tiered driver only
+	Memory *cache.MemoryStats `json:"memory,omitempty"` // Hits, misses and evictions, memory driver only
 }
 
 func NewCacheStats(cache cache.Cache) contract.UseCase {
@@ -123,6 +104,15 @@ func (u *cacheStats) Serve(data appctx.Data) appctx.Response {
 		Count:  len(keys),
 	}
 
+	switch c := u.cache.(type) {
+	case *cache.TieredCache:
+		stats := c.Stats()
+		response.Tiers = &stats
+	case *cache.MemoryCache:
+		stats := c.Stats()
+		response.Memory = &stats
+	}
+
 	logger.Info("Cache stats retrieved", lf)
 	return *appctx.NewResponse().WithData(response)
 }
@@ -1,16 +1,21 @@
 package usecase
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
 	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
 )
 
 type campaign struct {
 	campaignRepo repository.CampaignRepository
+	cursors      sqlbuilder.CursorCodec
 }
 
 func (c *campaign) Serve(data appctx.Data) appctx.Response {
@@ -20,20 +25,39 @@ func (c *campaign) Serve(data appctx.Data) appctx.Response {
 
 	lf := logger.NewFields("GetAllCampaigns").WithTrace(ctx)
 
-	campaigns, err := c.campaignRepo.GetAll(ctx)
+	req, err := appctx.BoundRequest[entity.PageRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	cursor, err := c.cursors.Decode(req.Cursor)
+	if err != nil {
+		return *appctx.NewResponse().WithError(ErrInvalidCursor.Wrap(err))
+	}
+
+	campaigns, page, err := c.campaignRepo.GetPage(ctx, cursor, req.Limit)
+	if errors.Is(err, sqlbuilder.ErrInvalidCursor) {
+		return *appctx.NewResponse().WithError(ErrInvalidCursor.Wrap(err))
+	}
 	if err != nil {
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to get all campaigns", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	meta, err := sqlbuilder.NewCursorResult(c.cursors, page)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	lf.Append(logger.Any("count", len(campaigns)))
 	logger.Info("Successfully retrieved all campaigns", lf)
-	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(campaigns)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(campaigns).WithMeta(meta)
 }
 
-func NewCampaign(campaignRepo repository.CampaignRepository) contract.UseCase {
+func NewCampaign(campaignRepo repository.CampaignRepository, cursors sqlbuilder.CursorCodec) contract.UseCase {
 	return &campaign{
 		campaignRepo: campaignRepo,
+		cursors:      cursors,
 	}
-}
\ No newline at end of file
+}
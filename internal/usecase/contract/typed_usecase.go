@@ -0,0 +1,17 @@
+package contract
+
+import "context"
+
+// TypedUseCase is transport agnostic business logic, it never touches *fiber.Ctx
+// so the same implementation can be served over HTTP, Pub/Sub or the job queue
+// through the adapters in the handler package
+type TypedUseCase[Req, Resp any] interface {
+	Execute(ctx context.Context, req Req) (Resp, error)
+}
+
+// TypedUseCaseFunc lets a plain function satisfy TypedUseCase
+type TypedUseCaseFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+func (f TypedUseCaseFunc[Req, Resp]) Execute(ctx context.Context, req Req) (Resp, error) {
+	return f(ctx, req)
+}
@@ -1,46 +1,49 @@
 package usecase
 
 import (
-	"github.com/gofiber/fiber/v2"
-	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"context"
+
 	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/crypto"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
 	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
 )
 
 type createUser struct {
 	userRepo repository.UserRepository
+	hasher   *crypto.BcryptHasher
 }
 
-func NewCreateUser(userRepo repository.UserRepository) contract.UseCase {
-	return &createUser{userRepo: userRepo}
+func NewCreateUser(userRepo repository.UserRepository, hasher *crypto.BcryptHasher) contract.TypedUseCase[entity.CreateUserRequest, entity.CreateUserResponse] {
+	return &createUser{userRepo: userRepo, hasher: hasher}
 }
 
-func (u *createUser) Serve(data appctx.Data) appctx.Response {
-	ctx := data.FiberCtx.UserContext()
-	ctx, span := telemetry.StartSpan(ctx, "createCampaign.Serve")
+func (u *createUser) Execute(ctx context.Context, req entity.CreateUserRequest) (entity.CreateUserResponse, error) {
+	ctx, span := telemetry.StartSpan(ctx, "createUser.Execute")
 	defer span.End()
 
-	lf := logger.NewFields("CreateCampaign").WithTrace(ctx)
-	// Parse request body
-	req := new(entity.CreateUserRequest)
-	if err := data.FiberCtx.BodyParser(req); err != nil {
+	lf := logger.NewFields("CreateUser").WithTrace(ctx)
+
+	// Only the bcrypt hash is stored
+	hash, err := u.hasher.HashPassword(req.Password)
+	if err != nil {
 		telemetry.SpanError(ctx, err)
 		lf.Append(logger.Any("error", err.Error()))
-		lf.Append(logger.Any("request", req))
-		logger.Error("Invalid create campaign request", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors(err.Error())
+		logger.Error("Failed to hash password", lf)
+		return entity.CreateUserResponse{}, err
 	}
+	req.PasswordHash = hash
+	req.Name = displayName(req.Name, req.Username)
 
 	// Create user in database
-	userID, err := u.userRepo.CreateUser(data.FiberCtx.Context(), *req)
+	userID, err := u.userRepo.CreateUser(ctx, req)
 	if err != nil {
 		telemetry.SpanError(ctx, err)
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to create user", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return entity.CreateUserResponse{}, err
 	}
 
 	// Prepare response
@@ -51,5 +54,5 @@ func (u *createUser) Serve(data appctx.Data) appctx.Response {
 	}
 	logger.Info("User created successfully", lf)
 
-	return *appctx.NewResponse().WithData(resp)
+	return resp, nil
 }
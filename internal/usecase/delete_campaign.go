@@ -1,8 +1,12 @@
 package usecase
 
 import (
+	"database/sql"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
@@ -20,27 +24,30 @@ func (d *deleteCampaign) Serve(data appctx.Data) appctx.Response {
 
 	lf := logger.NewFields("DeleteCampaign").WithTrace(ctx)
 
-	id := data.FiberCtx.Params("id")
-	if id == "" {
-		lf.Append(logger.Any("error", "Campaign ID is required"))
-		logger.Error("Missing campaign ID in request", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors("Campaign ID is required")
+	req, err := appctx.BoundRequest[entity.DeleteCampaignRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
 	}
+	id := req.ID
 
 	lf.Append(logger.Any("campaign_id", id))
 
 	// Check if campaign exists
-	_, err := d.campaignRepo.GetByID(ctx, id)
+	_, err = d.campaignRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("Campaign not found", lf)
+		return *appctx.NewResponse().WithError(ErrCampaignNotFound.Wrap(err))
+	}
 	if err != nil {
 		lf.Append(logger.Any("error", err.Error()))
-		logger.Error("Campaign not found", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusNotFound).WithErrors("Campaign not found")
+		logger.Error("Failed to get campaign", lf)
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	if err := d.campaignRepo.Delete(ctx, id); err != nil {
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to delete campaign", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	logger.Info("Campaign deleted successfully", lf)
@@ -7,7 +7,6 @@ import (
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
-	"strconv"
 )
 
 type deleteUser struct {
@@ -23,24 +22,18 @@ func (u *deleteUser) Serve(data appctx.Data) appctx.Response {
 		lf = logger.NewFields("DeleteUser")
 	)
 
-	// Parse user ID from path parameter
-	userID := data.FiberCtx.Params("id")
-	if userID == "" {
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors("User ID is required")
-	}
-
-	// Convert user ID to int64
-	id, err := strconv.ParseInt(userID, 10, 64)
+	req, err := appctx.BoundRequest[entity.DeleteUserRequest](data)
 	if err != nil {
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors("Invalid user ID format")
+		return *appctx.NewResponse().WithError(err)
 	}
+	id := req.ID
 
 	// Delete user from database
 	err = u.userRepo.DeleteUser(data.FiberCtx.Context(), id)
 	if err != nil {
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to delete user", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	// Prepare response
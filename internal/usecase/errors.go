@@ -0,0 +1,36 @@
+package usecase
+
+import "github.com/hanifkf12/hanif_skeleton/pkg/apperror"
+
+// Errors returned by usecases, the router maps their kind to a status code
+var (
+	ErrCampaignNotFound = apperror.NotFound("campaign_not_found", "Campaign not found")
+	ErrInvalidToken     = apperror.Unauthorized("invalid_token", "Invalid or expired token")
+	ErrInvalidCursor    = apperror.Validation("invalid_cursor", "Invalid or expired cursor")
+
+	ErrInvalidCredentials = apperror.Unauthorized("invalid_credentials", "Invalid credentials")
+	ErrAccountLocked      = apperror.RateLimited("account_locked", "Too many failed login attempts, try again later")
+
+	ErrUserNotFound         = apperror.NotFound("user_not_found", "User not found")
+	ErrInvalidAccountToken  = apperror.Validation("invalid_account_token", "Invalid or expired link")
+	ErrEmailAlreadyVerified = apperror.Conflict("email_already_verified", "Email is already verified")
+	ErrEmailUnavailable     = apperror.Transient("email_unavailable", "Email delivery is not available")
+
+	ErrInvalidMFACode      = apperror.Unauthorized("invalid_mfa_code", "Invalid two-factor code")
+	ErrInvalidMFAChallenge = apperror.Unauthorized("invalid_mfa_token", "Invalid or expired two-factor login, log in again")
+	ErrMFAAlreadyEnabled   = apperror.Conflict("mfa_already_enabled", "Two-factor authentication is already enabled")
+	ErrMFANotEnrolled      = apperror.Conflict("mfa_not_enrolled", "Two-factor authentication is not set up")
+
+	ErrOIDCProviderNotFound    = apperror.NotFound("oidc_provider_not_found", "Sign in provider not found")
+	ErrOIDCProviderUnavailable = apperror.Transient("oidc_provider_unavailable", "Sign in provider is not available")
+	ErrInvalidOIDCState        = apperror.Validation("invalid_oidc_state", "Invalid or expired sign in, start again")
+	ErrOIDCLoginFailed         = apperror.Unauthorized("oidc_login_failed", "Sign in with the provider failed")
+	ErrOIDCEmailRequired       = apperror.Validation("oidc_email_required", "The provider did not share an email address")
+	ErrOIDCEmailInUse          = apperror.Conflict("oidc_email_in_use", "An account with this email already exists, log in with its password")
+
+	ErrAPIKeyNotFound      = apperror.NotFound("api_key_not_found", "API key not found")
+	ErrAPIKeyInactive      = apperror.Conflict("api_key_inactive", "API key is revoked or expired")
+	ErrAPIKeyExpiring      = apperror.Conflict("api_key_expiring", "API key is already scheduled to expire, create a new key instead")
+	ErrInvalidAPIKeyExpiry = apperror.Validation("invalid_api_key_expiry", "expires_in must be a positive duration such as 720h")
+	ErrScopeNotGranted     = apperror.Forbidden("scope_not_granted", "Cannot grant a scope you do not hold")
+)
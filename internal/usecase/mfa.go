@@ -0,0 +1,519 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/internal/repository"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/crypto"
+	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/onetime"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+	"github.com/hanifkf12/hanif_skeleton/pkg/totp"
+)
+
+// purposeMFAChallenge marks the token handed out after the first step of a two-factor login, password or provider sign in
+const purposeMFAChallenge onetime.Purpose = "mfa_challenge"
+
+// Defaults when none are configured
+const (
+	defaultMFAChallengeExpiry = 5 * time.Minute
+	defaultMFARecoveryCodes   = 10
+	defaultMFAIssuer          = "Hanif Skeleton"
+)
+
+// issueMFAChallenge answers the first step of a login for users with MFA, method records how it was passed
+func issueMFAChallenge(ctx context.Context, tokens onetime.Store, cfg config.Login, user *entity.User, method string) (LoginResponse, error) {
+	expiry := cfg.MFAChallengeExpiry
+	if expiry <= 0 {
+		expiry = defaultMFAChallengeExpiry
+	}
+
+	challenge, err := tokens.Issue(ctx, purposeMFAChallenge, strconv.Itoa(user.Id)+":"+method, expiry)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	return LoginResponse{
+		MFARequired: true,
+		MFAToken:    challenge,
+		ExpiresIn:   expiry.String(),
+	}, nil
+}
+
+// parseMFAChallenge returns the user and first factor of a challenge, challenges without one were issued by a password login
+func parseMFAChallenge(subject string) (int64, string, error) {
+	id, method, found := strings.Cut(subject, ":")
+	if !found {
+		method = jwt.AMRPassword
+	}
+
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return userID, method, nil
+}
+
+// mfaVerifier checks TOTP codes of enrolled users, shared by the MFA usecases
+type mfaVerifier struct {
+	crypto crypto.Crypto
+	cache  cache.Cache
+	used   *cache.CacheKey
+}
+
+func newMFAVerifier(cryptoInstance crypto.Crypto, store cache.Cache) mfaVerifier {
+	return mfaVerifier{
+		crypto: cryptoInstance,
+		cache:  store,
+		used:   cache.NewCacheKey("mfa:used"),
+	}
+}
+
+// verify checks code against the user's encrypted secret, a code is accepted once even though it stays valid for a while
+func (v mfaVerifier) verify(ctx context.Context, user *entity.User, code string) (bool, error) {
+	if user.MFASecret == "" {
+		return false, nil
+	}
+
+	secret, err := v.crypto.Decrypt(user.MFASecret)
+	if err != nil {
+		return false, fmt.Errorf("failed to decrypt MFA secret: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// Codes are valid for every step within the skew, remember the step until none of them is accepted anymore
+	key := v.used.Build(strconv.Itoa(user.Id), strconv.FormatInt(step, 10))
+	n, err := v.cache.IncrByWithExpiry(ctx, key, 1, time.Duration(2*totp.Skew+1)*totp.Period)
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// currentUser loads the caller set by JWTAuth middleware
+func currentUser(ctx context.Context, data appctx.Data, userRepo repository.UserRepository) (*entity.User, error) {
+	userID, ok := data.FiberCtx.Locals("user_id").(int64)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// EnrollMFA usecase creating a TOTP secret for the caller
+type enrollMFA struct {
+	userRepo repository.UserRepository
+	crypto   crypto.Crypto
+	cfg      config.MFA
+}
+
+// EnrollMFAResponse carries the secret once, URI is rendered as QR code for authenticator apps
+type EnrollMFAResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+func NewEnrollMFA(userRepo repository.UserRepository, cryptoInstance crypto.Crypto, cfg config.MFA) contract.UseCase {
+	if cfg.Issuer == "" {
+		cfg.Issuer = defaultMFAIssuer
+	}
+
+	return &enrollMFA{
+		userRepo: userRepo,
+		crypto:   cryptoInstance,
+		cfg:      cfg,
+	}
+}
+
+func (u *enrollMFA) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "enrollMFA.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("EnrollMFA").WithTrace(ctx)
+
+	user, err := currentUser(ctx, data, u.userRepo)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("user_id", user.Id))
+
+	if user.MFAEnabledAt != nil {
+		logger.Error("MFA already enabled", lf)
+		return *appctx.NewResponse().WithError(ErrMFAAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to generate MFA secret", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	// Only the encrypted secret is stored, enrolling again replaces a pending secret
+	encrypted, err := u.crypto.Encrypt(secret)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to encrypt MFA secret", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	if err := u.userRepo.SetMFASecret(ctx, int64(user.Id), encrypted); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to store MFA secret", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	logger.Info("MFA enrolment started", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(EnrollMFAResponse{
+		Secret: secret,
+		URI:    totp.URI(secret, u.cfg.Issuer, user.Email),
+	})
+}
+
+// ActivateMFA usecase enabling MFA once the caller proves the authenticator works
+type activateMFA struct {
+	userRepo repository.UserRepository
+	hasher   *crypto.BcryptHasher
+	verifier mfaVerifier
+	lockout  lockout
+	cfg      config.MFA
+}
+
+// MFACodeRequest represents a request confirmed with a TOTP code
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// ActivateMFAResponse carries the recovery codes, they are shown only once
+type ActivateMFAResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func NewActivateMFA(userRepo repository.UserRepository, hasher *crypto.BcryptHasher, cryptoInstance crypto.Crypto, store cache.Cache, cfg config.MFA, loginCfg config.Login) contract.UseCase {
+	if cfg.RecoveryCodes <= 0 {
+		cfg.RecoveryCodes = defaultMFARecoveryCodes
+	}
+
+	return &activateMFA{
+		userRepo: userRepo,
+		hasher:   hasher,
+		verifier: newMFAVerifier(cryptoInstance, store),
+		lockout:  newLockout(store, loginCfg),
+		cfg:      cfg,
+	}
+}
+
+func (u *activateMFA) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "activateMFA.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("ActivateMFA").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[MFACodeRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	user, err := currentUser(ctx, data, u.userRepo)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("user_id", user.Id))
+
+	if user.MFAEnabledAt != nil {
+		logger.Error("MFA already enabled", lf)
+		return *appctx.NewResponse().WithError(ErrMFAAlreadyEnabled)
+	}
+	if user.MFASecret == "" {
+		logger.Error("MFA not enrolled", lf)
+		return *appctx.NewResponse().WithError(ErrMFANotEnrolled)
+	}
+
+	// A stolen session must not guess codes faster than a login could
+	if retryAfter, locked := u.lockout.lockedUser(ctx, user); locked {
+		lf.Append(logger.Any("retry_after", retryAfter.String()))
+		logger.Error("MFA activation rejected, account locked", lf)
+		return accountLocked(data, retryAfter)
+	}
+
+	valid, err := u.verifier.verify(ctx, user, req.Code)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to verify MFA code", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	if !valid {
+		u.lockout.recordUserFailure(ctx, user)
+		logger.Error("Invalid MFA code", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidMFACode)
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(u.cfg.RecoveryCodes)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to generate recovery codes", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		if hashes[i], err = u.hasher.HashPassword(code); err != nil {
+			telemetry.SpanError(ctx, err)
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Failed to hash recovery code", lf)
+			return *appctx.NewResponse().WithError(err)
+		}
+	}
+
+	if err := u.userRepo.EnableMFA(ctx, int64(user.Id), hashes); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to enable MFA", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	logger.Info("MFA enabled", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(ActivateMFAResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA usecase turning MFA off, confirmed with a current code
+type disableMFA struct {
+	userRepo repository.UserRepository
+	verifier mfaVerifier
+	lockout  lockout
+}
+
+func NewDisableMFA(userRepo repository.UserRepository, cryptoInstance crypto.Crypto, store cache.Cache, cfg config.Login) contract.UseCase {
+	return &disableMFA{
+		userRepo: userRepo,
+		verifier: newMFAVerifier(cryptoInstance, store),
+		lockout:  newLockout(store, cfg),
+	}
+}
+
+func (u *disableMFA) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "disableMFA.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("DisableMFA").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[MFACodeRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	user, err := currentUser(ctx, data, u.userRepo)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("user_id", user.Id))
+
+	if user.MFAEnabledAt == nil {
+		logger.Error("MFA not enabled", lf)
+		return *appctx.NewResponse().WithError(ErrMFANotEnrolled)
+	}
+
+	// A stolen session must not guess codes faster than a login could
+	if retryAfter, locked := u.lockout.lockedUser(ctx, user); locked {
+		lf.Append(logger.Any("retry_after", retryAfter.String()))
+		logger.Error("MFA disable rejected, account locked", lf)
+		return accountLocked(data, retryAfter)
+	}
+
+	valid, err := u.verifier.verify(ctx, user, req.Code)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to verify MFA code", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	if !valid {
+		u.lockout.recordUserFailure(ctx, user)
+		logger.Error("Invalid MFA code", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidMFACode)
+	}
+
+	if err := u.userRepo.DisableMFA(ctx, int64(user.Id)); err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to disable MFA", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	logger.Info("MFA disabled", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithMessage("Two-factor authentication disabled")
+}
+
+// VerifyMFA usecase completing a two-factor login, exchanging the challenge and a code for the tokens
+type verifyMFA struct {
+	userRepo repository.UserRepository
+	hasher   *crypto.BcryptHasher
+	tokens   onetime.Store
+	jwt      jwt.JWT
+	verifier mfaVerifier
+	lockout  lockout
+}
+
+// VerifyMFARequest represents the second login step, either Code or RecoveryCode is required
+type VerifyMFARequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+func NewVerifyMFA(userRepo repository.UserRepository, hasher *crypto.BcryptHasher, tokens onetime.Store, jwtInstance jwt.JWT, cryptoInstance crypto.Crypto, store cache.Cache, cfg config.Login) contract.UseCase {
+	return &verifyMFA{
+		userRepo: userRepo,
+		hasher:   hasher,
+		tokens:   tokens,
+		jwt:      jwtInstance,
+		verifier: newMFAVerifier(cryptoInstance, store),
+		lockout:  newLockout(store, cfg),
+	}
+}
+
+func (u *verifyMFA) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "verifyMFA.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("VerifyMFA").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[VerifyMFARequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	// Challenges are single use, a wrong code means starting over with the password,
+	// and counts as a failed login of the user
+	subject, err := u.tokens.Consume(ctx, purposeMFAChallenge, req.MFAToken)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to consume MFA challenge", lf)
+		if errors.Is(err, onetime.ErrInvalidToken) {
+			return *appctx.NewResponse().WithError(ErrInvalidMFAChallenge)
+		}
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	userID, method, err := parseMFAChallenge(subject)
+	if err != nil {
+		logger.Error("Malformed MFA challenge", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidMFAChallenge)
+	}
+	lf.Append(logger.Any("user_id", userID))
+
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to look up user", lf)
+		if errors.Is(err, sql.ErrNoRows) {
+			return *appctx.NewResponse().WithError(ErrInvalidMFAChallenge)
+		}
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	// Challenges issued before the lock do not get around it
+	if retryAfter, locked := u.lockout.lockedUser(ctx, user); locked {
+		lf.Append(logger.Any("retry_after", retryAfter.String()))
+		logger.Error("MFA verification rejected, account locked", lf)
+		return accountLocked(data, retryAfter)
+	}
+
+	// Recovery codes are recorded apart from TOTP, so tokens and audit logs tell them apart
+	var valid bool
+	factor := jwt.AMROTP
+	if req.Code != "" {
+		valid, err = u.verifier.verify(ctx, user, req.Code)
+	} else {
+		factor = jwt.AMRRecovery
+		valid, err = u.useRecoveryCode(ctx, userID, req.RecoveryCode)
+	}
+	lf.Append(logger.Any("amr", factor))
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to verify MFA code", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	if !valid {
+		u.lockout.recordUserFailure(ctx, user)
+		logger.Error("Invalid MFA code", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidMFACode)
+	}
+
+	// Failures of the password step are kept until here
+	for _, identifier := range userIdentifiers(user) {
+		u.lockout.resetFailures(ctx, identifier)
+	}
+
+	response, err := issueLoginTokens(u.jwt, user, method, factor)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to generate token", lf)
+		return *appctx.NewResponse().
+			WithCode(fiber.StatusInternalServerError).
+			WithErrors("Failed to generate token")
+	}
+
+	logger.Info("Login successful", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
+}
+
+// useRecoveryCode consumes the matching unused recovery code
+func (u *verifyMFA) useRecoveryCode(ctx context.Context, userID int64, code string) (bool, error) {
+	codes, err := u.userRepo.GetRecoveryCodes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	code = totp.NormalizeRecoveryCode(code)
+	for _, c := range codes {
+		if u.hasher.ComparePassword(code, c.CodeHash) {
+			return u.userRepo.UseRecoveryCode(ctx, c.Id)
+		}
+	}
+	return false, nil
+}
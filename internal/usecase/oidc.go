@@ -0,0 +1,361 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
+	"github.com/hanifkf12/hanif_skeleton/internal/repository"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/oidc"
+	"github.com/hanifkf12/hanif_skeleton/pkg/onetime"
+	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
+)
+
+// purposeOIDCState marks the state parameter of a sign in with an external provider
+const purposeOIDCState onetime.Purpose = "oidc_state"
+
+// oidcStateExpiry is how long the user has to finish signing in at the provider
+const oidcStateExpiry = 10 * time.Minute
+
+// Lengths of the users.username and users.name columns
+const (
+	maxUsernameLength = 50
+	maxNameLength     = 100
+)
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// oidcState is kept server side under the state token, so the PKCE verifier and nonce never reach the browser
+type oidcState struct {
+	Provider string `json:"provider"`
+	Verifier string `json:"verifier"`
+	Nonce    string `json:"nonce"`
+}
+
+// OIDC authorize usecase starting a sign in with an external provider
+type oidcAuthorize struct {
+	providers map[string]oidc.Provider
+	tokens    onetime.Store
+}
+
+// OIDCAuthorizeRequest names the provider to sign in with
+type OIDCAuthorizeRequest struct {
+	Provider string `params:"provider" validate:"required"`
+}
+
+// OIDCAuthorizeResponse is where the user is sent, the provider returns them to the redirect URL with code and state
+type OIDCAuthorizeResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	ExpiresIn        string `json:"expires_in"`
+}
+
+func NewOIDCAuthorize(providers map[string]oidc.Provider, tokens onetime.Store) contract.UseCase {
+	return &oidcAuthorize{
+		providers: providers,
+		tokens:    tokens,
+	}
+}
+
+func (u *oidcAuthorize) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "oidcAuthorize.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("OIDCAuthorize").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[OIDCAuthorizeRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("provider", req.Provider))
+
+	provider, ok := u.providers[req.Provider]
+	if !ok {
+		logger.Error("Unknown OIDC provider", lf)
+		return *appctx.NewResponse().WithError(ErrOIDCProviderNotFound)
+	}
+
+	pkce, err := oidc.NewPKCE()
+	if err != nil {
+		return u.fail(ctx, lf, "Failed to generate PKCE verifier", err)
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return u.fail(ctx, lf, "Failed to generate nonce", err)
+	}
+
+	subject, err := json.Marshal(oidcState{Provider: req.Provider, Verifier: pkce.Verifier, Nonce: nonce})
+	if err != nil {
+		return u.fail(ctx, lf, "Failed to encode OIDC state", err)
+	}
+
+	state, err := u.tokens.Issue(ctx, purposeOIDCState, string(subject), oidcStateExpiry)
+	if err != nil {
+		return u.fail(ctx, lf, "Failed to issue OIDC state", err)
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, pkce.Challenge)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to build authorization URL", lf)
+		return *appctx.NewResponse().WithError(ErrOIDCProviderUnavailable.Wrap(err))
+	}
+
+	logger.Info("OIDC sign in started", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(OIDCAuthorizeResponse{
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresIn:        oidcStateExpiry.String(),
+	})
+}
+
+func (u *oidcAuthorize) fail(ctx context.Context, lf *logger.Fields, msg string, err error) appctx.Response {
+	telemetry.SpanError(ctx, err)
+	lf.Append(logger.Any("error", err.Error()))
+	logger.Error(msg, lf)
+	return *appctx.NewResponse().WithError(err)
+}
+
+// OIDC callback usecase finishing a sign in, the external identity is mapped onto a user and our own tokens are issued
+type oidcCallback struct {
+	providers map[string]oidc.Provider
+	userRepo  repository.UserRepository
+	tokens    onetime.Store
+	jwt       jwt.JWT
+	cfg       config.Login
+}
+
+// OIDCCallbackRequest carries what the provider sent back to the redirect URL
+type OIDCCallbackRequest struct {
+	Provider string `params:"provider" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+	State    string `json:"state" validate:"required"`
+}
+
+func NewOIDCCallback(providers map[string]oidc.Provider, userRepo repository.UserRepository, tokens onetime.Store, jwtInstance jwt.JWT, cfg config.Login) contract.UseCase {
+	return &oidcCallback{
+		providers: providers,
+		userRepo:  userRepo,
+		tokens:    tokens,
+		jwt:       jwtInstance,
+		cfg:       cfg,
+	}
+}
+
+func (u *oidcCallback) Serve(data appctx.Data) appctx.Response {
+	ctx := data.FiberCtx.UserContext()
+	ctx, span := telemetry.StartSpan(ctx, "oidcCallback.Serve")
+	defer span.End()
+
+	lf := logger.NewFields("OIDCCallback").WithTrace(ctx)
+
+	req, err := appctx.BoundRequest[OIDCCallbackRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("provider", req.Provider))
+
+	provider, ok := u.providers[req.Provider]
+	if !ok {
+		logger.Error("Unknown OIDC provider", lf)
+		return *appctx.NewResponse().WithError(ErrOIDCProviderNotFound)
+	}
+
+	// States are single use, a replayed callback has to start over
+	subject, err := u.tokens.Consume(ctx, purposeOIDCState, req.State)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to consume OIDC state", lf)
+		if errors.Is(err, onetime.ErrInvalidToken) {
+			return *appctx.NewResponse().WithError(ErrInvalidOIDCState)
+		}
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	var state oidcState
+	if err := json.Unmarshal([]byte(subject), &state); err != nil || state.Provider != req.Provider {
+		logger.Error("OIDC state does not belong to provider", lf)
+		return *appctx.NewResponse().WithError(ErrInvalidOIDCState)
+	}
+
+	token, err := provider.Exchange(ctx, req.Code, state.Verifier)
+	if err != nil {
+		return u.providerError(ctx, lf, "Failed to exchange authorization code", err)
+	}
+
+	identity, err := provider.VerifyIDToken(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		return u.providerError(ctx, lf, "Failed to verify ID token", err)
+	}
+	lf.Append(logger.Any("subject", identity.Subject))
+
+	user, err := u.resolveUser(ctx, provider.Name(), identity)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to map OIDC identity to user", lf)
+		return *appctx.NewResponse().WithError(err)
+	}
+	lf.Append(logger.Any("user_id", user.Id))
+
+	// The provider replaces the password, a local second factor is still asked for
+	if user.MFAEnabledAt != nil {
+		response, err := issueMFAChallenge(ctx, u.tokens, u.cfg, user, jwt.AMRFederated)
+		if err != nil {
+			telemetry.SpanError(ctx, err)
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Failed to issue MFA challenge", lf)
+			return *appctx.NewResponse().WithError(err)
+		}
+
+		logger.Info("OIDC sign in verified, MFA required", lf)
+		return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
+	}
+
+	response, err := issueLoginTokens(u.jwt, user, jwt.AMRFederated)
+	if err != nil {
+		telemetry.SpanError(ctx, err)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to generate token", lf)
+		return *appctx.NewResponse().
+			WithCode(fiber.StatusInternalServerError).
+			WithErrors("Failed to generate token")
+	}
+
+	logger.Info("OIDC sign in successful", lf)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(response)
+}
+
+// providerError maps a failure talking to the provider, discovery failures are on their side
+func (u *oidcCallback) providerError(ctx context.Context, lf *logger.Fields, msg string, err error) appctx.Response {
+	telemetry.SpanError(ctx, err)
+	lf.Append(logger.Any("error", err.Error()))
+	logger.Error(msg, lf)
+
+	if errors.Is(err, oidc.ErrDiscovery) {
+		return *appctx.NewResponse().WithError(ErrOIDCProviderUnavailable.Wrap(err))
+	}
+	return *appctx.NewResponse().WithError(ErrOIDCLoginFailed.Wrap(err))
+}
+
+// resolveUser finds the user of an external identity, in order:
+// an identity linked before, a user with the same email when the provider verified it, or a new user
+func (u *oidcCallback) resolveUser(ctx context.Context, provider string, identity *oidc.Identity) (*entity.User, error) {
+	user, err := u.userRepo.GetUserByIdentity(ctx, provider, identity.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" {
+		return nil, ErrOIDCEmailRequired
+	}
+
+	link := entity.UserIdentity{
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    email,
+	}
+
+	user, err = u.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// An unverified email could be anyone's, linking it would hand them the account
+		if !identity.EmailVerified {
+			return nil, ErrOIDCEmailInUse
+		}
+
+		link.UserID = int64(user.Id)
+		if err := u.userRepo.LinkIdentity(ctx, link); err != nil {
+			return nil, err
+		}
+		return user, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, err
+	}
+
+	username, err := u.availableUsername(ctx, identity, email)
+	if err != nil {
+		return nil, err
+	}
+
+	// New users have no password, they sign in with the provider or set one through password reset
+	newUser := entity.User{
+		Name:     displayName(identity.Name, username),
+		Username: username,
+		Email:    email,
+	}
+	// Only an email the provider verified counts as verified, otherwise the user verifies it with us
+	if identity.EmailVerified {
+		now := time.Now()
+		newUser.EmailVerifiedAt = &now
+	}
+
+	id, err := u.userRepo.CreateUserWithIdentity(ctx, newUser, link)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.userRepo.GetUserByID(ctx, id)
+}
+
+// displayName is the name the provider sent, the username without one, cut to fit users.name
+func displayName(name string, username string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return username
+	}
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	return name
+}
+
+// availableUsername derives a username from the identity, a random suffix is added while it is taken
+func (u *oidcCallback) availableUsername(ctx context.Context, identity *oidc.Identity, email string) (string, error) {
+	base := identity.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(email, "@")
+	}
+
+	base = strings.Trim(usernameInvalidChars.ReplaceAllString(strings.ToLower(base), "-"), "-._")
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > maxUsernameLength-9 {
+		base = base[:maxUsernameLength-9]
+	}
+
+	candidate := base
+	for attempt := 0; attempt < 5; attempt++ {
+		_, err := u.userRepo.GetUserByUsername(ctx, candidate)
+		if errors.Is(err, sql.ErrNoRows) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		candidate = base + "-" + strings.ToLower(rand.Text()[:8])
+	}
+
+	return "", errors.New("no available username")
+}
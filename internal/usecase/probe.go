@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	healthcheck "github.com/hanifkf12/hanif_skeleton/pkg/health"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// liveness reports whether the process is alive, dependency outages must not fail it
+type liveness struct {
+	health healthcheck.Health
+}
+
+func (l *liveness) Serve(data appctx.Data) appctx.Response {
+	report := l.health.Liveness(data.FiberCtx.UserContext())
+	if report.Status == healthcheck.StatusDown {
+		lf := logger.NewFields("Liveness")
+		lf.Append(logger.Any("checks", report.Checks))
+		logger.Error("Liveness check failed", lf)
+		return *appctx.NewResponse().WithCode(fiber.StatusServiceUnavailable).WithData(report)
+	}
+
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(report)
+}
+
+func NewLiveness(h healthcheck.Health) contract.UseCase {
+	return &liveness{
+		health: h,
+	}
+}
+
+// readiness reports whether the application can serve traffic
+type readiness struct {
+	health  healthcheck.Health
+	isReady func() bool
+}
+
+func (r *readiness) Serve(data appctx.Data) appctx.Response {
+	// Fail fast while starting up or draining, without probing dependencies
+	if !r.isReady() {
+		report := &healthcheck.Report{Status: healthcheck.StatusDown, CheckedAt: time.Now()}
+		return *appctx.NewResponse().WithCode(fiber.StatusServiceUnavailable).WithData(report)
+	}
+
+	report := r.health.Readiness(data.FiberCtx.UserContext())
+	if report.Status == healthcheck.StatusDown {
+		lf := logger.NewFields("Readiness")
+		lf.Append(logger.Any("checks", report.Checks))
+		logger.Error("Readiness check failed", lf)
+		return *appctx.NewResponse().WithCode(fiber.StatusServiceUnavailable).WithData(report)
+	}
+
+	// Degraded still accepts traffic, only non-critical dependencies are failing
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(report)
+}
+
+func NewReadiness(h healthcheck.Health, isReady func() bool) contract.UseCase {
+	return &readiness{
+		health:  h,
+		isReady: isReady,
+	}
+}
@@ -1,7 +1,9 @@
 package usecase
 
 import (
-	"github.com/go-playground/validator/v10"
+	"database/sql"
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
 	"github.com/hanifkf12/hanif_skeleton/internal/entity"
@@ -13,7 +15,6 @@ import (
 
 type updateCampaign struct {
 	campaignRepo repository.CampaignRepository
-	validator    *validator.Validate
 }
 
 func (u *updateCampaign) Serve(data appctx.Data) appctx.Response {
@@ -23,28 +24,23 @@ func (u *updateCampaign) Serve(data appctx.Data) appctx.Response {
 
 	lf := logger.NewFields("UpdateCampaign").WithTrace(ctx)
 
-	req := new(entity.UpdateCampaignRequest)
-	if err := data.FiberCtx.BodyParser(req); err != nil {
-		lf.Append(logger.Any("error", err.Error()))
-		logger.Error("Failed to parse update campaign request", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors(err.Error())
-	}
-
-	if err := u.validator.Struct(req); err != nil {
-		lf.Append(logger.Any("error", err.Error()))
-		lf.Append(logger.Any("request", req))
-		logger.Error("Invalid update campaign request", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors(err.Error())
+	req, err := appctx.BoundRequest[entity.UpdateCampaignRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	lf.Append(logger.Any("campaign_id", req.ID))
 
 	// Check if campaign exists
 	existing, err := u.campaignRepo.GetByID(ctx, req.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Info("Campaign not found", lf)
+		return *appctx.NewResponse().WithError(ErrCampaignNotFound.Wrap(err))
+	}
 	if err != nil {
 		lf.Append(logger.Any("error", err.Error()))
-		logger.Error("Campaign not found", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusNotFound).WithErrors("Campaign not found")
+		logger.Error("Failed to get campaign", lf)
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	// Update campaign fields
@@ -57,7 +53,7 @@ func (u *updateCampaign) Serve(data appctx.Data) appctx.Response {
 	if err := u.campaignRepo.Update(ctx, existing); err != nil {
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to update campaign", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	logger.Info("Campaign updated successfully", lf)
@@ -67,6 +63,5 @@ func (u *updateCampaign) Serve(data appctx.Data) appctx.Response {
 func NewUpdateCampaign(campaignRepo repository.CampaignRepository) contract.UseCase {
 	return &updateCampaign{
 		campaignRepo: campaignRepo,
-		validator:    validator.New(),
 	}
 }
\ No newline at end of file
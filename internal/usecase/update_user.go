@@ -6,16 +6,17 @@ import (
 	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
+	"github.com/hanifkf12/hanif_skeleton/pkg/crypto"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
-	"strconv"
 )
 
 type updateUser struct {
 	userRepo repository.UserRepository
+	hasher   *crypto.BcryptHasher
 }
 
-func NewUpdateUser(userRepo repository.UserRepository) contract.UseCase {
-	return &updateUser{userRepo: userRepo}
+func NewUpdateUser(userRepo repository.UserRepository, hasher *crypto.BcryptHasher) contract.UseCase {
+	return &updateUser{userRepo: userRepo, hasher: hasher}
 }
 
 func (u *updateUser) Serve(data appctx.Data) appctx.Response {
@@ -23,33 +24,30 @@ func (u *updateUser) Serve(data appctx.Data) appctx.Response {
 		lf = logger.NewFields("UpdateUser")
 	)
 
-	// Parse user ID from path parameter
-	userID := data.FiberCtx.Params("id")
-	if userID == "" {
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors("User ID is required")
-	}
-
-	// Convert user ID to int64
-	id, err := strconv.ParseInt(userID, 10, 64)
+	// ID is bound from the path parameter
+	req, err := appctx.BoundRequest[entity.UpdateUserRequest](data)
 	if err != nil {
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors("Invalid user ID format")
+		return *appctx.NewResponse().WithError(err)
 	}
+	id := req.ID
 
-	// Parse request body
-	req := new(entity.UpdateUserRequest)
-	if err := data.FiberCtx.BodyParser(req); err != nil {
-		return *appctx.NewResponse().WithCode(fiber.StatusBadRequest).WithErrors(err.Error())
+	// Only the bcrypt hash is stored
+	if req.Password != "" {
+		hash, err := u.hasher.HashPassword(req.Password)
+		if err != nil {
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Failed to hash password", lf)
+			return *appctx.NewResponse().WithError(err)
+		}
+		req.PasswordHash = hash
 	}
 
-	// Set the ID from the path parameter
-	req.ID = id
-
 	// Update user in database
 	err = u.userRepo.UpdateUser(data.FiberCtx.Context(), *req)
 	if err != nil {
 		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to update user", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	// Prepare response
@@ -1,16 +1,21 @@
 package usecase
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/hanifkf12/hanif_skeleton/internal/appctx"
+	"github.com/hanifkf12/hanif_skeleton/internal/entity"
 	"github.com/hanifkf12/hanif_skeleton/internal/repository"
 	"github.com/hanifkf12/hanif_skeleton/internal/usecase/contract"
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/sqlbuilder"
 	"github.com/hanifkf12/hanif_skeleton/pkg/telemetry"
 )
 
 type user struct {
 	userRepo repository.UserRepository
+	cursors  sqlbuilder.CursorCodec
 }
 
 func (u *user) Serve(data appctx.Data) appctx.Response {
@@ -31,18 +36,38 @@ func (u *user) Serve(data appctx.Data) appctx.Response {
 	lf.Append(logger.Any("user_zip", "12345"))
 	lf.Append(logger.Any("user_country", "USA"))
 
-	users, err := u.userRepo.GetUsers(ctx)
+	req, err := appctx.BoundRequest[entity.PageRequest](data)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	cursor, err := u.cursors.Decode(req.Cursor)
 	if err != nil {
+		return *appctx.NewResponse().WithError(ErrInvalidCursor.Wrap(err))
+	}
+
+	users, page, err := u.userRepo.GetUsersPage(ctx, cursor, req.Limit)
+	if errors.Is(err, sqlbuilder.ErrInvalidCursor) {
+		return *appctx.NewResponse().WithError(ErrInvalidCursor.Wrap(err))
+	}
+	if err != nil {
+		lf.Append(logger.Any("error", err.Error()))
 		logger.Error("Failed to get users", lf)
-		return *appctx.NewResponse().WithCode(fiber.StatusInternalServerError).WithErrors(err.Error())
+		return *appctx.NewResponse().WithError(err)
+	}
+
+	meta, err := sqlbuilder.NewCursorResult(u.cursors, page)
+	if err != nil {
+		return *appctx.NewResponse().WithError(err)
 	}
 
 	logger.Info("Successfully retrieved users", lf)
-	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(users)
+	return *appctx.NewResponse().WithCode(fiber.StatusOK).WithData(users).WithMeta(meta)
 }
 
-func NewUser(userRepo repository.UserRepository) contract.UseCase {
+func NewUser(userRepo repository.UserRepository, cursors sqlbuilder.CursorCodec) contract.UseCase {
 	return &user{
 		userRepo: userRepo,
+		cursors:  cursors,
 	}
 }
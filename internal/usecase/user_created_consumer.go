@@ -43,6 +43,8 @@ func (c *userCreatedConsumer) Consume(data appctx.PubSubData) appctx.PubSubRespo
 	lf.Append(logger.Any("username", req.Username))
 	lf.Append(logger.Any("email", req.Email))
 
+	req.Name = displayName(req.Name, req.Username)
+
 	// Create user in database
 	userID, err := c.userRepo.CreateUser(ctx, req)
 	if err != nil {
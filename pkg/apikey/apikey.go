@@ -0,0 +1,102 @@
+package apikey
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/crypto"
+)
+
+// Keys look like sk_<prefix>_<secret>, the prefix is stored in plain text to find the key without scanning every hash
+const (
+	TokenPrefix  = "sk_"
+	prefixLength = 8
+	secretBytes  = 30
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid api key")
+	ErrKeyExpired = errors.New("api key expired")
+	ErrKeyRevoked = errors.New("api key revoked")
+	ErrNotFound   = errors.New("api key not found")
+)
+
+// Key is a stored API key, only the hash of the secret is ever stored
+type Key struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Hash      string     `json:"hash"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Generated is a new key, Token is shown to its owner once and cannot be recovered
+type Generated struct {
+	Token  string
+	Prefix string
+	Hash   string
+}
+
+// Generate creates a random key
+func Generate() (Generated, error) {
+	// 6 and 30 bytes encode without padding, so the token only contains URL safe characters
+	prefix, err := crypto.GenerateRandomKey(prefixLength * 3 / 4)
+	if err != nil {
+		return Generated{}, err
+	}
+	secret, err := crypto.GenerateRandomKey(secretBytes)
+	if err != nil {
+		return Generated{}, err
+	}
+
+	token := TokenPrefix + prefix + "_" + secret
+	return Generated{
+		Token:  token,
+		Prefix: prefix,
+		Hash:   Hash(token),
+	}, nil
+}
+
+// Parse returns the lookup prefix of token, false when it is not shaped like a key
+func Parse(token string) (string, bool) {
+	rest, ok := strings.CutPrefix(token, TokenPrefix)
+	if !ok || len(rest) < prefixLength+2 || rest[prefixLength] != '_' {
+		return "", false
+	}
+	return rest[:prefixLength], true
+}
+
+// Hash hashes a token for storage, keys are random so a fast hash is enough unlike passwords
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify checks token against the stored key, its expiry and revocation
+func (k *Key) Verify(token string, now time.Time) error {
+	if subtle.ConstantTimeCompare([]byte(Hash(token)), []byte(k.Hash)) != 1 {
+		return ErrInvalidKey
+	}
+	if k.RevokedAt != nil && !now.Before(*k.RevokedAt) {
+		return ErrKeyRevoked
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return ErrKeyExpired
+	}
+	return nil
+}
+
+// HasScope checks if the key was granted scope
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package apikey
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.SetupNop()
+	os.Exit(m.Run())
+}
+
+// fakeStore counts queries so caching can be asserted
+type fakeStore struct {
+	keys    map[string]*Key
+	queries int
+	touched []int64
+}
+
+func (s *fakeStore) GetByPrefix(ctx context.Context, prefix string) (*Key, error) {
+	s.queries++
+	key, ok := s.keys[prefix]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (s *fakeStore) TouchLastUsed(ctx context.Context, id int64, t time.Time) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+func TestGenerateAndParse(t *testing.T) {
+	generated, err := Generate()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(generated.Token, TokenPrefix))
+	assert.NotContains(t, generated.Token, "=")
+	assert.Len(t, generated.Prefix, prefixLength)
+	assert.NotContains(t, generated.Hash, generated.Token)
+
+	prefix, ok := Parse(generated.Token)
+	require.True(t, ok)
+	assert.Equal(t, generated.Prefix, prefix)
+
+	for _, token := range []string{"", "api-key-123", "sk_short", "sk_abcdefghXsecret"} {
+		_, ok := Parse(token)
+		assert.False(t, ok, token)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	generated, err := Generate()
+	require.NoError(t, err)
+
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	assert.NoError(t, (&Key{Hash: generated.Hash}).Verify(generated.Token, now))
+	assert.NoError(t, (&Key{Hash: generated.Hash, ExpiresAt: &future}).Verify(generated.Token, now))
+	assert.ErrorIs(t, (&Key{Hash: generated.Hash}).Verify(generated.Token+"x", now), ErrInvalidKey)
+	assert.ErrorIs(t, (&Key{Hash: generated.Hash, ExpiresAt: &past}).Verify(generated.Token, now), ErrKeyExpired)
+	assert.ErrorIs(t, (&Key{Hash: generated.Hash, RevokedAt: &past}).Verify(generated.Token, now), ErrKeyRevoked)
+}
+
+func TestResolver(t *testing.T) {
+	ctx := context.Background()
+
+	generated, err := Generate()
+	require.NoError(t, err)
+
+	store := &fakeStore{keys: map[string]*Key{
+		generated.Prefix: {ID: 7, Name: "reporting", Hash: generated.Hash, Scopes: []string{"campaign:read"}},
+	}}
+	r := NewResolver(store, cache.NewMemoryCache(), ResolverConfig{})
+
+	key, err := r.Resolve(ctx, generated.Token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), key.ID)
+	assert.True(t, key.HasScope("campaign:read"))
+
+	_, err = r.Resolve(ctx, generated.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.queries, "second lookup is served from cache")
+	assert.Equal(t, []int64{7}, store.touched, "last used is throttled")
+
+	// A wrong secret with a valid prefix is rejected from cache too
+	_, err = r.Resolve(ctx, TokenPrefix+generated.Prefix+"_wrong-secret")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	// Revocation applies once the cached key is invalidated
+	now := time.Now()
+	store.keys[generated.Prefix].RevokedAt = &now
+	require.NoError(t, r.Invalidate(ctx, generated.Prefix))
+	_, err = r.Resolve(ctx, generated.Token)
+	assert.ErrorIs(t, err, ErrKeyRevoked)
+}
+
+func TestResolverUnknownKey(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{keys: map[string]*Key{}}
+	r := NewResolver(store, cache.NewMemoryCache(), ResolverConfig{})
+
+	unknown, err := Generate()
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.Resolve(ctx, unknown.Token)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	}
+	assert.Equal(t, 1, store.queries, "unknown prefixes are cached as missing")
+
+	_, err = r.Resolve(ctx, "not-a-key")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.Equal(t, 1, store.queries, "malformed keys never reach the store")
+}
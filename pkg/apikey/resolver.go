@@ -0,0 +1,160 @@
+package apikey
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// Defaults when none are configured
+const (
+	defaultCacheTTL     = time.Minute
+	defaultTouchEvery   = time.Minute
+	maxNegativeCacheTTL = time.Minute
+)
+
+// missing is cached for prefixes without a key, so made up keys do not each cost a query
+const missing = "-"
+
+// Store loads keys, implemented on top of the API key repository
+type Store interface {
+	// GetByPrefix returns the key with prefix, ErrNotFound when there is none
+	GetByPrefix(ctx context.Context, prefix string) (*Key, error)
+
+	// TouchLastUsed records that the key was used at t
+	TouchLastUsed(ctx context.Context, id int64, t time.Time) error
+}
+
+// Resolver turns the key a request presents into the stored key
+type Resolver interface {
+	// Resolve verifies token, ErrInvalidKey, ErrKeyExpired or ErrKeyRevoked when it may not be used
+	Resolve(ctx context.Context, token string) (*Key, error)
+
+	// Invalidate drops the cached key with prefix, called after revoking or rotating it
+	// Only the cache of the resolver is cleared, with a per-process cache other instances keep the key for up to CacheTTL
+	Invalidate(ctx context.Context, prefix string) error
+}
+
+// ResolverConfig configures caching of keys
+type ResolverConfig struct {
+	CacheTTL   time.Duration // How long keys are cached, also how long a revoked key lasts on instances not sharing the cache
+	TouchEvery time.Duration // Last used is written at most once per interval per key
+}
+
+type resolver struct {
+	store Store
+	cache cache.Cache
+	cfg   ResolverConfig
+	keys  *cache.CacheKey
+	touch *cache.CacheKey
+}
+
+// NewResolver creates a resolver caching keys in c, cache failures fall back to the store
+func NewResolver(store Store, c cache.Cache, cfg ResolverConfig) Resolver {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	if cfg.TouchEvery <= 0 {
+		cfg.TouchEvery = defaultTouchEvery
+	}
+
+	return &resolver{
+		store: store,
+		cache: c,
+		cfg:   cfg,
+		keys:  cache.NewCacheKey("apikey"),
+		touch: cache.NewCacheKey("apikey:touched"),
+	}
+}
+
+func (r *resolver) Resolve(ctx context.Context, token string) (*Key, error) {
+	prefix, ok := Parse(token)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+
+	key, err := r.lookup(ctx, prefix)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := key.Verify(token, now); err != nil {
+		return nil, err
+	}
+
+	r.touchLastUsed(ctx, key, now)
+	return key, nil
+}
+
+func (r *resolver) Invalidate(ctx context.Context, prefix string) error {
+	return r.cache.Delete(ctx, r.keys.Build(prefix))
+}
+
+// lookup reads the key from cache, loading and caching it from the store on a miss
+func (r *resolver) lookup(ctx context.Context, prefix string) (*Key, error) {
+	cacheKey := r.keys.Build(prefix)
+
+	if cached, err := r.cache.Get(ctx, cacheKey); err == nil {
+		if cached == missing {
+			return nil, ErrNotFound
+		}
+
+		var key Key
+		if err := json.Unmarshal([]byte(cached), &key); err == nil {
+			return &key, nil
+		}
+	}
+
+	key, err := r.store.GetByPrefix(ctx, prefix)
+	if errors.Is(err, ErrNotFound) {
+		r.set(ctx, cacheKey, missing, min(r.cfg.CacheTTL, maxNegativeCacheTTL))
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(key); err == nil {
+		r.set(ctx, cacheKey, string(data), r.cfg.CacheTTL)
+	}
+	return key, nil
+}
+
+func (r *resolver) set(ctx context.Context, cacheKey string, value string, ttl time.Duration) {
+	if err := r.cache.Set(ctx, cacheKey, value, ttl); err != nil {
+		lf := logger.NewFields("APIKey.Resolve").WithTrace(ctx)
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to cache API key", lf)
+	}
+}
+
+// touchLastUsed records usage at most once per TouchEvery, so busy keys do not write on every request
+func (r *resolver) touchLastUsed(ctx context.Context, key *Key, now time.Time) {
+	lf := logger.NewFields("APIKey.touchLastUsed").WithTrace(ctx)
+	lf.Append(logger.Any("api_key_id", key.ID))
+
+	touchKey := r.touch.Build(strconv.FormatInt(key.ID, 10))
+	n, err := r.cache.IncrByWithExpiry(ctx, touchKey, 1, r.cfg.TouchEvery)
+	if err != nil {
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to throttle API key last used", lf)
+		return
+	}
+	if n != 1 {
+		return
+	}
+
+	if err := r.store.TouchLastUsed(ctx, key.ID, now); err != nil {
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to record API key last used", lf)
+	}
+}
@@ -1,37 +1,117 @@
 package app
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/hanifkf12/hanif_skeleton/internal/router"
 	"github.com/hanifkf12/hanif_skeleton/pkg/config"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
 	"github.com/hanifkf12/hanif_skeleton/pkg/middleware"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type App struct {
 	*fiber.App
-	Cfg *config.Config
+	Cfg       *config.Config
+	Lifecycle *Lifecycle
 }
 
-func InitializeApp(cfg *config.Config) *App {
+func InitializeApp(cfg *config.Config, lifecycle *Lifecycle) *App {
 	f := fiber.New(fiber.Config{})
 
 	// Add global trace middleware to ensure all requests are traced
 	f.Use(middleware.TraceMiddleware())
 
-	rtr := router.NewRouter(cfg, f)
+	rtr := router.NewRouter(cfg, f, lifecycle)
 
 	rtr.Route()
 
 	// Initialize default config (Assign the middleware to /metrics)
 	f.Get("/metrics", monitor.New())
+
+	f.Hooks().OnListen(func(fiber.ListenData) error {
+		lifecycle.SetReady(true)
+		return nil
+	})
+
 	return &App{
-		App: f,
-		Cfg: cfg,
+		App:       f,
+		Cfg:       cfg,
+		Lifecycle: lifecycle,
 	}
 }
 
+// Run starts the server and blocks until it fails or SIGINT/SIGTERM is received,
+// in which case in-flight requests are drained before resources are released
 func (app *App) Run() error {
-	return app.Listen(fmt.Sprintf("%s:%s", "localhost", app.Cfg.App.Port))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- app.Listen(fmt.Sprintf("%s:%s", "localhost", app.Cfg.App.Port))
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case err := <-errCh:
+		// Server never started or stopped on its own, still release resources
+		_ = app.Lifecycle.Shutdown(context.Background())
+		return err
+	case sig := <-quit:
+		lf := logger.NewFields("App.Run")
+		lf.Append(logger.Any("signal", sig.String()))
+		logger.Info("Received shutdown signal", lf)
+	}
+
+	if err := app.GracefulShutdown(); err != nil {
+		return err
+	}
+
+	return <-errCh
+}
+
+// GracefulShutdown fails readiness, waits SHUTDOWN_DELAY so load balancers stop routing,
+// drains in-flight requests within SHUTDOWN_TIMEOUT and then runs registered closers
+func (app *App) GracefulShutdown() error {
+	lf := logger.NewFields("App.GracefulShutdown")
+
+	timeout := app.Cfg.App.ShutdownTimeout
+	if timeout == 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	lf.Append(logger.Any("timeout", timeout.String()))
+	lf.Append(logger.Any("delay", app.Cfg.App.ShutdownDelay.String()))
+
+	app.Lifecycle.SetReady(false)
+	if app.Cfg.App.ShutdownDelay > 0 {
+		logger.Info("Readiness set to failing, waiting before draining", lf)
+		time.Sleep(app.Cfg.App.ShutdownDelay)
+	}
+
+	logger.Info("Draining HTTP server", lf)
+	drainErr := app.ShutdownWithTimeout(timeout)
+	if drainErr != nil {
+		lf.Append(logger.Any("error", drainErr.Error()))
+		logger.Error("HTTP server did not drain in time", lf)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := app.Lifecycle.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	logger.Info("HTTP server stopped gracefully", lf)
+	return drainErr
 }
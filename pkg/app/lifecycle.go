@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// Closer releases a resource during shutdown
+type Closer func(ctx context.Context) error
+
+type namedCloser struct {
+	name string
+	fn   Closer
+}
+
+// Lifecycle tracks readiness and the resources to release on shutdown
+// Closers run in reverse registration order, so resources created first are closed last
+type Lifecycle struct {
+	mu      sync.Mutex
+	closers []namedCloser
+	ready   atomic.Bool
+	once    sync.Once
+}
+
+// NewLifecycle creates a new lifecycle manager, not ready until SetReady is called
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// OnShutdown registers a closer to run on shutdown
+func (l *Lifecycle) OnShutdown(name string, fn func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closers = append(l.closers, namedCloser{name: name, fn: fn})
+}
+
+// SetReady flips the readiness state reported to probes
+func (l *Lifecycle) SetReady(ready bool) {
+	l.ready.Store(ready)
+}
+
+// IsReady reports whether the application accepts traffic
+func (l *Lifecycle) IsReady() bool {
+	return l.ready.Load()
+}
+
+// Shutdown marks the application as not ready and runs all closers once
+// Every closer runs even if an earlier one fails, errors are joined
+func (l *Lifecycle) Shutdown(ctx context.Context) error {
+	var err error
+
+	l.once.Do(func() {
+		l.SetReady(false)
+
+		l.mu.Lock()
+		closers := make([]namedCloser, len(l.closers))
+		copy(closers, l.closers)
+		l.mu.Unlock()
+
+		var errs []error
+		for i := len(closers) - 1; i >= 0; i-- {
+			c := closers[i]
+
+			lf := logger.NewFields("Lifecycle.Shutdown")
+			lf.Append(logger.Any("resource", c.name))
+
+			if cerr := c.fn(ctx); cerr != nil {
+				lf.Append(logger.Any("error", cerr.Error()))
+				logger.Error("Failed to close resource", lf)
+				errs = append(errs, fmt.Errorf("%s: %w", c.name, cerr))
+				continue
+			}
+
+			logger.Info("Resource closed", lf)
+		}
+
+		err = errors.Join(errs...)
+	})
+
+	return err
+}
@@ -0,0 +1,213 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Kind classifies an error so every transport can decide how to react to it
+type Kind string
+
+const (
+	KindInternal     Kind = "internal"
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindRateLimited  Kind = "rate_limited" // Too many attempts, the caller has to wait before retrying
+	KindTransient    Kind = "transient"    // Temporary failure, retrying may succeed
+	KindPermanent    Kind = "permanent"    // Retrying will never succeed, e.g. a malformed message
+)
+
+// Error is a classified application error
+// Message and Details are safe to return to clients, Err is the cause and is only logged
+type Error struct {
+	Kind    Kind
+	Code    string // Machine readable code, e.g. "campaign_not_found"
+	Message string
+	Details interface{}
+	Err     error
+}
+
+// New creates an error of any kind
+func New(kind Kind, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(code string, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict creates an error for a request that clashes with the current state, e.g. a duplicate
+func Conflict(code string, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Validation creates an error for invalid input, Details usually holds per-field errors
+func Validation(code string, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Unauthorized creates an error for missing or invalid credentials
+func Unauthorized(code string, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden creates an error for valid credentials lacking permission
+func Forbidden(code string, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// RateLimited creates an error for a caller exceeding a limit, e.g. repeated failed logins
+func RateLimited(code string, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Transient creates an error for a temporary failure, e.g. a dependency timing out
+func Transient(code string, message string) *Error {
+	return New(KindTransient, code, message)
+}
+
+// Permanent creates an error that will fail the same way however often it is retried
+func Permanent(code string, message string) *Error {
+	return New(KindPermanent, code, message)
+}
+
+// Internal creates an error for an unexpected failure
+func Internal(code string, message string) *Error {
+	return New(KindInternal, code, message)
+}
+
+// Wrap returns a copy of e caused by err, so package level errors can be reused safely
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithDetails returns a copy of e carrying client facing details
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same kind and code, so errors.Is works against package level errors
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Kind == t.Kind && e.Code == t.Code
+}
+
+// As finds the first *Error in err's chain
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// KindOf classifies any error, unclassified errors are internal except context deadlines which are transient
+func KindOf(err error) Kind {
+	if err == nil {
+		return ""
+	}
+
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return KindTransient
+	}
+
+	return KindInternal
+}
+
+// HTTPStatus maps an error to the HTTP status code returned to clients
+func HTTPStatus(err error) int {
+	switch KindOf(err) {
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindRateLimited:
+		return http.StatusTooManyRequests
+	case KindTransient:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Retryable reports whether redelivering a message or retrying a job may succeed
+// Unclassified errors are retried, the queue's retry limit still applies
+func Retryable(err error) bool {
+	switch KindOf(err) {
+	case KindNotFound, KindConflict, KindValidation, KindUnauthorized, KindForbidden, KindPermanent:
+		return false
+	default:
+		return true
+	}
+}
+
+// Body is the client facing representation of an error
+type Body struct {
+	Kind    Kind        `json:"kind"`
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ToBody renders err for clients, the cause of internal errors is never exposed
+func ToBody(err error) Body {
+	if appErr, ok := As(err); ok {
+		return Body{
+			Kind:    appErr.Kind,
+			Code:    appErr.Code,
+			Message: appErr.Message,
+			Details: appErr.Details,
+		}
+	}
+
+	if KindOf(err) == KindTransient {
+		return Body{
+			Kind:    KindTransient,
+			Code:    "timeout",
+			Message: "The request timed out, please retry",
+		}
+	}
+
+	return Body{
+		Kind:    KindInternal,
+		Code:    "internal_error",
+		Message: "Internal server error",
+	}
+}
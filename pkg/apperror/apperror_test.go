@@ -0,0 +1,70 @@
+package apperror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errUserNotFound = NotFound("user_not_found", "User not found")
+
+func TestWrap_KeepsPackageErrorUntouched(t *testing.T) {
+	cause := errors.New("sql: no rows in result set")
+	err := errUserNotFound.Wrap(cause).WithDetails(map[string]int64{"id": 7})
+
+	assert.Nil(t, errUserNotFound.Err)
+	assert.Nil(t, errUserNotFound.Details)
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, err, errUserNotFound)
+	assert.Equal(t, "User not found: sql: no rows in result set", err.Error())
+}
+
+func TestKindOf_FindsWrappedError(t *testing.T) {
+	err := fmt.Errorf("get user: %w", Conflict("email_taken", "Email already registered"))
+
+	assert.Equal(t, KindConflict, KindOf(err))
+	assert.Equal(t, KindInternal, KindOf(errors.New("boom")))
+	assert.Equal(t, KindTransient, KindOf(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.Equal(t, Kind(""), KindOf(nil))
+}
+
+func TestHTTPStatus(t *testing.T) {
+	cases := map[int]error{
+		http.StatusNotFound:            NotFound("x", "x"),
+		http.StatusConflict:            Conflict("x", "x"),
+		http.StatusUnprocessableEntity: Validation("x", "x"),
+		http.StatusUnauthorized:        Unauthorized("x", "x"),
+		http.StatusForbidden:           Forbidden("x", "x"),
+		http.StatusTooManyRequests:     RateLimited("x", "x"),
+		http.StatusServiceUnavailable:  Transient("x", "x"),
+		http.StatusInternalServerError: errors.New("boom"),
+	}
+
+	for status, err := range cases {
+		assert.Equal(t, status, HTTPStatus(err), err.Error())
+	}
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(Permanent("x", "x")))
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, Retryable(Transient("upstream_timeout", "Upstream timed out")))
+	assert.True(t, Retryable(errors.New("connection reset")))
+	assert.False(t, Retryable(Permanent("malformed_payload", "Malformed payload")))
+	assert.False(t, Retryable(fmt.Errorf("job: %w", Validation("invalid", "Invalid"))))
+	assert.False(t, Retryable(errUserNotFound))
+}
+
+func TestToBody_HidesInternalCause(t *testing.T) {
+	body := ToBody(errors.New("pq: password authentication failed"))
+	assert.Equal(t, KindInternal, body.Kind)
+	assert.Equal(t, "Internal server error", body.Message)
+
+	body = ToBody(Validation("validation_failed", "Validation failed").WithDetails([]string{"name is required"}))
+	assert.Equal(t, KindValidation, body.Kind)
+	assert.Equal(t, "validation_failed", body.Code)
+	assert.Equal(t, []string{"name is required"}, body.Details)
+}
@@ -0,0 +1,193 @@
+package authz
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ownSuffix marks a permission that only applies to resources the subject owns, e.g. "user:update:own"
+const ownSuffix = ":own"
+
+var ErrUnknownRole = errors.New("unknown role")
+
+// Policy maps roles to permissions, loaded from a JSON file:
+//
+//	{
+//	  "roles": {
+//	    "viewer": {"permissions": ["campaign:read"]},
+//	    "editor": {"inherits": ["viewer"], "permissions": ["campaign:write", "user:update:own"]},
+//	    "admin":  {"permissions": ["*"]}
+//	  }
+//	}
+//
+// Permissions are "resource:action", "resource:*" grants every action and "*" grants everything
+type Policy struct {
+	Roles map[string]Role `json:"roles"`
+}
+
+// Role is a named set of permissions, inherited roles add their permissions
+type Role struct {
+	Inherits    []string `json:"inherits,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Subject is who asks for access
+type Subject struct {
+	ID    int64
+	Roles []string
+}
+
+// Resource is what access is asked for, used by ownership checks
+type Resource struct {
+	OwnerID int64
+}
+
+// Authorizer answers permission checks against a policy
+type Authorizer interface {
+	// Can reports whether any role of subject grants permission
+	Can(subject Subject, permission string) bool
+
+	// CanOn reports whether subject may act on resource, "<permission>:own" grants it for owned resources
+	CanOn(subject Subject, permission string, resource Resource) bool
+
+	// Permissions lists the effective permissions of role, inherited ones included
+	Permissions(role string) []string
+}
+
+// authorizer holds the flattened policy, inheritance is resolved once on creation
+type authorizer struct {
+	grants map[string][]string
+}
+
+// NewAuthorizer creates an authorizer, unknown inherited roles and inheritance cycles are rejected
+func NewAuthorizer(policy Policy) (Authorizer, error) {
+	a := &authorizer{grants: make(map[string][]string, len(policy.Roles))}
+
+	for name := range policy.Roles {
+		seen := map[string]bool{}
+		if err := a.resolve(policy, name, seen, nil); err != nil {
+			return nil, err
+		}
+
+		perms := make([]string, 0, len(seen))
+		for p := range seen {
+			perms = append(perms, p)
+		}
+		sort.Strings(perms)
+		a.grants[name] = perms
+	}
+
+	return a, nil
+}
+
+// resolve collects the permissions of role and its ancestors into seen
+func (a *authorizer) resolve(policy Policy, role string, seen map[string]bool, path []string) error {
+	for _, p := range path {
+		if p == role {
+			return fmt.Errorf("role inheritance cycle: %s -> %s", strings.Join(path, " -> "), role)
+		}
+	}
+
+	r, ok := policy.Roles[role]
+	if !ok {
+		return fmt.Errorf("%w %q inherited by %q", ErrUnknownRole, role, path[len(path)-1])
+	}
+
+	for _, p := range r.Permissions {
+		seen[p] = true
+	}
+
+	for _, parent := range r.Inherits {
+		if err := a.resolve(policy, parent, seen, append(path, role)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *authorizer) Can(subject Subject, permission string) bool {
+	return a.granted(subject, permission)
+}
+
+func (a *authorizer) CanOn(subject Subject, permission string, resource Resource) bool {
+	if a.granted(subject, permission) {
+		return true
+	}
+
+	// Anonymous subjects and unowned resources never match
+	owner := subject.ID != 0 && resource.OwnerID == subject.ID
+	return owner && a.granted(subject, permission+ownSuffix)
+}
+
+func (a *authorizer) Permissions(role string) []string {
+	return append([]string(nil), a.grants[role]...)
+}
+
+func (a *authorizer) granted(subject Subject, permission string) bool {
+	for _, role := range subject.Roles {
+		for _, grant := range a.grants[role] {
+			if matches(grant, permission) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches reports whether grant covers permission, "campaign:*" covers "campaign:write" and "campaign:write:own"
+func matches(grant string, permission string) bool {
+	if grant == "*" || grant == permission {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(grant, "*"); ok {
+		return strings.HasPrefix(permission, prefix)
+	}
+	return false
+}
+
+// LoadPolicy reads a JSON policy file
+func LoadPolicy(path string) (Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Policy{}, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// DefaultPolicy is used when no policy file is configured
+func DefaultPolicy() Policy {
+	return Policy{
+		Roles: map[string]Role{
+			"viewer": {
+				Permissions: []string{"campaign:read"},
+			},
+			"user": {
+				Inherits:    []string{"viewer"},
+				Permissions: []string{"user:update:own"},
+			},
+			// Listing users exposes every email, plain users (the default role) only see themselves
+			"editor": {
+				Inherits:    []string{"user"},
+				Permissions: []string{"campaign:write", "campaign:delete", "user:read"},
+			},
+			"admin": {
+				Inherits:    []string{"editor"},
+				Permissions: []string{"user:*", "apikey:*", "cache:read"},
+			},
+			"superadmin": {
+				Permissions: []string{"*"},
+			},
+		},
+	}
+}
@@ -0,0 +1,99 @@
+package authz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizer(t *testing.T) {
+	a, err := NewAuthorizer(DefaultPolicy())
+	require.NoError(t, err)
+
+	viewer := Subject{ID: 1, Roles: []string{"viewer"}}
+	user := Subject{ID: 2, Roles: []string{"user"}}
+	editor := Subject{ID: 3, Roles: []string{"editor"}}
+	admin := Subject{ID: 4, Roles: []string{"admin"}}
+	superadmin := Subject{ID: 5, Roles: []string{"superadmin"}}
+
+	tests := []struct {
+		name       string
+		subject    Subject
+		permission string
+		want       bool
+	}{
+		{"viewer reads", viewer, "campaign:read", true},
+		{"viewer cannot write", viewer, "campaign:write", false},
+		{"editor inherits read", editor, "campaign:read", true},
+		{"editor writes", editor, "campaign:write", true},
+		{"editor cannot delete users", editor, "user:delete", false},
+		{"admin wildcard", admin, "user:delete", true},
+		{"admin inherits campaign write", admin, "campaign:write", true},
+		{"superadmin everything", superadmin, "anything:at-all", true},
+		{"own grant is not a global grant", user, "user:update", false},
+		{"user cannot list users", user, "user:read", false},
+		{"viewer cannot list users", viewer, "user:read", false},
+		{"editor lists users", editor, "user:read", true},
+		{"no roles", Subject{ID: 6}, "campaign:read", false},
+		{"unknown role", Subject{ID: 7, Roles: []string{"ghost"}}, "campaign:read", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, a.Can(tt.subject, tt.permission))
+		})
+	}
+}
+
+func TestOwnership(t *testing.T) {
+	a, err := NewAuthorizer(DefaultPolicy())
+	require.NoError(t, err)
+
+	user := Subject{ID: 2, Roles: []string{"user"}}
+	admin := Subject{ID: 4, Roles: []string{"admin"}}
+
+	assert.True(t, a.CanOn(user, "user:update", Resource{OwnerID: 2}), "users may update themselves")
+	assert.False(t, a.CanOn(user, "user:update", Resource{OwnerID: 3}), "but nobody else")
+	assert.True(t, a.CanOn(admin, "user:update", Resource{OwnerID: 3}), "admins may update anyone")
+	assert.False(t, a.CanOn(Subject{Roles: []string{"user"}}, "user:update", Resource{}), "anonymous subjects own nothing")
+}
+
+func TestNewAuthorizerValidation(t *testing.T) {
+	_, err := NewAuthorizer(Policy{Roles: map[string]Role{
+		"a": {Inherits: []string{"b"}},
+		"b": {Inherits: []string{"a"}},
+	}})
+	assert.ErrorContains(t, err, "cycle")
+
+	_, err = NewAuthorizer(Policy{Roles: map[string]Role{
+		"a": {Inherits: []string{"missing"}},
+	}})
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	// Diamonds are not cycles
+	a, err := NewAuthorizer(Policy{Roles: map[string]Role{
+		"base":  {Permissions: []string{"x:read"}},
+		"left":  {Inherits: []string{"base"}},
+		"right": {Inherits: []string{"base"}},
+		"top":   {Inherits: []string{"left", "right"}, Permissions: []string{"x:write"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x:read", "x:write"}, a.Permissions("top"))
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	policy := `{"roles": {"viewer": {"permissions": ["campaign:read"]}, "editor": {"inherits": ["viewer"], "permissions": ["campaign:*"]}}}`
+	require.NoError(t, os.WriteFile(path, []byte(policy), 0o600))
+
+	loaded, err := LoadPolicy(path)
+	require.NoError(t, err)
+
+	a, err := NewAuthorizer(loaded)
+	require.NoError(t, err)
+	assert.True(t, a.Can(Subject{Roles: []string{"editor"}}, "campaign:delete"))
+	assert.False(t, a.Can(Subject{Roles: []string{"viewer"}}, "campaign:delete"))
+}
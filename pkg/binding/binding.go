@@ -0,0 +1,221 @@
+package binding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Struct tags read from request structs, the same tags Fiber's parsers use
+const (
+	TagJSON   = "json"
+	TagQuery  = "query"
+	TagParams = "params"
+	TagHeader = "reqHeader"
+)
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+
+	// sources caches which request parts a struct type binds from
+	sources sync.Map
+)
+
+// BindError is returned when the request cannot be decoded at all, e.g. malformed JSON
+type BindError struct {
+	Code int
+	Err  error
+}
+
+func (e *BindError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError describes why a single field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned when the request decoded but one or more fields are invalid
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, 0, len(v))
+	for _, fe := range v {
+		messages = append(messages, fe.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+type bindSources struct {
+	query   bool
+	params  bool
+	headers bool
+}
+
+// Bind decodes the request body, query string, headers and path params into out and validates it
+// Path params are applied last so a route's :id always wins over an id sent in the body
+func Bind(c *fiber.Ctx, out interface{}) error {
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(out); err != nil {
+			return decodeError(err)
+		}
+	}
+
+	src := sourcesOf(out)
+
+	if src.query {
+		if err := c.QueryParser(out); err != nil {
+			return &BindError{Code: fiber.StatusBadRequest, Err: err}
+		}
+	}
+
+	if src.headers {
+		if err := c.ReqHeaderParser(out); err != nil {
+			return &BindError{Code: fiber.StatusBadRequest, Err: err}
+		}
+	}
+
+	if src.params {
+		if err := c.ParamsParser(out); err != nil {
+			return &BindError{Code: fiber.StatusBadRequest, Err: err}
+		}
+	}
+
+	return Validate(out)
+}
+
+// Validate evaluates the `validate` tags of a struct, other values have nothing to validate
+func Validate(out interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(out))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := validatorInstance().Struct(out)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	result := make(ValidationErrors, 0, len(verrs))
+	for _, fe := range verrs {
+		result = append(result, FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fe),
+		})
+	}
+
+	return result
+}
+
+// RegisterValidation adds a custom validation rule usable in `validate` tags
+func RegisterValidation(tag string, fn validator.Func) error {
+	return validatorInstance().RegisterValidation(tag, fn)
+}
+
+func validatorInstance() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+
+		// Report the name clients send instead of the Go field name
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			for _, tag := range []string{TagJSON, TagParams, TagQuery, TagHeader} {
+				name := strings.Split(field.Tag.Get(tag), ",")[0]
+				if name == "-" {
+					continue
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return field.Name
+		})
+	})
+
+	return validate
+}
+
+// decodeError maps a body parsing failure, type mismatches are reported against their field
+func decodeError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return ValidationErrors{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Value,
+			Message: fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type.String()),
+		}}
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return &BindError{Code: fiberErr.Code, Err: err}
+	}
+
+	return &BindError{Code: fiber.StatusBadRequest, Err: err}
+}
+
+// sourcesOf reports which request parts a struct declares tags for, so fields are never
+// matched against query keys or headers by Go field name alone
+func sourcesOf(out interface{}) bindSources {
+	t := reflect.TypeOf(out)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if cached, ok := sources.Load(t); ok {
+		return cached.(bindSources)
+	}
+
+	var src bindSources
+	collectSources(t, &src)
+	sources.Store(t, src)
+
+	return src
+}
+
+func collectSources(t reflect.Type, src *bindSources) {
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			collectSources(ft, src)
+			continue
+		}
+
+		_, q := field.Tag.Lookup(TagQuery)
+		_, p := field.Tag.Lookup(TagParams)
+		_, h := field.Tag.Lookup(TagHeader)
+		src.query = src.query || q
+		src.params = src.params || p
+		src.headers = src.headers || h
+	}
+}
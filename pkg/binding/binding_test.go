@@ -0,0 +1,95 @@
+package binding
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type updateRequest struct {
+	ID      int64     `json:"id" params:"id" validate:"required"`
+	Name    string    `json:"name" validate:"required"`
+	Email   string    `json:"email" validate:"omitempty,email"`
+	Page    int       `query:"page" validate:"omitempty,gte=1"`
+	Tenant  string    `reqHeader:"X-Tenant-ID" validate:"required"`
+	EndDate time.Time `json:"end_date" validate:"omitempty,gt"`
+}
+
+func bindRequest(t *testing.T, target, body string, headers map[string]string) (*updateRequest, error) {
+	t.Helper()
+
+	var (
+		bound   *updateRequest
+		bindErr error
+	)
+
+	app := fiber.New()
+	app.Put("/users/:id", func(c *fiber.Ctx) error {
+		bound = new(updateRequest)
+		bindErr = Bind(c, bound)
+		return nil
+	})
+
+	req := httptest.NewRequest(fiber.MethodPut, target, strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return bound, bindErr
+}
+
+func TestBind_AllSources(t *testing.T) {
+	req, err := bindRequest(t, "/users/42?page=2", `{"id": 7, "name": "hanif"}`, map[string]string{"X-Tenant-ID": "acme"})
+	require.NoError(t, err)
+
+	// Path param wins over the body
+	assert.Equal(t, int64(42), req.ID)
+	assert.Equal(t, "hanif", req.Name)
+	assert.Equal(t, 2, req.Page)
+	assert.Equal(t, "acme", req.Tenant)
+}
+
+func TestBind_ValidationErrors(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	_, err := bindRequest(t, "/users/1?page=-1", `{"email": "not-an-email", "end_date": "`+past+`"}`, nil)
+
+	var verrs ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+
+	fields := map[string]FieldError{}
+	for _, fe := range verrs {
+		fields[fe.Field] = fe
+	}
+
+	assert.Equal(t, "name is required", fields["name"].Message)
+	assert.Equal(t, "email", fields["email"].Rule)
+	assert.Equal(t, "page must be greater than or equal to 1", fields["page"].Message)
+	assert.Equal(t, "required", fields["X-Tenant-ID"].Rule)
+	assert.Equal(t, "end_date must be in the future", fields["end_date"].Message)
+}
+
+func TestBind_DecodeErrors(t *testing.T) {
+	_, err := bindRequest(t, "/users/1", `{"name": `, map[string]string{"X-Tenant-ID": "acme"})
+
+	var bindErr *BindError
+	require.ErrorAs(t, err, &bindErr)
+	assert.Equal(t, fiber.StatusBadRequest, bindErr.Code)
+
+	_, err = bindRequest(t, "/users/1", `{"name": 10}`, map[string]string{"X-Tenant-ID": "acme"})
+
+	var verrs ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, "name", verrs[0].Field)
+	assert.Equal(t, "type", verrs[0].Rule)
+}
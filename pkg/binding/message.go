@@ -0,0 +1,69 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// message renders a client facing message for a failed rule
+func message(fe validator.FieldError) string {
+	field := fe.Field()
+	param := fe.Param()
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "uuid", "uuid4":
+		return fmt.Sprintf("%s must be a valid UUID", field)
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", field)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, strings.ReplaceAll(param, " ", ", "))
+	case "len":
+		return fmt.Sprintf("%s must be %s%s", field, param, unit(fe))
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", field, param, unit(fe))
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", field, param, unit(fe))
+	case "gt":
+		if isTime(fe) {
+			return fmt.Sprintf("%s must be in the future", field)
+		}
+		return fmt.Sprintf("%s must be greater than %s%s", field, param, unit(fe))
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s%s", field, param, unit(fe))
+	case "lt":
+		if isTime(fe) {
+			return fmt.Sprintf("%s must be in the past", field)
+		}
+		return fmt.Sprintf("%s must be less than %s%s", field, param, unit(fe))
+	case "lte":
+		return fmt.Sprintf("%s must be less than or equal to %s%s", field, param, unit(fe))
+	default:
+		return fmt.Sprintf("%s failed on the '%s' rule", field, fe.Tag())
+	}
+}
+
+// unit qualifies size rules, which count characters or items rather than compare values
+func unit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
+	}
+}
+
+func isTime(fe validator.FieldError) bool {
+	return fe.Type() == timeType
+}
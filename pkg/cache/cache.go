@@ -2,9 +2,14 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrWrongType is returned when a key holds another kind of value than the operation expects,
+// e.g. Get on a hash or HSet on a string
+var ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+
 // Cache is the interface for cache operations
 type Cache interface {
 	// Set sets a key-value pair with optional expiry
@@ -19,6 +24,16 @@ type Cache interface {
 	// Delete deletes a key
 	Delete(ctx context.Context, key string) error
 
+	// SetWithTags sets a key-value pair like Set and adds the key to tags, see InvalidateTags
+	// A key set again without its tags may stay in them until they are invalidated
+	SetWithTags(ctx context.Context, key string, value interface{}, expiry time.Duration, tags ...string) error
+
+	// InvalidateTags deletes every key set with any of tags, e.g. "campaign:42" or "campaign-list"
+	InvalidateTags(ctx context.Context, tags ...string) error
+
+	// SetNX sets a key only if it doesn't exist (atomic), reports whether it was set
+	SetNX(ctx context.Context, key string, value interface{}, expiry time.Duration) (bool, error)
+
 	// Exists checks if a key exists
 	Exists(ctx context.Context, key string) (bool, error)
 
@@ -28,10 +43,42 @@ type Cache interface {
 	// Decrement decrements a key's value
 	Decrement(ctx context.Context, key string) (int64, error)
 
+	// IncrByWithExpiry increments a key's value by value, a key it creates expires after expiry
+	// An existing key keeps its expiry, so a counter covers a fixed window from its first increment
+	IncrByWithExpiry(ctx context.Context, key string, value int64, expiry time.Duration) (int64, error)
+
+	// HSet sets fields of the hash stored at key, values are stored like Set stores them
+	HSet(ctx context.Context, key string, values map[string]interface{}) error
+
+	// HGet gets a field of the hash stored at key
+	HGet(ctx context.Context, key string, field string) (string, error)
+
+	// HGetAll gets all fields of the hash stored at key, empty if the key doesn't exist
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+
+	// HDel deletes fields of the hash stored at key, the key is deleted with its last field
+	HDel(ctx context.Context, key string, fields ...string) error
+
+	// ZAdd adds members to the sorted set stored at key, or updates their scores
+	ZAdd(ctx context.Context, key string, members ...ZMember) error
+
+	// ZIncrBy increments the score of member in the sorted set stored at key, returns the new score
+	ZIncrBy(ctx context.Context, key string, member string, increment float64) (float64, error)
+
+	// ZRangeByScore gets the members of the sorted set stored at key with a score within the range
+	ZRangeByScore(ctx context.Context, key string, opt ZRangeBy) ([]ZMember, error)
+
+	// ZRem removes members from the sorted set stored at key, the key is deleted with its last member
+	ZRem(ctx context.Context, key string, members ...string) error
+
+	// Eval runs script atomically, no other operation sees the keys while it runs, see NewScript
+	Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error)
+
 	// Expire sets expiry on an existing key
 	Expire(ctx context.Context, key string, expiry time.Duration) error
 
-	// Keys gets all keys matching pattern
+	// Keys gets all keys matching a glob pattern, e.g. "campaign:*"
+	// Scans the whole keyspace, use tags to invalidate groups of keys
 	Keys(ctx context.Context, pattern string) ([]string, error)
 
 	// FlushAll flushes all keys in current database
@@ -44,6 +91,22 @@ type Cache interface {
 	Ping(ctx context.Context) error
 }
 
+// ZMember is a member of a sorted set with its score
+type ZMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// ZRangeBy selects members of a sorted set by score, Min and Max are inclusive, use math.Inf for no bound
+// Members are ordered by score ascending, descending with Rev, Offset and Count page through them, Count 0 is all
+type ZRangeBy struct {
+	Min    float64
+	Max    float64
+	Rev    bool
+	Offset int64
+	Count  int64
+}
+
 // CacheKey is a helper to build cache keys with prefix
 type CacheKey struct {
 	prefix string
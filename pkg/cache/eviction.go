@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"container/list"
+	"fmt"
+)
+
+// EvictionPolicy picks which key MemoryCache evicts when it is full
+// Calls are made under the cache lock, implementations need no locking of their own,
+// but an instance must not be shared between caches
+type EvictionPolicy interface {
+	// Add records a new key
+	Add(key string)
+
+	// Touch records a read or write of an existing key
+	Touch(key string)
+
+	// Remove forgets a key that was deleted, expired or evicted
+	Remove(key string)
+
+	// Victim returns the key to evict next, false when there is none
+	Victim() (string, bool)
+
+	// Reset forgets all keys
+	Reset()
+}
+
+// EvictionReason tells an eviction callback why a key left the cache
+type EvictionReason string
+
+// Eviction reasons
+const (
+	EvictionCapacity EvictionReason = "capacity" // Evicted to stay within MaxEntries or MaxBytes
+	EvictionExpired  EvictionReason = "expired"  // Removed by the cleanup of expired keys
+)
+
+// NewEvictionPolicy returns the policy named name, lru or lfu
+func NewEvictionPolicy(name string) (EvictionPolicy, error) {
+	switch name {
+	case "", "lru":
+		return NewLRUPolicy(), nil
+	case "lfu":
+		return NewLFUPolicy(), nil
+	default:
+		return nil, fmt.Errorf("unsupported eviction policy: %s", name)
+	}
+}
+
+// lruPolicy evicts the least recently used key
+type lruPolicy struct {
+	order    *list.List // Most recently used first
+	elements map[string]*list.Element
+}
+
+// NewLRUPolicy creates a least recently used policy
+func NewLRUPolicy() EvictionPolicy {
+	return &lruPolicy{
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+func (p *lruPolicy) Add(key string) {
+	p.elements[key] = p.order.PushFront(key)
+}
+
+func (p *lruPolicy) Touch(key string) {
+	if element, ok := p.elements[key]; ok {
+		p.order.MoveToFront(element)
+	}
+}
+
+func (p *lruPolicy) Remove(key string) {
+	if element, ok := p.elements[key]; ok {
+		p.order.Remove(element)
+		delete(p.elements, key)
+	}
+}
+
+func (p *lruPolicy) Victim() (string, bool) {
+	oldest := p.order.Back()
+	if oldest == nil {
+		return "", false
+	}
+	return oldest.Value.(string), true
+}
+
+func (p *lruPolicy) Reset() {
+	p.order.Init()
+	p.elements = make(map[string]*list.Element)
+}
+
+// lfuPolicy evicts the least frequently used key, the least recently used among equally frequent ones
+// Buckets are kept in use count order, so the victim is always in the first one
+type lfuPolicy struct {
+	entries map[string]*lfuEntry
+	buckets *list.List // *lfuBucket by ascending freq, only non-empty buckets
+}
+
+type lfuBucket struct {
+	freq int
+	keys *list.List // Most recently used first
+}
+
+type lfuEntry struct {
+	bucket  *list.Element
+	element *list.Element
+}
+
+// NewLFUPolicy creates a least frequently used policy
+func NewLFUPolicy() EvictionPolicy {
+	return &lfuPolicy{
+		entries: make(map[string]*lfuEntry),
+		buckets: list.New(),
+	}
+}
+
+func (p *lfuPolicy) Add(key string) {
+	p.Remove(key)
+
+	front := p.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = p.buckets.PushFront(&lfuBucket{freq: 1, keys: list.New()})
+	}
+	p.entries[key] = &lfuEntry{bucket: front, element: front.Value.(*lfuBucket).keys.PushFront(key)}
+}
+
+func (p *lfuPolicy) Touch(key string) {
+	entry, ok := p.entries[key]
+	if !ok {
+		return
+	}
+
+	// The next bucket is created right after the current one, the order stays sorted
+	current := entry.bucket
+	freq := current.Value.(*lfuBucket).freq + 1
+	next := current.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != freq {
+		next = p.buckets.InsertAfter(&lfuBucket{freq: freq, keys: list.New()}, current)
+	}
+
+	p.unlink(entry)
+	entry.bucket = next
+	entry.element = next.Value.(*lfuBucket).keys.PushFront(key)
+}
+
+func (p *lfuPolicy) Remove(key string) {
+	if entry, ok := p.entries[key]; ok {
+		p.unlink(entry)
+		delete(p.entries, key)
+	}
+}
+
+func (p *lfuPolicy) Victim() (string, bool) {
+	lowest := p.buckets.Front()
+	if lowest == nil {
+		return "", false
+	}
+	return lowest.Value.(*lfuBucket).keys.Back().Value.(string), true
+}
+
+func (p *lfuPolicy) Reset() {
+	p.entries = make(map[string]*lfuEntry)
+	p.buckets.Init()
+}
+
+// unlink takes entry out of its bucket, empty buckets are dropped
+func (p *lfuPolicy) unlink(entry *lfuEntry) {
+	bucket := entry.bucket.Value.(*lfuBucket)
+	bucket.keys.Remove(entry.element)
+	if bucket.keys.Len() == 0 {
+		p.buckets.Remove(entry.bucket)
+	}
+}
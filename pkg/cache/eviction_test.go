@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func victims(p EvictionPolicy) []string {
+	var keys []string
+	for {
+		key, ok := p.Victim()
+		if !ok {
+			return keys
+		}
+		keys = append(keys, key)
+		p.Remove(key)
+	}
+}
+
+func TestLRUPolicy(t *testing.T) {
+	p := NewLRUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	p.Touch("a")
+	p.Remove("b")
+
+	assert.Equal(t, []string{"c", "a"}, victims(p))
+
+	p.Add("d")
+	p.Reset()
+	assert.Empty(t, victims(p))
+}
+
+func TestLFUPolicy(t *testing.T) {
+	p := NewLFUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	p.Touch("a")
+	p.Touch("a")
+	p.Touch("b")
+
+	// Equally frequent keys go least recently used first
+	p.Add("d")
+	assert.Equal(t, []string{"c", "d", "b", "a"}, victims(p))
+}
+
+func TestLFUPolicyRemoveLowestBucket(t *testing.T) {
+	p := NewLFUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Touch("b")
+	p.Touch("b")
+	p.Remove("a")
+
+	key, ok := p.Victim()
+	require.True(t, ok)
+	assert.Equal(t, "b", key)
+}
+
+func TestLFUPolicyKeepsOrderAfterRemovals(t *testing.T) {
+	p := NewLFUPolicy()
+	p.Add("a")
+	p.Add("b")
+	p.Add("c")
+	for i := 0; i < 3; i++ {
+		p.Touch("b")
+	}
+	p.Touch("c")
+
+	// Removing the only key of the lowest bucket leaves c as the least frequent
+	p.Remove("a")
+	key, ok := p.Victim()
+	require.True(t, ok)
+	assert.Equal(t, "c", key)
+
+	// A new key is less frequent than every existing one
+	p.Add("d")
+	assert.Equal(t, []string{"d", "c", "b"}, victims(p))
+}
+
+func TestLFUPolicyAddExistingKey(t *testing.T) {
+	p := NewLFUPolicy()
+	p.Add("a")
+	p.Touch("a")
+	p.Add("b")
+	p.Add("a")
+
+	// Adding again starts the count over, the old entry is not left behind
+	assert.Equal(t, []string{"b", "a"}, victims(p))
+}
+
+func TestNewEvictionPolicy(t *testing.T) {
+	for _, name := range []string{"", "lru", "lfu"} {
+		p, err := NewEvictionPolicy(name)
+		require.NoError(t, err, name)
+		assert.NotNil(t, p)
+	}
+
+	_, err := NewEvictionPolicy("random")
+	assert.Error(t, err)
+}
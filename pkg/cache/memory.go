@@ -2,28 +2,96 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
+// ErrEntryTooLarge is returned when a single key and value exceed MaxBytes
+var ErrEntryTooLarge = errors.New("cache entry larger than max bytes")
+
+// entryOverhead approximates the map entry, item and policy bookkeeping of a key
+const entryOverhead = 96
+
 // MemoryCache implements Cache interface using in-memory map
 // For development/testing purposes only - use Redis in production
 type MemoryCache struct {
 	data   map[string]*cacheItem
+	tags   map[string]map[string]struct{} // Keys by tag
+	policy EvictionPolicy
+	config MemoryConfig
 	mu     sync.RWMutex
 	stopCh chan struct{}
+
+	bytes       int64
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
+	evicted     []evictedItem // Waiting for OnEvict until the lock is released
+}
+
+// MemoryConfig holds memory cache configuration
+type MemoryConfig struct {
+	MaxEntries int            // Keys are evicted above this, 0 is unbounded
+	MaxBytes   int64          // Approximate size of keys and values, keys are evicted above this, 0 is unbounded
+	Policy     EvictionPolicy // Picks the key to evict, defaults to NewLRUPolicy()
+
+	// OnEvict is called for keys evicted to make room or removed after expiring, not for Delete or FlushAll
+	// It runs outside the cache lock, so it may use the cache
+	OnEvict func(key string, value interface{}, reason EvictionReason)
+}
+
+// MemoryStats counts what happened in a memory cache since start
+type MemoryStats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Evictions   int64 `json:"evictions"`   // Keys evicted to stay within MaxEntries or MaxBytes
+	Expirations int64 `json:"expirations"` // Expired keys removed
+	Entries     int   `json:"entries"`
+	Bytes       int64 `json:"bytes"`
 }
 
 type cacheItem struct {
 	value     interface{}
 	expiresAt time.Time
+	size      int64
+	tags      []string
+}
+
+// memoryHash and memorySortedSet are the values of hash and sorted set keys, changed in place
+type (
+	memoryHash      map[string]string
+	memorySortedSet map[string]float64
+)
+
+type evictedItem struct {
+	key    string
+	value  interface{}
+	reason EvictionReason
 }
 
 // NewMemoryCache creates a new in-memory cache instance
 func NewMemoryCache() Cache {
+	return NewMemoryCacheWithConfig(MemoryConfig{})
+}
+
+// NewMemoryCacheWithConfig creates a new in-memory cache instance, bounded by config
+func NewMemoryCacheWithConfig(config MemoryConfig) Cache {
+	policy := config.Policy
+	if policy == nil {
+		policy = NewLRUPolicy()
+	}
+
 	mc := &MemoryCache{
 		data:   make(map[string]*cacheItem),
+		tags:   make(map[string]map[string]struct{}),
+		policy: policy,
+		config: config,
 		stopCh: make(chan struct{}),
 	}
 
@@ -36,36 +104,38 @@ func NewMemoryCache() Cache {
 // Set sets a key-value pair with optional expiry
 func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	item := &cacheItem{
-		value: value,
-	}
+	defer c.unlock()
+	return c.set(key, value, expiry, nil)
+}
 
-	if expiry > 0 {
-		item.expiresAt = time.Now().Add(expiry)
-	}
+// SetWithTags sets a key-value pair and adds the key to the index of each tag
+func (c *MemoryCache) SetWithTags(ctx context.Context, key string, value interface{}, expiry time.Duration, tags ...string) error {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.set(key, value, expiry, tags)
+}
 
-	c.data[key] = item
+// InvalidateTags deletes every key set with any of tags
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.unlock()
+	c.invalidateTags(tags)
 	return nil
 }
 
+// SetNX sets a key only if it doesn't exist (atomic)
+func (c *MemoryCache) SetNX(ctx context.Context, key string, value interface{}, expiry time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.setNX(key, value, expiry)
+}
+
 // Get gets a value by key
 func (c *MemoryCache) Get(ctx context.Context, key string) (string, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	item, exists := c.data[key]
-	if !exists {
-		return "", fmt.Errorf("key not found: %s", key)
-	}
-
-	// Check if expired
-	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
-		return "", fmt.Errorf("key expired: %s", key)
-	}
-
-	return fmt.Sprintf("%v", item.value), nil
+	// Write lock, a read is recorded by the eviction policy
+	c.mu.Lock()
+	defer c.unlock()
+	return c.get(key)
 }
 
 // GetBytes gets a value as bytes
@@ -80,9 +150,8 @@ func (c *MemoryCache) GetBytes(ctx context.Context, key string) ([]byte, error)
 // Delete deletes a key
 func (c *MemoryCache) Delete(ctx context.Context, key string) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	delete(c.data, key)
+	defer c.unlock()
+	c.del(key)
 	return nil
 }
 
@@ -90,29 +159,204 @@ func (c *MemoryCache) Delete(ctx context.Context, key string) error {
 func (c *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	return c.exists(key), nil
+}
+
+// Increment increments a key's value
+func (c *MemoryCache) Increment(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.incrBy(key, 1, 0)
+}
+
+// Decrement decrements a key's value
+func (c *MemoryCache) Decrement(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.incrBy(key, -1, 0)
+}
+
+// IncrByWithExpiry increments a key's value by value, a key without expiry gets expiry
+func (c *MemoryCache) IncrByWithExpiry(ctx context.Context, key string, value int64, expiry time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.incrBy(key, value, expiry)
+}
+
+// Expire sets expiry on an existing key
+func (c *MemoryCache) Expire(ctx context.Context, key string, expiry time.Duration) error {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.expire(key, expiry)
+}
+
+// Keys gets all keys matching a glob pattern, like Redis KEYS
+func (c *MemoryCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	match, err := globMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keys(match), nil
+}
+
+// HSet sets fields of the hash stored at key
+func (c *MemoryCache) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.hset(key, values)
+}
+
+// HGet gets a field of the hash stored at key
+func (c *MemoryCache) HGet(ctx context.Context, key string, field string) (string, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.hget(key, field)
+}
+
+// HGetAll gets all fields of the hash stored at key
+func (c *MemoryCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.hgetAll(key)
+}
+
+// HDel deletes fields of the hash stored at key
+func (c *MemoryCache) HDel(ctx context.Context, key string, fields ...string) error {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.hdel(key, fields)
+}
+
+// ZAdd adds members to the sorted set stored at key
+func (c *MemoryCache) ZAdd(ctx context.Context, key string, members ...ZMember) error {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.zadd(key, members)
+}
+
+// ZIncrBy increments the score of member in the sorted set stored at key
+func (c *MemoryCache) ZIncrBy(ctx context.Context, key string, member string, increment float64) (float64, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.zincrBy(key, member, increment)
+}
+
+// ZRangeByScore gets the members of the sorted set stored at key with a score within the range
+func (c *MemoryCache) ZRangeByScore(ctx context.Context, key string, opt ZRangeBy) ([]ZMember, error) {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.zrangeByScore(key, opt)
+}
+
+// ZRem removes members from the sorted set stored at key
+func (c *MemoryCache) ZRem(ctx context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.zrem(key, members)
+}
 
+// Eval runs the Go function of script with the cache locked, its operations see no other writes
+func (c *MemoryCache) Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	if script.fn == nil {
+		return nil, ErrScriptUnsupported
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+	return script.fn(ctx, lockedCache{c: c}, keys, args)
+}
+
+// FlushAll flushes all keys
+func (c *MemoryCache) FlushAll(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.unlock()
+	c.flushAll()
+	return nil
+}
+
+// Close closes the cache
+func (c *MemoryCache) Close() error {
+	close(c.stopCh)
+	return nil
+}
+
+// Ping checks if cache is alive
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// The operations below are called with the lock held
+
+func (c *MemoryCache) set(key string, value interface{}, expiry time.Duration, tags []string) error {
+	item := &cacheItem{
+		value: value,
+		tags:  tags,
+	}
+
+	if expiry > 0 {
+		item.expiresAt = time.Now().Add(expiry)
+	}
+
+	return c.store(key, item)
+}
+
+func (c *MemoryCache) setNX(key string, value interface{}, expiry time.Duration) (bool, error) {
+	if _, exists := c.live(key); exists {
+		return false, nil
+	}
+
+	if err := c.set(key, value, expiry, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *MemoryCache) get(key string) (string, error) {
 	item, exists := c.data[key]
 	if !exists {
-		return false, nil
+		c.misses++
+		return "", fmt.Errorf("key not found: %s", key)
 	}
 
 	// Check if expired
-	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
-		return false, nil
+	if c.isExpired(item) {
+		c.misses++
+		c.evict(key, EvictionExpired)
+		return "", fmt.Errorf("key expired: %s", key)
 	}
 
-	return true, nil
+	switch item.value.(type) {
+	case memoryHash, memorySortedSet:
+		return "", ErrWrongType
+	}
+
+	c.hits++
+	c.policy.Touch(key)
+	return fmt.Sprintf("%v", item.value), nil
 }
 
-// Increment increments a key's value
-func (c *MemoryCache) Increment(ctx context.Context, key string) (int64, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *MemoryCache) del(key string) {
+	if item, exists := c.data[key]; exists {
+		c.remove(key, item)
+	}
+}
 
+func (c *MemoryCache) exists(key string) bool {
 	item, exists := c.data[key]
+	return exists && !c.isExpired(item)
+}
+
+// incrBy adds value to the integer at key, expiry applies to a key without one, 0 leaves it alone
+func (c *MemoryCache) incrBy(key string, value int64, expiry time.Duration) (int64, error) {
+	item, exists := c.live(key)
 	if !exists {
-		c.data[key] = &cacheItem{value: int64(1)}
-		return 1, nil
+		if err := c.set(key, value, expiry, nil); err != nil {
+			return 0, err
+		}
+		return value, nil
 	}
 
 	val, ok := item.value.(int64)
@@ -120,78 +364,463 @@ func (c *MemoryCache) Increment(ctx context.Context, key string) (int64, error)
 		return 0, fmt.Errorf("value is not an integer")
 	}
 
-	val++
+	val += value
 	item.value = val
+	if expiry > 0 && item.expiresAt.IsZero() {
+		item.expiresAt = time.Now().Add(expiry)
+	}
+	c.policy.Touch(key)
 	return val, nil
 }
 
-// Decrement decrements a key's value
-func (c *MemoryCache) Decrement(ctx context.Context, key string) (int64, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *MemoryCache) expire(key string, expiry time.Duration) error {
+	// An expired key is gone, setting a new expiry must not bring it back
+	item, exists := c.live(key)
+	if !exists {
+		return fmt.Errorf("key not found: %s", key)
+	}
 
-	item, exists := c.data[key]
+	item.expiresAt = time.Now().Add(expiry)
+	return nil
+}
+
+func (c *MemoryCache) keys(match func(string) bool) []string {
+	keys := []string{}
+	for k, item := range c.data {
+		if !c.isExpired(item) && match(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (c *MemoryCache) invalidateTags(tags []string) {
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			c.remove(key, c.data[key])
+		}
+	}
+}
+
+func (c *MemoryCache) flushAll() {
+	c.data = make(map[string]*cacheItem)
+	c.tags = make(map[string]map[string]struct{})
+	c.policy.Reset()
+	c.bytes = 0
+}
+
+func (c *MemoryCache) hset(key string, values map[string]interface{}) error {
+	fields := make(memoryHash, len(values))
+	for field, value := range values {
+		data, err := encodeValue(value)
+		if err != nil {
+			return err
+		}
+		fields[field] = fmt.Sprintf("%s", data)
+	}
+
+	item, exists := c.live(key)
 	if !exists {
-		c.data[key] = &cacheItem{value: int64(-1)}
-		return -1, nil
+		if len(fields) == 0 {
+			return nil
+		}
+		return c.store(key, &cacheItem{value: fields})
 	}
 
-	val, ok := item.value.(int64)
+	hash, ok := item.value.(memoryHash)
 	if !ok {
-		return 0, fmt.Errorf("value is not an integer")
+		return ErrWrongType
+	}
+	for field, value := range fields {
+		hash[field] = value
 	}
+	return c.resize(key, item)
+}
 
-	val--
-	item.value = val
+func (c *MemoryCache) hget(key string, field string) (string, error) {
+	hash, err := c.hash(key)
+	if err != nil {
+		return "", err
+	}
+
+	val, ok := hash[field]
+	if !ok {
+		return "", fmt.Errorf("field not found: %s %s", key, field)
+	}
 	return val, nil
 }
 
-// Expire sets expiry on an existing key
-func (c *MemoryCache) Expire(ctx context.Context, key string, expiry time.Duration) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *MemoryCache) hgetAll(key string) (map[string]string, error) {
+	hash, err := c.hash(key)
+	if err != nil {
+		return nil, err
+	}
 
-	item, exists := c.data[key]
+	fields := make(map[string]string, len(hash))
+	for field, value := range hash {
+		fields[field] = value
+	}
+	return fields, nil
+}
+
+func (c *MemoryCache) hdel(key string, fields []string) error {
+	item, exists := c.live(key)
 	if !exists {
-		return fmt.Errorf("key not found: %s", key)
+		return nil
 	}
 
-	item.expiresAt = time.Now().Add(expiry)
-	return nil
+	hash, ok := item.value.(memoryHash)
+	if !ok {
+		return ErrWrongType
+	}
+	for _, field := range fields {
+		delete(hash, field)
+	}
+
+	if len(hash) == 0 {
+		c.remove(key, item)
+		return nil
+	}
+	return c.resize(key, item)
 }
 
-// Keys gets all keys matching pattern (simple prefix match)
-func (c *MemoryCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+// hash returns the hash at key, touching it, nil if the key doesn't exist
+func (c *MemoryCache) hash(key string) (memoryHash, error) {
+	item, exists := c.live(key)
+	if !exists {
+		return nil, nil
+	}
+
+	hash, ok := item.value.(memoryHash)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	c.policy.Touch(key)
+	return hash, nil
+}
+
+func (c *MemoryCache) zadd(key string, members []ZMember) error {
+	item, exists := c.live(key)
+	if !exists {
+		if len(members) == 0 {
+			return nil
+		}
+		set := make(memorySortedSet, len(members))
+		for _, m := range members {
+			set[m.Member] = m.Score
+		}
+		return c.store(key, &cacheItem{value: set})
+	}
+
+	set, ok := item.value.(memorySortedSet)
+	if !ok {
+		return ErrWrongType
+	}
+	for _, m := range members {
+		set[m.Member] = m.Score
+	}
+	return c.resize(key, item)
+}
+
+func (c *MemoryCache) zincrBy(key string, member string, increment float64) (float64, error) {
+	item, exists := c.live(key)
+	if !exists {
+		if err := c.store(key, &cacheItem{value: memorySortedSet{member: increment}}); err != nil {
+			return 0, err
+		}
+		return increment, nil
+	}
+
+	set, ok := item.value.(memorySortedSet)
+	if !ok {
+		return 0, ErrWrongType
+	}
+	set[member] += increment
+	score := set[member]
+	return score, c.resize(key, item)
+}
+
+func (c *MemoryCache) zrangeByScore(key string, opt ZRangeBy) ([]ZMember, error) {
+	item, exists := c.live(key)
+	if !exists {
+		return []ZMember{}, nil
+	}
+
+	set, ok := item.value.(memorySortedSet)
+	if !ok {
+		return nil, ErrWrongType
+	}
+	c.policy.Touch(key)
+
+	members := make([]ZMember, 0, len(set))
+	for member, score := range set {
+		if score >= opt.Min && score <= opt.Max {
+			members = append(members, ZMember{Member: member, Score: score})
+		}
+	}
+
+	// Like Redis, equal scores are ordered by member
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i], members[j]
+		if opt.Rev {
+			a, b = b, a
+		}
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Member < b.Member
+	})
+
+	if opt.Offset >= int64(len(members)) {
+		return []ZMember{}, nil
+	}
+	members = members[opt.Offset:]
+	if opt.Count > 0 && opt.Count < int64(len(members)) {
+		members = members[:opt.Count]
+	}
+	return members, nil
+}
+
+func (c *MemoryCache) zrem(key string, members []string) error {
+	item, exists := c.live(key)
+	if !exists {
+		return nil
+	}
+
+	set, ok := item.value.(memorySortedSet)
+	if !ok {
+		return ErrWrongType
+	}
+	for _, member := range members {
+		delete(set, member)
+	}
+
+	if len(set) == 0 {
+		c.remove(key, item)
+		return nil
+	}
+	return c.resize(key, item)
+}
+
+// isExpired checks if an item has passed its expiry time
+func (c *MemoryCache) isExpired(item *cacheItem) bool {
+	return !item.expiresAt.IsZero() && time.Now().After(item.expiresAt)
+}
+
+// Stats returns the counters of the cache
+func (c *MemoryCache) Stats() MemoryStats {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	var keys []string
-	for k := range c.data {
-		keys = append(keys, k)
+	return MemoryStats{
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+		Entries:     len(c.data),
+		Bytes:       c.bytes,
 	}
-	return keys, nil
 }
 
-// FlushAll flushes all keys
-func (c *MemoryCache) FlushAll(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// store adds or replaces key, evicting keys picked by the policy until it fits
+// A replaced key starts over as a new key for the policy
+func (c *MemoryCache) store(key string, item *cacheItem) error {
+	item.size = entrySize(key, item.value)
+	if c.config.MaxBytes > 0 && item.size > c.config.MaxBytes {
+		return ErrEntryTooLarge
+	}
 
-	c.data = make(map[string]*cacheItem)
+	if old, exists := c.data[key]; exists {
+		c.remove(key, old)
+	}
+
+	for c.full(item.size) {
+		victim, ok := c.policy.Victim()
+		if !ok {
+			break
+		}
+		c.evict(victim, EvictionCapacity)
+	}
+
+	c.policy.Add(key)
+	c.data[key] = item
+	c.bytes += item.size
+
+	for _, tag := range item.tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
 	return nil
 }
 
-// Close closes the cache
-func (c *MemoryCache) Close() error {
-	close(c.stopCh)
-	return nil
+// full reports whether an entry of size does not fit without evicting
+func (c *MemoryCache) full(size int64) bool {
+	if c.config.MaxEntries > 0 && len(c.data) >= c.config.MaxEntries {
+		return true
+	}
+	return c.config.MaxBytes > 0 && c.bytes+size > c.config.MaxBytes
 }
 
-// Ping checks if cache is alive
-func (c *MemoryCache) Ping(ctx context.Context) error {
+// evict removes key and queues it for OnEvict, an expired victim counts as expired
+func (c *MemoryCache) evict(key string, reason EvictionReason) {
+	item := c.data[key]
+	if c.isExpired(item) {
+		reason = EvictionExpired
+	}
+	c.remove(key, item)
+
+	if reason == EvictionExpired {
+		c.expirations++
+	} else {
+		c.evictions++
+	}
+
+	if c.config.OnEvict != nil {
+		c.evicted = append(c.evicted, evictedItem{key: key, value: item.value, reason: reason})
+	}
+}
+
+// remove deletes key from the data, the policy, the tag index and the size
+func (c *MemoryCache) remove(key string, item *cacheItem) {
+	c.policy.Remove(key)
+	delete(c.data, key)
+	c.bytes -= item.size
+
+	for _, tag := range item.tags {
+		delete(c.tags[tag], key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// live returns the item of key, an expired item is evicted and reported missing
+func (c *MemoryCache) live(key string) (*cacheItem, bool) {
+	item, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	if c.isExpired(item) {
+		c.evict(key, EvictionExpired)
+		return nil, false
+	}
+	return item, true
+}
+
+// resize accounts for a value changed in place, evicting other keys until it fits
+// A value grown above MaxBytes is evicted itself
+func (c *MemoryCache) resize(key string, item *cacheItem) error {
+	size := entrySize(key, item.value)
+	c.bytes += size - item.size
+	item.size = size
+	c.policy.Touch(key)
+
+	if c.config.MaxBytes > 0 && size > c.config.MaxBytes {
+		c.evict(key, EvictionCapacity)
+		return ErrEntryTooLarge
+	}
+
+	for c.config.MaxBytes > 0 && c.bytes > c.config.MaxBytes {
+		victim, ok := c.policy.Victim()
+		if !ok {
+			break
+		}
+		c.evict(victim, EvictionCapacity)
+	}
 	return nil
 }
 
+// unlock releases the write lock, then runs OnEvict for the keys evicted while it was held
+func (c *MemoryCache) unlock() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+
+	for _, item := range evicted {
+		c.config.OnEvict(item.key, item.value, item.reason)
+	}
+}
+
+// globMatcher compiles a Redis glob pattern: * and ? wildcards, [abc], [^abc] and [a-z] classes, backslash escapes
+func globMatcher(pattern string) (func(string) bool, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			expr.WriteString("(?s:.*)")
+		case '?':
+			expr.WriteString("(?s:.)")
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			i += end + 1
+			negate := strings.HasPrefix(class, "^")
+			if negate {
+				class = class[1:]
+			}
+
+			expr.WriteString("[")
+			if negate {
+				expr.WriteString("^")
+			}
+			for _, r := range class {
+				if r == '-' {
+					expr.WriteRune(r)
+					continue
+				}
+				expr.WriteString(regexp.QuoteMeta(string(r)))
+			}
+			expr.WriteString("]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re.MatchString, nil
+}
+
+// entrySize approximates the memory held by key and value
+func entrySize(key string, value interface{}) int64 {
+	size := len(key) + entryOverhead
+	switch v := value.(type) {
+	case string:
+		size += len(v)
+	case []byte:
+		size += len(v)
+	case int, int64, uint64, float64:
+		size += 8
+	case memoryHash:
+		for field, val := range v {
+			size += len(field) + len(val)
+		}
+	case memorySortedSet:
+		for member := range v {
+			size += len(member) + 8
+		}
+	default:
+		size += len(fmt.Sprintf("%v", v))
+	}
+	return int64(size)
+}
+
 // cleanupExpired removes expired items periodically
 func (c *MemoryCache) cleanupExpired() {
 	ticker := time.NewTicker(1 * time.Minute)
@@ -201,15 +830,135 @@ func (c *MemoryCache) cleanupExpired() {
 		select {
 		case <-ticker.C:
 			c.mu.Lock()
-			now := time.Now()
 			for k, item := range c.data {
-				if !item.expiresAt.IsZero() && now.After(item.expiresAt) {
-					delete(c.data, k)
+				if c.isExpired(item) {
+					c.evict(k, EvictionExpired)
 				}
 			}
-			c.mu.Unlock()
+			c.unlock()
 		case <-c.stopCh:
 			return
 		}
 	}
 }
+
+// lockedCache is the cache given to the Go function of a script, the lock is held for it by Eval
+type lockedCache struct {
+	c *MemoryCache
+}
+
+func (l lockedCache) Set(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
+	return l.c.set(key, value, expiry, nil)
+}
+
+func (l lockedCache) Get(ctx context.Context, key string) (string, error) {
+	return l.c.get(key)
+}
+
+func (l lockedCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	val, err := l.c.get(key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(val), nil
+}
+
+func (l lockedCache) Delete(ctx context.Context, key string) error {
+	l.c.del(key)
+	return nil
+}
+
+func (l lockedCache) SetWithTags(ctx context.Context, key string, value interface{}, expiry time.Duration, tags ...string) error {
+	return l.c.set(key, value, expiry, tags)
+}
+
+func (l lockedCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	l.c.invalidateTags(tags)
+	return nil
+}
+
+func (l lockedCache) SetNX(ctx context.Context, key string, value interface{}, expiry time.Duration) (bool, error) {
+	return l.c.setNX(key, value, expiry)
+}
+
+func (l lockedCache) Exists(ctx context.Context, key string) (bool, error) {
+	return l.c.exists(key), nil
+}
+
+func (l lockedCache) Increment(ctx context.Context, key string) (int64, error) {
+	return l.c.incrBy(key, 1, 0)
+}
+
+func (l lockedCache) Decrement(ctx context.Context, key string) (int64, error) {
+	return l.c.incrBy(key, -1, 0)
+}
+
+func (l lockedCache) IncrByWithExpiry(ctx context.Context, key string, value int64, expiry time.Duration) (int64, error) {
+	return l.c.incrBy(key, value, expiry)
+}
+
+func (l lockedCache) Expire(ctx context.Context, key string, expiry time.Duration) error {
+	return l.c.expire(key, expiry)
+}
+
+func (l lockedCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	match, err := globMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return l.c.keys(match), nil
+}
+
+func (l lockedCache) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	return l.c.hset(key, values)
+}
+
+func (l lockedCache) HGet(ctx context.Context, key string, field string) (string, error) {
+	return l.c.hget(key, field)
+}
+
+func (l lockedCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return l.c.hgetAll(key)
+}
+
+func (l lockedCache) HDel(ctx context.Context, key string, fields ...string) error {
+	return l.c.hdel(key, fields)
+}
+
+func (l lockedCache) ZAdd(ctx context.Context, key string, members ...ZMember) error {
+	return l.c.zadd(key, members)
+}
+
+func (l lockedCache) ZIncrBy(ctx context.Context, key string, member string, increment float64) (float64, error) {
+	return l.c.zincrBy(key, member, increment)
+}
+
+func (l lockedCache) ZRangeByScore(ctx context.Context, key string, opt ZRangeBy) ([]ZMember, error) {
+	return l.c.zrangeByScore(key, opt)
+}
+
+func (l lockedCache) ZRem(ctx context.Context, key string, members ...string) error {
+	return l.c.zrem(key, members)
+}
+
+// Eval runs a nested script in the same lock
+func (l lockedCache) Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	if script.fn == nil {
+		return nil, ErrScriptUnsupported
+	}
+	return script.fn(ctx, l, keys, args)
+}
+
+func (l lockedCache) FlushAll(ctx context.Context) error {
+	l.c.flushAll()
+	return nil
+}
+
+// Close is not available to a script, the cache outlives it
+func (l lockedCache) Close() error {
+	return errors.New("cache cannot be closed from a script")
+}
+
+func (l lockedCache) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,379 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCacheWithConfig(MemoryConfig{MaxEntries: 2})
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, "a", "1", time.Minute))
+	require.NoError(t, c.Set(ctx, "b", "2", time.Minute))
+
+	// Reading a makes b the least recently used
+	_, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "c", "3", time.Minute))
+
+	_, err = c.Get(ctx, "b")
+	assert.Error(t, err)
+	for _, key := range []string{"a", "c"} {
+		exists, _ := c.Exists(ctx, key)
+		assert.True(t, exists, key)
+	}
+
+	// Replacing a key does not count twice
+	require.NoError(t, c.Set(ctx, "c", "4", time.Minute))
+	exists, _ := c.Exists(ctx, "a")
+	assert.True(t, exists)
+}
+
+func TestMemoryUnboundedByDefault(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	for i := 0; i < 100; i++ {
+		_, err := c.Increment(ctx, fmt.Sprintf("counter:%d", i))
+		require.NoError(t, err)
+	}
+
+	keys, err := c.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.Len(t, keys, 100)
+}
+
+func TestMemoryMaxBytes(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCacheWithConfig(MemoryConfig{MaxBytes: 3 * (entryOverhead + 2 + 10)})
+	defer c.Close()
+
+	for _, key := range []string{"k1", "k2", "k3", "k4"} {
+		require.NoError(t, c.Set(ctx, key, "0123456789", time.Minute))
+	}
+
+	stats := c.(*MemoryCache).Stats()
+	assert.Equal(t, 3, stats.Entries)
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.LessOrEqual(t, stats.Bytes, int64(3*(entryOverhead+2+10)))
+
+	exists, _ := c.Exists(ctx, "k1")
+	assert.False(t, exists)
+
+	err := c.Set(ctx, "big", string(make([]byte, 1000)), time.Minute)
+	assert.ErrorIs(t, err, ErrEntryTooLarge)
+}
+
+func TestMemoryEvictionCallbackAndStats(t *testing.T) {
+	ctx := context.Background()
+
+	type eviction struct {
+		key    string
+		reason EvictionReason
+	}
+	var evicted []eviction
+
+	var c Cache
+	c = NewMemoryCacheWithConfig(MemoryConfig{
+		MaxEntries: 1,
+		OnEvict: func(key string, value interface{}, reason EvictionReason) {
+			evicted = append(evicted, eviction{key, reason})
+			// Runs outside the lock, the cache can be used
+			_, _ = c.Exists(ctx, key)
+		},
+	})
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, "a", "1", 50*time.Millisecond))
+	require.NoError(t, c.Set(ctx, "b", "2", 50*time.Millisecond))
+
+	_, err := c.Get(ctx, "b")
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "a")
+	assert.Error(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = c.Get(ctx, "b")
+	assert.Error(t, err)
+
+	// Deleting is not an eviction
+	require.NoError(t, c.Set(ctx, "c", "3", time.Minute))
+	require.NoError(t, c.Delete(ctx, "c"))
+
+	assert.Equal(t, []eviction{{"a", EvictionCapacity}, {"b", EvictionExpired}}, evicted)
+	assert.Equal(t, MemoryStats{Hits: 1, Misses: 2, Evictions: 1, Expirations: 1}, c.(*MemoryCache).Stats())
+}
+
+func TestMemoryLFU(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCacheWithConfig(MemoryConfig{MaxEntries: 2, Policy: NewLFUPolicy()})
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, "hot", "1", time.Minute))
+	require.NoError(t, c.Set(ctx, "cold", "2", time.Minute))
+	for i := 0; i < 3; i++ {
+		_, _ = c.Get(ctx, "hot")
+	}
+	_, _ = c.Get(ctx, "cold")
+
+	// cold was read last, but hot was read more often
+	require.NoError(t, c.Set(ctx, "new", "3", time.Minute))
+
+	exists, _ := c.Exists(ctx, "hot")
+	assert.True(t, exists)
+	exists, _ = c.Exists(ctx, "cold")
+	assert.False(t, exists)
+}
+
+func TestMemoryInvalidateTags(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	require.NoError(t, c.SetWithTags(ctx, "campaign:42", "a", time.Minute, "campaign:42", "campaign-list"))
+	require.NoError(t, c.SetWithTags(ctx, "campaign:43", "b", time.Minute, "campaign:43", "campaign-list"))
+	require.NoError(t, c.SetWithTags(ctx, "campaigns:page:1", "c", time.Minute, "campaign-list"))
+	require.NoError(t, c.Set(ctx, "user:1", "d", time.Minute))
+
+	require.NoError(t, c.InvalidateTags(ctx, "campaign:42"))
+	keys, err := c.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"campaign:43", "campaigns:page:1", "user:1"}, keys)
+
+	require.NoError(t, c.InvalidateTags(ctx, "campaign-list", "unknown"))
+	keys, err = c.Keys(ctx, "*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user:1"}, keys)
+
+	// Deleted keys leave the index, so the tag does not grow
+	require.NoError(t, c.SetWithTags(ctx, "campaign:44", "e", time.Minute, "campaign:44"))
+	require.NoError(t, c.Delete(ctx, "campaign:44"))
+	assert.Empty(t, c.(*MemoryCache).tags)
+}
+
+func TestMemoryKeysPattern(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	for _, key := range []string{"campaign:1", "campaign:2", "campaign:10", "user:1", "files/a.txt", "h?llo"} {
+		require.NoError(t, c.Set(ctx, key, "v", time.Minute))
+	}
+	require.NoError(t, c.Set(ctx, "campaign:expired", "v", time.Millisecond))
+	time.Sleep(10 * time.Millisecond)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*", []string{"campaign:1", "campaign:2", "campaign:10", "user:1", "files/a.txt", "h?llo"}},
+		{"campaign:*", []string{"campaign:1", "campaign:2", "campaign:10"}},
+		{"campaign:?", []string{"campaign:1", "campaign:2"}},
+		{"campaign:[^1]", []string{"campaign:2"}},
+		{"campaign:[1-2]0", []string{"campaign:10"}},
+		{"files/*", []string{"files/a.txt"}},
+		{`h\?llo`, []string{"h?llo"}},
+		{"user:1", []string{"user:1"}},
+		{"nothing:*", []string{}},
+	}
+
+	for _, tt := range tests {
+		keys, err := c.Keys(ctx, tt.pattern)
+		require.NoError(t, err, tt.pattern)
+		assert.ElementsMatch(t, tt.want, keys, tt.pattern)
+	}
+}
+
+func TestMemoryHashes(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	require.NoError(t, c.HSet(ctx, "user:1", map[string]interface{}{"name": "hanif", "age": 30}))
+	require.NoError(t, c.HSet(ctx, "user:1", map[string]interface{}{"city": "Jakarta"}))
+
+	name, err := c.HGet(ctx, "user:1", "name")
+	require.NoError(t, err)
+	assert.Equal(t, "hanif", name)
+
+	_, err = c.HGet(ctx, "user:1", "email")
+	assert.Error(t, err)
+
+	fields, err := c.HGetAll(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "hanif", "age": "30", "city": "Jakarta"}, fields)
+
+	// A hash is not a string
+	_, err = c.Get(ctx, "user:1")
+	assert.ErrorIs(t, err, ErrWrongType)
+	require.NoError(t, c.Set(ctx, "plain", "v", time.Minute))
+	assert.ErrorIs(t, c.HSet(ctx, "plain", map[string]interface{}{"a": 1}), ErrWrongType)
+
+	// The key goes with its last field
+	require.NoError(t, c.HDel(ctx, "user:1", "name", "age", "city"))
+	exists, _ := c.Exists(ctx, "user:1")
+	assert.False(t, exists)
+
+	fields, err = c.HGetAll(ctx, "user:1")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestMemorySortedSets(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	require.NoError(t, c.ZAdd(ctx, "leaderboard",
+		ZMember{Member: "alice", Score: 30},
+		ZMember{Member: "bob", Score: 10},
+		ZMember{Member: "carol", Score: 20},
+		ZMember{Member: "dave", Score: 20},
+	))
+
+	score, err := c.ZIncrBy(ctx, "leaderboard", "bob", 25)
+	require.NoError(t, err)
+	assert.Equal(t, float64(35), score)
+
+	all := ZRangeBy{Min: math.Inf(-1), Max: math.Inf(1)}
+	members, err := c.ZRangeByScore(ctx, "leaderboard", all)
+	require.NoError(t, err)
+	assert.Equal(t, []ZMember{{"carol", 20}, {"dave", 20}, {"alice", 30}, {"bob", 35}}, members)
+
+	top2 := ZRangeBy{Min: math.Inf(-1), Max: math.Inf(1), Rev: true, Count: 2}
+	members, err = c.ZRangeByScore(ctx, "leaderboard", top2)
+	require.NoError(t, err)
+	assert.Equal(t, []ZMember{{"bob", 35}, {"alice", 30}}, members)
+
+	page := ZRangeBy{Min: 20, Max: 30, Offset: 1}
+	members, err = c.ZRangeByScore(ctx, "leaderboard", page)
+	require.NoError(t, err)
+	assert.Equal(t, []ZMember{{"dave", 20}, {"alice", 30}}, members)
+
+	require.NoError(t, c.ZRem(ctx, "leaderboard", "alice", "bob", "carol", "dave"))
+	members, err = c.ZRangeByScore(ctx, "leaderboard", all)
+	require.NoError(t, err)
+	assert.Empty(t, members)
+}
+
+func TestMemoryGrowingValueEvicts(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCacheWithConfig(MemoryConfig{MaxBytes: 400})
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, "old", "v", time.Minute))
+	require.NoError(t, c.HSet(ctx, "hash", map[string]interface{}{"a": "1"}))
+
+	// Growing the hash in place evicts the least recently used key
+	require.NoError(t, c.HSet(ctx, "hash", map[string]interface{}{"b": strings.Repeat("x", 250)}))
+	exists, _ := c.Exists(ctx, "old")
+	assert.False(t, exists)
+
+	// A hash grown above MaxBytes is evicted itself
+	err := c.HSet(ctx, "hash", map[string]interface{}{"c": strings.Repeat("x", 400)})
+	assert.ErrorIs(t, err, ErrEntryTooLarge)
+	exists, _ = c.Exists(ctx, "hash")
+	assert.False(t, exists)
+	assert.Equal(t, int64(0), c.(*MemoryCache).Stats().Bytes)
+}
+
+func TestMemoryIncrByWithExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	n, err := c.IncrByWithExpiry(ctx, "hits", 5, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), n)
+
+	// The window started by the first increment is not extended
+	time.Sleep(30 * time.Millisecond)
+	n, err = c.IncrByWithExpiry(ctx, "hits", 2, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+
+	time.Sleep(30 * time.Millisecond)
+	n, err = c.IncrByWithExpiry(ctx, "hits", 1, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// A counter without expiry gets one
+	_, err = c.Increment(ctx, "forever")
+	require.NoError(t, err)
+	_, err = c.IncrByWithExpiry(ctx, "forever", 1, 50*time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(80 * time.Millisecond)
+	exists, _ := c.Exists(ctx, "forever")
+	assert.False(t, exists)
+}
+
+func TestMemoryExpireExpiredKey(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	require.NoError(t, c.Set(ctx, "session", "abc", 20*time.Millisecond))
+	time.Sleep(40 * time.Millisecond)
+
+	// Like Redis, an expired key is missing and stays missing
+	assert.Error(t, c.Expire(ctx, "session", time.Minute))
+	_, err := c.Get(ctx, "session")
+	assert.Error(t, err)
+}
+
+func TestMemoryEval(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	// Takes ARGV[1] tokens if KEYS[1] still has them
+	take := NewScript(`
+local left = tonumber(redis.call('GET', KEYS[1]) or ARGV[2])
+if left < tonumber(ARGV[1]) then return -1 end
+redis.call('SET', KEYS[1], left - ARGV[1])
+return left - ARGV[1]
+`, func(ctx context.Context, c Cache, keys []string, args []interface{}) (interface{}, error) {
+		left := args[1].(int64)
+		if val, err := c.Get(ctx, keys[0]); err == nil {
+			left, _ = strconv.ParseInt(val, 10, 64)
+		}
+		if left < args[0].(int64) {
+			return int64(-1), nil
+		}
+		left -= args[0].(int64)
+		return left, c.Set(ctx, keys[0], strconv.FormatInt(left, 10), 0)
+	})
+
+	var wg sync.WaitGroup
+	var taken atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			left, err := c.Eval(ctx, take, []string{"tokens"}, int64(1), int64(10))
+			assert.NoError(t, err)
+			if left.(int64) >= 0 {
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), taken.Load())
+
+	_, err := c.Eval(ctx, NewScript("return 1", nil), nil)
+	assert.ErrorIs(t, err, ErrScriptUnsupported)
+}
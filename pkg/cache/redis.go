@@ -3,17 +3,73 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// scanCount is the number of keys Redis is asked to look at per SCAN call
+const scanCount = 1000
+
 // RedisCache implements Cache interface using Redis
 type RedisCache struct {
 	client *redis.Client
 }
 
+// setWithTagsScript sets KEYS[1] and adds it to the tag sets in KEYS[2:], atomically
+// A tag set lives as long as its longest lived key, and forever once it holds a key without expiry
+var setWithTagsScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+
+for i = 2, #KEYS do
+	local existed = redis.call('EXISTS', KEYS[i])
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if ttl <= 0 then
+		redis.call('PERSIST', KEYS[i])
+	elseif existed == 0 then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	else
+		local current = redis.call('PTTL', KEYS[i])
+		if current >= 0 and current < ttl then
+			redis.call('PEXPIRE', KEYS[i], ttl)
+		end
+	end
+end
+return 1
+`)
+
+// invalidateTagsScript deletes the tag sets in KEYS and every key in them, returning the deleted keys
+var invalidateTagsScript = redis.NewScript(`
+local deleted = {}
+for i = 1, #KEYS do
+	local members = redis.call('SMEMBERS', KEYS[i])
+	for _, key in ipairs(members) do
+		redis.call('UNLINK', key)
+		table.insert(deleted, key)
+	end
+	redis.call('UNLINK', KEYS[i])
+end
+return deleted
+`)
+
+// incrByWithExpiryScript increments KEYS[1] by ARGV[1] and sets a ARGV[2] milliseconds expiry if it has none
+var incrByWithExpiryScript = redis.NewScript(`
+local n = redis.call('INCRBY', KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call('PTTL', KEYS[1]) == -1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return n
+`)
+
 // NewRedisCache creates a new Redis cache instance
 func NewRedisCache(client *redis.Client) Cache {
 	return &RedisCache{
@@ -23,24 +79,50 @@ func NewRedisCache(client *redis.Client) Cache {
 
 // Set sets a key-value pair with optional expiry
 func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
-	// Convert value to JSON if not string or []byte
-	var data interface{}
-	switch v := value.(type) {
-	case string:
-		data = v
-	case []byte:
-		data = v
-	default:
-		jsonData, err := json.Marshal(value)
-		if err != nil {
-			return fmt.Errorf("failed to marshal value: %w", err)
-		}
-		data = jsonData
+	data, err := encodeValue(value)
+	if err != nil {
+		return err
 	}
 
 	return c.client.Set(ctx, key, data, expiry).Err()
 }
 
+// SetWithTags sets a key-value pair and adds the key to the Redis set of each tag
+func (c *RedisCache) SetWithTags(ctx context.Context, key string, value interface{}, expiry time.Duration, tags ...string) error {
+	data, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(tags)+1)
+	keys = append(keys, key)
+	for _, tag := range tags {
+		keys = append(keys, tagKey(tag))
+	}
+
+	return setWithTagsScript.Run(ctx, c.client, keys, data, expiry.Milliseconds()).Err()
+}
+
+// InvalidateTags deletes every key set with any of tags
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	_, err := c.invalidateTags(ctx, tags)
+	return err
+}
+
+// invalidateTags deletes the keys of tags and returns them
+func (c *RedisCache) invalidateTags(ctx context.Context, tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, tagKey(tag))
+	}
+
+	return invalidateTagsScript.Run(ctx, c.client, keys).StringSlice()
+}
+
 // Get gets a value by key
 func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
 	val, err := c.client.Get(ctx, key).Result()
@@ -89,14 +171,34 @@ func (c *RedisCache) Decrement(ctx context.Context, key string) (int64, error) {
 	return c.client.Decr(ctx, key).Result()
 }
 
+// IncrByWithExpiry increments a key's value by value, a key without expiry gets expiry
+func (c *RedisCache) IncrByWithExpiry(ctx context.Context, key string, value int64, expiry time.Duration) (int64, error) {
+	return incrByWithExpiryScript.Run(ctx, c.client, []string{key}, value, expiry.Milliseconds()).Int64()
+}
+
 // Expire sets expiry on an existing key
 func (c *RedisCache) Expire(ctx context.Context, key string, expiry time.Duration) error {
 	return c.client.Expire(ctx, key, expiry).Err()
 }
 
 // Keys gets all keys matching pattern
+// Uses SCAN, which does not block Redis like KEYS, keys changed during the scan may be missed
 func (c *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
-	return c.client.Keys(ctx, pattern).Result()
+	seen := make(map[string]struct{})
+	keys := []string{}
+
+	// SCAN may return a key more than once
+	iter := c.client.Scan(ctx, 0, pattern, scanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	return keys, iter.Err()
 }
 
 // FlushAll flushes all keys in current database
@@ -114,6 +216,125 @@ func (c *RedisCache) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
 
+// HSet sets fields of the hash stored at key
+func (c *RedisCache) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for field, value := range values {
+		data, err := encodeValue(value)
+		if err != nil {
+			return err
+		}
+		fields[field] = data
+	}
+	return c.client.HSet(ctx, key, fields).Err()
+}
+
+// HGet gets a field of the hash stored at key
+func (c *RedisCache) HGet(ctx context.Context, key string, field string) (string, error) {
+	val, err := c.client.HGet(ctx, key, field).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("field not found: %s %s", key, field)
+		}
+		return "", err
+	}
+	return val, nil
+}
+
+// HGetAll gets all fields of the hash stored at key
+func (c *RedisCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.client.HGetAll(ctx, key).Result()
+}
+
+// HDel deletes fields of the hash stored at key
+func (c *RedisCache) HDel(ctx context.Context, key string, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	return c.client.HDel(ctx, key, fields...).Err()
+}
+
+// ZAdd adds members to the sorted set stored at key
+func (c *RedisCache) ZAdd(ctx context.Context, key string, members ...ZMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	zs := make([]redis.Z, 0, len(members))
+	for _, m := range members {
+		zs = append(zs, redis.Z{Score: m.Score, Member: m.Member})
+	}
+	return c.client.ZAdd(ctx, key, zs...).Err()
+}
+
+// ZIncrBy increments the score of member in the sorted set stored at key
+func (c *RedisCache) ZIncrBy(ctx context.Context, key string, member string, increment float64) (float64, error) {
+	return c.client.ZIncrBy(ctx, key, increment, member).Result()
+}
+
+// ZRangeByScore gets the members of the sorted set stored at key with a score within the range
+func (c *RedisCache) ZRangeByScore(ctx context.Context, key string, opt ZRangeBy) ([]ZMember, error) {
+	by := &redis.ZRangeBy{
+		Min:    formatScore(opt.Min),
+		Max:    formatScore(opt.Max),
+		Offset: opt.Offset,
+		Count:  opt.Count,
+	}
+	// Redis reads a count of 0 as no members, -1 is all of them
+	if opt.Offset > 0 && opt.Count <= 0 {
+		by.Count = -1
+	}
+
+	var zs []redis.Z
+	var err error
+	if opt.Rev {
+		zs, err = c.client.ZRevRangeByScoreWithScores(ctx, key, by).Result()
+	} else {
+		zs, err = c.client.ZRangeByScoreWithScores(ctx, key, by).Result()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ZMember, 0, len(zs))
+	for _, z := range zs {
+		member, _ := z.Member.(string)
+		members = append(members, ZMember{Member: member, Score: z.Score})
+	}
+	return members, nil
+}
+
+// ZRem removes members from the sorted set stored at key
+func (c *RedisCache) ZRem(ctx context.Context, key string, members ...string) error {
+	if len(members) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(members))
+	for _, m := range members {
+		args = append(args, m)
+	}
+	return c.client.ZRem(ctx, key, args...).Err()
+}
+
+// Eval runs the Lua source of script, by hash once Redis has cached it
+// A script returning nil or false returns nil without error
+func (c *RedisCache) Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	if script.lua == nil {
+		return nil, ErrScriptUnsupported
+	}
+
+	val, err := script.lua.Run(ctx, c.client, keys, args...).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return val, err
+}
+
 // GetJSON gets a value and unmarshals it from JSON
 func (c *RedisCache) GetJSON(ctx context.Context, key string, dest interface{}) error {
 	val, err := c.GetBytes(ctx, key)
@@ -165,3 +386,36 @@ func (c *RedisCache) MSet(ctx context.Context, pairs map[string]interface{}) err
 	}
 	return c.client.MSet(ctx, args...).Err()
 }
+
+// tagKey is the Redis set holding the keys of tag
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
+
+// formatScore formats a score bound for Redis, which spells infinity +inf and -inf
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(score, 'f', -1, 64)
+	}
+}
+
+// encodeValue converts value to JSON if not string or []byte
+func encodeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return v, nil
+	default:
+		jsonData, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		return jsonData, nil
+	}
+}
@@ -0,0 +1,343 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+)
+
+// ErrNotFound is returned by a Remember loader when the value does not exist,
+// with WithNegativeTTL the miss itself is cached so lookups of missing keys do not reach the loader
+var ErrNotFound = errors.New("not found")
+
+// Remember defaults
+const (
+	defaultJitter      = 0.1
+	defaultLockTimeout = 5 * time.Second
+	lockPollInterval   = 50 * time.Millisecond
+)
+
+// flightKey identifies a key of one cache, two caches never share a load or a refresh
+// Cache implementations are pointers, so the cache is a valid map key
+type flightKey struct {
+	cache Cache
+	key   string
+}
+
+// flight is a load in progress, concurrent misses of the same key wait for it
+type flight struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+var (
+	// flights collapses concurrent loads of a key within this process
+	flightsMu sync.Mutex
+	flights   = map[flightKey]*flight{}
+
+	// refreshing tracks keys refreshed in the background, so a stale key starts one refresh per process
+	refreshing sync.Map
+)
+
+// unlockScript deletes the lock only while it still holds our token, an expired lock may belong to another instance
+var unlockScript = NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`, func(ctx context.Context, c Cache, keys []string, args []interface{}) (interface{}, error) {
+	owner, err := c.Get(ctx, keys[0])
+	if err != nil || owner != args[0] {
+		return int64(0), nil
+	}
+	return int64(1), c.Delete(ctx, keys[0])
+})
+
+// RememberOption customizes Remember
+type RememberOption func(o *rememberOptions)
+
+type rememberOptions struct {
+	jitter      float64
+	stale       time.Duration
+	negativeTTL time.Duration
+	lockTimeout time.Duration
+}
+
+// WithJitter shortens each TTL by a random part up to fraction, so keys cached together do not expire together
+// Defaults to 0.1, 0 disables it
+func WithJitter(fraction float64) RememberOption {
+	return func(o *rememberOptions) {
+		o.jitter = fraction
+	}
+}
+
+// WithStale keeps serving an expired value for up to d while it is reloaded in the background
+func WithStale(d time.Duration) RememberOption {
+	return func(o *rememberOptions) {
+		o.stale = d
+	}
+}
+
+// WithNegativeTTL caches ErrNotFound from the loader for ttl
+func WithNegativeTTL(ttl time.Duration) RememberOption {
+	return func(o *rememberOptions) {
+		o.negativeTTL = ttl
+	}
+}
+
+// WithLockTimeout bounds how long a load runs, how long it holds the distributed lock and how long other instances wait for it
+// Defaults to 5s
+func WithLockTimeout(d time.Duration) RememberOption {
+	return func(o *rememberOptions) {
+		o.lockTimeout = d
+	}
+}
+
+// entry is what Remember stores under a key
+type entry struct {
+	Value      json.RawMessage `json:"v,omitempty"`
+	FreshUntil int64           `json:"f,omitempty"` // Unix milliseconds, 0 never goes stale
+	NotFound   bool            `json:"n,omitempty"`
+}
+
+// Remember returns the value cached under key, or loads it with loader and caches it for ttl
+//
+//	users, err := cache.Remember(ctx, c, "users:list", 5*time.Minute, func(ctx context.Context) ([]entity.User, error) {
+//		return repo.GetUsers(ctx)
+//	}, cache.WithStale(time.Minute))
+//
+// Values are stored as JSON, so T must round trip through encoding/json, and a key always holds the same T
+// Concurrent misses of a key run loader once per process, and once across instances while the lock
+// (SetNX on "<key>:lock") is held, the others wait for its value
+// Loader errors are returned and not cached, except ErrNotFound with WithNegativeTTL
+func Remember[T any](ctx context.Context, c Cache, key string, ttl time.Duration, loader func(ctx context.Context) (T, error), opts ...RememberOption) (T, error) {
+	o := rememberOptions{
+		jitter:      defaultJitter,
+		lockTimeout: defaultLockTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// A broken entry is treated as a miss and overwritten by the load
+	if e, ok := lookup(ctx, c, key); ok {
+		value, err := decode[T](e)
+		if err == nil || errors.Is(err, ErrNotFound) {
+			if e.FreshUntil > 0 && time.Now().UnixMilli() > e.FreshUntil {
+				refresh(ctx, c, key, ttl, loader, o)
+			}
+			return value, err
+		}
+	}
+
+	// Callers waiting on the same load share it, the first one going away must not fail the others
+	// The load is still bounded, a hung loader would otherwise hold the key for every later miss
+	f := share(flightKey{cache: c, key: key}, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), o.lockTimeout)
+		defer cancel()
+		return loadLocked(ctx, c, key, ttl, loader, o)
+	})
+
+	var value T
+	select {
+	case <-ctx.Done():
+		return value, ctx.Err()
+	case <-f.done:
+	}
+
+	if f.err != nil {
+		return value, f.err
+	}
+	if f.val == nil {
+		return value, nil
+	}
+	value, ok := f.val.(T)
+	if !ok {
+		return value, fmt.Errorf("cache: key %q is remembered as %T, not %T", key, f.val, value)
+	}
+	return value, nil
+}
+
+// share runs fn once for concurrent callers with the same key, later callers get the running flight
+func share(k flightKey, fn func() (interface{}, error)) *flight {
+	flightsMu.Lock()
+	if f, ok := flights[k]; ok {
+		flightsMu.Unlock()
+		return f
+	}
+	f := &flight{done: make(chan struct{})}
+	flights[k] = f
+	flightsMu.Unlock()
+
+	go func() {
+		defer func() {
+			flightsMu.Lock()
+			delete(flights, k)
+			flightsMu.Unlock()
+			close(f.done)
+		}()
+		f.val, f.err = fn()
+	}()
+
+	return f
+}
+
+// loadLocked loads key while holding the distributed lock, or waits for the instance holding it
+func loadLocked[T any](ctx context.Context, c Cache, key string, ttl time.Duration, loader func(ctx context.Context) (T, error), o rememberOptions) (T, error) {
+	lockKey := key + ":lock"
+	token := rand.Text()
+
+	// Without a working lock every instance loads, which is what happens without Remember
+	locked, err := c.SetNX(ctx, lockKey, token, o.lockTimeout)
+	if err == nil && !locked {
+		if e, ok := waitFor(ctx, c, key, lockKey, o.lockTimeout); ok {
+			if value, err := decode[T](e); err == nil || errors.Is(err, ErrNotFound) {
+				return value, err
+			}
+		}
+	}
+	if locked {
+		defer unlock(context.WithoutCancel(ctx), c, lockKey, token)
+	}
+
+	return load(ctx, c, key, ttl, loader, o)
+}
+
+// load runs loader and caches its result
+func load[T any](ctx context.Context, c Cache, key string, ttl time.Duration, loader func(ctx context.Context) (T, error), o rememberOptions) (T, error) {
+	value, err := loader(ctx)
+	if errors.Is(err, ErrNotFound) && o.negativeTTL > 0 {
+		store(ctx, c, key, entry{NotFound: true}, jitter(o.negativeTTL, o.jitter), 0)
+		return value, err
+	}
+	if err != nil {
+		return value, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, err
+	}
+	store(ctx, c, key, entry{Value: data}, jitter(ttl, o.jitter), o.stale)
+	return value, nil
+}
+
+// refresh reloads a stale key in the background, only one instance refreshes a key at a time
+func refresh[T any](ctx context.Context, c Cache, key string, ttl time.Duration, loader func(ctx context.Context) (T, error), o rememberOptions) {
+	fk := flightKey{cache: c, key: key}
+	if _, running := refreshing.LoadOrStore(fk, struct{}{}); running {
+		return
+	}
+
+	// The request that noticed the stale value may finish before the refresh does
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), o.lockTimeout)
+
+	go func() {
+		defer cancel()
+		defer refreshing.Delete(fk)
+
+		lockKey := key + ":lock"
+		token := rand.Text()
+		locked, err := c.SetNX(ctx, lockKey, token, o.lockTimeout)
+		if err != nil || !locked {
+			return
+		}
+		defer unlock(ctx, c, lockKey, token)
+
+		if _, err := load(ctx, c, key, ttl, loader, o); err != nil && !errors.Is(err, ErrNotFound) {
+			lf := logger.NewFields("Cache.Remember").WithTrace(ctx)
+			lf.Append(logger.Any("key", key))
+			lf.Append(logger.Any("error", err.Error()))
+			logger.Error("Failed to refresh stale cache entry", lf)
+		}
+	}()
+}
+
+func lookup(ctx context.Context, c Cache, key string) (entry, bool) {
+	cached, err := c.Get(ctx, key)
+	if err != nil {
+		return entry{}, false
+	}
+
+	var e entry
+	if err := json.Unmarshal([]byte(cached), &e); err != nil {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func decode[T any](e entry) (T, error) {
+	var value T
+	if e.NotFound {
+		return value, ErrNotFound
+	}
+	err := json.Unmarshal(e.Value, &value)
+	return value, err
+}
+
+// store writes e, fresh for ttl and kept for stale longer, ttl 0 never expires
+func store(ctx context.Context, c Cache, key string, e entry, ttl time.Duration, stale time.Duration) {
+	expiry := time.Duration(0)
+	if ttl > 0 {
+		e.FreshUntil = time.Now().Add(ttl).UnixMilli()
+		expiry = ttl + stale
+	}
+
+	data, err := json.Marshal(e)
+	if err == nil {
+		err = c.Set(ctx, key, string(data), expiry)
+	}
+	if err != nil {
+		lf := logger.NewFields("Cache.Remember").WithTrace(ctx)
+		lf.Append(logger.Any("key", key))
+		lf.Append(logger.Any("error", err.Error()))
+		logger.Error("Failed to cache value", lf)
+	}
+}
+
+// waitFor polls key until another instance stored it, or gives up once the lock is released or timeout passes
+func waitFor(ctx context.Context, c Cache, key string, lockKey string, timeout time.Duration) (entry, bool) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(lockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return entry{}, false
+		case <-deadline.C:
+			return entry{}, false
+		case <-ticker.C:
+			if e, ok := lookup(ctx, c, key); ok {
+				return e, true
+			}
+
+			// The holder failed to load, or stored the value and unlocked since the lookup
+			if held, err := c.Exists(ctx, lockKey); err == nil && !held {
+				return lookup(ctx, c, key)
+			}
+		}
+	}
+}
+
+// unlock releases the lock if it is still ours, checked and deleted in one step
+func unlock(ctx context.Context, c Cache, lockKey string, token string) {
+	_, _ = c.Eval(ctx, unlockScript, []string{lockKey}, token)
+}
+
+// jitter shortens ttl by a random part up to fraction of it
+func jitter(ttl time.Duration, fraction float64) time.Duration {
+	if ttl <= 0 || fraction <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(mathrand.Float64()*fraction*float64(ttl))
+}
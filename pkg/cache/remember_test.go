@@ -0,0 +1,375 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	logger.SetupNop()
+	os.Exit(m.Run())
+}
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func countingLoader(calls *atomic.Int32, u user, err error) func(ctx context.Context) (user, error) {
+	return func(ctx context.Context) (user, error) {
+		calls.Add(1)
+		return u, err
+	}
+}
+
+func TestRememberCachesValue(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	var calls atomic.Int32
+	loader := countingLoader(&calls, user{ID: 1, Name: "hanif"}, nil)
+
+	for i := 0; i < 3; i++ {
+		got, err := Remember(ctx, c, "user:1", time.Minute, loader)
+		require.NoError(t, err)
+		assert.Equal(t, user{ID: 1, Name: "hanif"}, got)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRememberLoaderErrorIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	var calls atomic.Int32
+	boom := errors.New("db down")
+
+	_, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{}, boom))
+	assert.ErrorIs(t, err, boom)
+
+	got, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{ID: 1}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.ID)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRememberNegativeCaching(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	var calls atomic.Int32
+	loader := countingLoader(&calls, user{}, ErrNotFound)
+
+	for i := 0; i < 3; i++ {
+		_, err := Remember(ctx, c, "user:404", time.Minute, loader, WithNegativeTTL(time.Minute))
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Without a negative TTL every miss reaches the loader
+	for i := 0; i < 2; i++ {
+		_, err := Remember(ctx, c, "user:405", time.Minute, loader)
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRememberSingleflight(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (user, error) {
+		calls.Add(1)
+		<-release
+		return user{ID: 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := Remember(ctx, c, "user:1", time.Minute, loader)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), got.ID)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRememberWaitsForLockHolder(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	// Another instance holds the lock and stores the value shortly after
+	locked, err := c.SetNX(ctx, "user:1:lock", "other", time.Second)
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		store(ctx, c, "user:1", entry{Value: []byte(`{"id":1,"name":"other"}`)}, time.Minute, 0)
+	}()
+
+	var calls atomic.Int32
+	got, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{ID: 1, Name: "self"}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, "other", got.Name)
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func TestRememberLoadsWhenLockHolderTimesOut(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	_, err := c.SetNX(ctx, "user:1:lock", "other", time.Minute)
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	got, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{ID: 1}, nil), WithLockTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.ID)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// The lock was not ours, it is left alone
+	owner, err := c.Get(ctx, "user:1:lock")
+	require.NoError(t, err)
+	assert.Equal(t, "other", owner)
+}
+
+func TestRememberLoadsWhenLockReleased(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	// Another instance holds the lock and gives up without storing a value
+	_, err := c.SetNX(ctx, "user:1:lock", "other", time.Minute)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = c.Delete(ctx, "user:1:lock")
+	}()
+
+	var calls atomic.Int32
+	start := time.Now()
+	got, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{ID: 1}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.ID)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Less(t, time.Since(start), time.Second, "does not wait for the lock timeout")
+}
+
+func TestRememberSharedLoadOutlivesCaller(t *testing.T) {
+	c := NewMemoryCache()
+	defer c.Close()
+
+	var once sync.Once
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (user, error) {
+		once.Do(func() { close(started) })
+		<-release
+		return user{ID: 1}, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := Remember(ctx, c, "user:1", time.Minute, loader)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan user, 1)
+	go func() {
+		got, err := Remember(context.Background(), c, "user:1", time.Minute, loader)
+		assert.NoError(t, err)
+		second <- got
+	}()
+
+	// The first caller goes away, the load it started keeps going for the second
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+
+	close(release)
+	assert.Equal(t, int64(1), (<-second).ID)
+}
+
+func TestRememberLoadIsBoundedByLockTimeout(t *testing.T) {
+	c := NewMemoryCache()
+	defer c.Close()
+
+	hung := func(ctx context.Context) (user, error) {
+		<-ctx.Done()
+		return user{}, ctx.Err()
+	}
+
+	start := time.Now()
+	_, err := Remember(context.Background(), c, "user:1", time.Minute, hung, WithLockTimeout(100*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The lock was released, the next miss loads right away
+	var calls atomic.Int32
+	got, err := Remember(context.Background(), c, "user:1", time.Minute, countingLoader(&calls, user{ID: 1}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.ID)
+}
+
+func TestRememberKeepsCachesApart(t *testing.T) {
+	a, b := NewMemoryCache(), NewMemoryCache()
+	defer a.Close()
+	defer b.Close()
+
+	release := make(chan struct{})
+	blocking := func(u user) func(ctx context.Context) (user, error) {
+		return func(ctx context.Context) (user, error) {
+			<-release
+			return u, nil
+		}
+	}
+
+	fromA := make(chan user, 1)
+	fromB := make(chan user, 1)
+	go func() {
+		got, err := Remember(context.Background(), a, "user:1", time.Minute, blocking(user{ID: 1, Name: "a"}))
+		assert.NoError(t, err)
+		fromA <- got
+	}()
+	go func() {
+		got, err := Remember(context.Background(), b, "user:1", time.Minute, blocking(user{ID: 1, Name: "b"}))
+		assert.NoError(t, err)
+		fromB <- got
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	assert.Equal(t, "a", (<-fromA).Name)
+	assert.Equal(t, "b", (<-fromB).Name)
+}
+
+func TestRememberSharedLoadOfAnotherType(t *testing.T) {
+	c := NewMemoryCache()
+	defer c.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_, _ = Remember(context.Background(), c, "user:1", time.Minute, func(ctx context.Context) (user, error) {
+			close(started)
+			<-release
+			return user{ID: 1}, nil
+		})
+	}()
+	<-started
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := Remember(context.Background(), c, "user:1", time.Minute, func(ctx context.Context) (string, error) {
+			return "jane", nil
+		})
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	assert.ErrorContains(t, <-done, "not string")
+}
+
+func TestRememberUnlockLeavesForeignLock(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	_, err := c.SetNX(ctx, "user:1:lock", "other", time.Minute)
+	require.NoError(t, err)
+
+	unlock(ctx, c, "user:1:lock", "mine")
+	exists, err := c.Exists(ctx, "user:1:lock")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	unlock(ctx, c, "user:1:lock", "other")
+	exists, err = c.Exists(ctx, "user:1:lock")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRememberStaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	var calls atomic.Int32
+	_, err := Remember(ctx, c, "user:1", 50*time.Millisecond, countingLoader(&calls, user{Name: "old"}, nil), WithStale(time.Minute), WithJitter(0))
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+
+	// The stale value is served right away and reloaded in the background
+	got, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{Name: "new"}, nil), WithStale(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "old", got.Name)
+
+	assert.Eventually(t, func() bool {
+		got, err := Remember(ctx, c, "user:1", time.Minute, countingLoader(&calls, user{Name: "unused"}, nil))
+		return err == nil && got.Name == "new"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		got := jitter(time.Minute, 0.1)
+		assert.LessOrEqual(t, got, time.Minute)
+		assert.Greater(t, got, 54*time.Second)
+	}
+	assert.Equal(t, time.Minute, jitter(time.Minute, 0))
+	assert.Equal(t, time.Duration(0), jitter(0, 0.1))
+}
+
+func TestMemorySetNX(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+	defer c.Close()
+
+	ok, err := c.SetNX(ctx, "k", "a", 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = c.SetNX(ctx, "k", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	time.Sleep(100 * time.Millisecond)
+
+	ok, err = c.SetNX(ctx, "k", "c", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	got, _ := c.Get(ctx, "k")
+	assert.Equal(t, "c", got)
+}
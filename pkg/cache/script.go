@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrScriptUnsupported is returned by Eval when the script has no implementation for the cache
+var ErrScriptUnsupported = errors.New("script not supported by this cache")
+
+// ScriptFunc is the Go implementation of a script, it runs on MemoryCache with c locked for the script
+// It returns what the Lua script returns as go-redis decodes it: int64, string, []interface{} or nil
+type ScriptFunc func(ctx context.Context, c Cache, keys []string, args []interface{}) (interface{}, error)
+
+// Script is an operation run atomically by Eval, as Lua on Redis and as a Go function on MemoryCache
+// Both should do the same, the Go function keeps tests and development on the memory cache working
+type Script struct {
+	lua *redis.Script
+	fn  ScriptFunc
+}
+
+// NewScript creates a script from its Lua source and its Go equivalent, either may be empty
+// Scripts are created once, e.g. as package variables, Redis caches them by hash
+//
+//	var claimScript = cache.NewScript(`
+//	if redis.call('GET', KEYS[1]) then return 0 end
+//	redis.call('SET', KEYS[1], ARGV[1])
+//	return 1
+//	`, func(ctx context.Context, c cache.Cache, keys []string, args []interface{}) (interface{}, error) {
+//		ok, err := c.SetNX(ctx, keys[0], args[0], 0)
+//		if err != nil || !ok {
+//			return int64(0), err
+//		}
+//		return int64(1), nil
+//	})
+func NewScript(lua string, fn ScriptFunc) *Script {
+	s := &Script{fn: fn}
+	if lua != "" {
+		s.lua = redis.NewScript(lua)
+	}
+	return s
+}
@@ -0,0 +1,445 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// Tiered cache defaults
+const (
+	defaultL1Size              = 10000
+	defaultL1TTL               = 30 * time.Second
+	defaultInvalidationChannel = "cache:invalidate"
+)
+
+// TieredCache implements Cache interface with a bounded in-process LRU (L1) in front of Redis (L2)
+// Writes go to Redis and are broadcast over pub/sub, so every instance drops its local copy of the key
+type TieredCache struct {
+	l1     Cache
+	l2     *RedisCache
+	config TieredConfig
+	origin string // Identifies this instance, its own broadcasts are not applied twice
+	pubsub *redis.PubSub
+	done   chan struct{}
+
+	// reads tracks L2 reads in flight, a read only fills L1 if its key was not invalidated meanwhile
+	mu    sync.Mutex
+	reads map[string]*read
+
+	l1Hits, l1Misses, l2Hits, l2Misses atomic.Int64
+
+	closeOnce sync.Once
+}
+
+// TieredConfig holds tiered cache configuration
+type TieredConfig struct {
+	L1Size int           // Max keys kept in process, defaults to 10000
+	L1TTL  time.Duration // Max time a key is served from process, bounds staleness when a broadcast is lost, defaults to 30s
+
+	InvalidationChannel string // Redis pub/sub channel, defaults to "cache:invalidate"
+}
+
+// TieredStats counts lookups per tier, L2 is only asked on an L1 miss
+type TieredStats struct {
+	L1Hits     int64   `json:"l1_hits"`
+	L1Misses   int64   `json:"l1_misses"`
+	L1HitRatio float64 `json:"l1_hit_ratio"`
+	L2Hits     int64   `json:"l2_hits"`
+	L2Misses   int64   `json:"l2_misses"`
+	L2HitRatio float64 `json:"l2_hit_ratio"`
+}
+
+// read counts the in flight L2 reads of a key, version changes when the key is invalidated
+type read struct {
+	readers int
+	version uint64
+}
+
+// invalidation is broadcast when keys change
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	Flush  bool     `json:"flush,omitempty"`
+}
+
+// NewTieredCache creates a two tier cache over client and subscribes to invalidations
+func NewTieredCache(ctx context.Context, client *redis.Client, config TieredConfig) (Cache, error) {
+	if config.L1Size <= 0 {
+		config.L1Size = defaultL1Size
+	}
+	if config.L1TTL <= 0 {
+		config.L1TTL = defaultL1TTL
+	}
+	if config.InvalidationChannel == "" {
+		config.InvalidationChannel = defaultInvalidationChannel
+	}
+
+	pubsub := client.Subscribe(ctx, config.InvalidationChannel)
+	// Wait for the subscription, invalidations sent before it would be missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	c := &TieredCache{
+		l1:     NewMemoryCacheWithConfig(MemoryConfig{MaxEntries: config.L1Size}),
+		l2:     &RedisCache{client: client},
+		config: config,
+		origin: rand.Text(),
+		pubsub: pubsub,
+		done:   make(chan struct{}),
+		reads:  make(map[string]*read),
+	}
+
+	go c.listen()
+
+	return c, nil
+}
+
+// Set sets a key-value pair with optional expiry
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, expiry time.Duration) error {
+	if err := c.l2.Set(ctx, key, value, expiry); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// SetWithTags sets a key-value pair and adds the key to tags
+func (c *TieredCache) SetWithTags(ctx context.Context, key string, value interface{}, expiry time.Duration, tags ...string) error {
+	if err := c.l2.SetWithTags(ctx, key, value, expiry, tags...); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// InvalidateTags deletes every key set with any of tags, on every instance
+func (c *TieredCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	keys, err := c.l2.invalidateTags(ctx, tags)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+	return c.invalidate(ctx, keys...)
+}
+
+// SetNX sets a key only if it doesn't exist (atomic)
+func (c *TieredCache) SetNX(ctx context.Context, key string, value interface{}, expiry time.Duration) (bool, error) {
+	ok, err := c.l2.SetNX(ctx, key, value, expiry)
+	if err != nil || !ok {
+		return ok, err
+	}
+	return true, c.invalidate(ctx, key)
+}
+
+// Get gets a value by key, from process if cached there
+func (c *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if val, err := c.l1.Get(ctx, key); err == nil {
+		c.l1Hits.Add(1)
+		return val, nil
+	}
+	c.l1Misses.Add(1)
+
+	r, version := c.startRead(key)
+	defer c.endRead(key, r)
+
+	// The remaining TTL keeps L1 from serving a key after Redis expired it
+	pipe := c.l2.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	pttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			c.l2Misses.Add(1)
+			return "", fmt.Errorf("key not found: %s", key)
+		}
+		return "", err
+	}
+	c.l2Hits.Add(1)
+
+	val := get.Val()
+	ttl := c.config.L1TTL
+	if remaining := pttl.Val(); remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+
+	// A value read before an invalidation arrived may already be outdated
+	c.mu.Lock()
+	if r.version == version {
+		_ = c.l1.Set(ctx, key, val, ttl)
+	}
+	c.mu.Unlock()
+
+	return val, nil
+}
+
+// GetBytes gets a value as bytes
+func (c *TieredCache) GetBytes(ctx context.Context, key string) ([]byte, error) {
+	val, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(val), nil
+}
+
+// Delete deletes a key
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := c.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	return c.invalidate(ctx, key)
+}
+
+// Exists checks if a key exists
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if ok, _ := c.l1.Exists(ctx, key); ok {
+		return true, nil
+	}
+	return c.l2.Exists(ctx, key)
+}
+
+// Counters and expiries change on every request for rate limits and lockouts, broadcasting each change would
+// flood pub/sub. They only drop the local copy, a Get of the key on another instance is stale for at most L1TTL,
+// callers use the value returned by the increment instead
+
+// Increment increments a key's value
+func (c *TieredCache) Increment(ctx context.Context, key string) (int64, error) {
+	val, err := c.l2.Increment(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	c.evict([]string{key})
+	return val, nil
+}
+
+// Decrement decrements a key's value
+func (c *TieredCache) Decrement(ctx context.Context, key string) (int64, error) {
+	val, err := c.l2.Decrement(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	c.evict([]string{key})
+	return val, nil
+}
+
+// IncrByWithExpiry increments a key's value by value, a key without expiry gets expiry
+func (c *TieredCache) IncrByWithExpiry(ctx context.Context, key string, value int64, expiry time.Duration) (int64, error) {
+	val, err := c.l2.IncrByWithExpiry(ctx, key, value, expiry)
+	if err != nil {
+		return 0, err
+	}
+	c.evict([]string{key})
+	return val, nil
+}
+
+// Expire sets expiry on an existing key
+func (c *TieredCache) Expire(ctx context.Context, key string, expiry time.Duration) error {
+	if err := c.l2.Expire(ctx, key, expiry); err != nil {
+		return err
+	}
+	c.evict([]string{key})
+	return nil
+}
+
+// Hashes and sorted sets are never held in process, their operations go straight to Redis
+
+// HSet sets fields of the hash stored at key
+func (c *TieredCache) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	return c.l2.HSet(ctx, key, values)
+}
+
+// HGet gets a field of the hash stored at key
+func (c *TieredCache) HGet(ctx context.Context, key string, field string) (string, error) {
+	return c.l2.HGet(ctx, key, field)
+}
+
+// HGetAll gets all fields of the hash stored at key
+func (c *TieredCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return c.l2.HGetAll(ctx, key)
+}
+
+// HDel deletes fields of the hash stored at key
+func (c *TieredCache) HDel(ctx context.Context, key string, fields ...string) error {
+	return c.l2.HDel(ctx, key, fields...)
+}
+
+// ZAdd adds members to the sorted set stored at key
+func (c *TieredCache) ZAdd(ctx context.Context, key string, members ...ZMember) error {
+	return c.l2.ZAdd(ctx, key, members...)
+}
+
+// ZIncrBy increments the score of member in the sorted set stored at key
+func (c *TieredCache) ZIncrBy(ctx context.Context, key string, member string, increment float64) (float64, error) {
+	return c.l2.ZIncrBy(ctx, key, member, increment)
+}
+
+// ZRangeByScore gets the members of the sorted set stored at key with a score within the range
+func (c *TieredCache) ZRangeByScore(ctx context.Context, key string, opt ZRangeBy) ([]ZMember, error) {
+	return c.l2.ZRangeByScore(ctx, key, opt)
+}
+
+// ZRem removes members from the sorted set stored at key
+func (c *TieredCache) ZRem(ctx context.Context, key string, members ...string) error {
+	return c.l2.ZRem(ctx, key, members...)
+}
+
+// Eval runs script on Redis and invalidates keys on every instance, a script should only write keys it is given
+func (c *TieredCache) Eval(ctx context.Context, script *Script, keys []string, args ...interface{}) (interface{}, error) {
+	val, err := c.l2.Eval(ctx, script, keys, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return val, nil
+	}
+	return val, c.invalidate(ctx, keys...)
+}
+
+// Keys gets all keys matching pattern, from Redis
+func (c *TieredCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return c.l2.Keys(ctx, pattern)
+}
+
+// FlushAll flushes all keys in current database and every instance's process cache
+func (c *TieredCache) FlushAll(ctx context.Context) error {
+	if err := c.l2.FlushAll(ctx); err != nil {
+		return err
+	}
+
+	c.flushLocal()
+	return c.publish(ctx, invalidation{Origin: c.origin, Flush: true})
+}
+
+// Close stops listening for invalidations and closes the Redis connection
+func (c *TieredCache) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.pubsub.Close()
+		_ = c.l1.Close()
+		err = c.l2.Close()
+	})
+	return err
+}
+
+// Ping checks if Redis is alive
+func (c *TieredCache) Ping(ctx context.Context) error {
+	return c.l2.Ping(ctx)
+}
+
+// Stats returns the lookup counters since start
+func (c *TieredCache) Stats() TieredStats {
+	stats := TieredStats{
+		L1Hits:   c.l1Hits.Load(),
+		L1Misses: c.l1Misses.Load(),
+		L2Hits:   c.l2Hits.Load(),
+		L2Misses: c.l2Misses.Load(),
+	}
+	stats.L1HitRatio = ratio(stats.L1Hits, stats.L1Misses)
+	stats.L2HitRatio = ratio(stats.L2Hits, stats.L2Misses)
+	return stats
+}
+
+// invalidate drops key from this instance and broadcasts it to the others
+func (c *TieredCache) invalidate(ctx context.Context, keys ...string) error {
+	c.evict(keys)
+	return c.publish(ctx, invalidation{Origin: c.origin, Keys: keys})
+}
+
+func (c *TieredCache) evict(keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if r, ok := c.reads[key]; ok {
+			r.version++
+		}
+		_ = c.l1.Delete(context.Background(), key)
+	}
+}
+
+func (c *TieredCache) flushLocal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range c.reads {
+		r.version++
+	}
+	_ = c.l1.FlushAll(context.Background())
+}
+
+func (c *TieredCache) startRead(key string) (*read, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, ok := c.reads[key]
+	if !ok {
+		r = &read{}
+		c.reads[key] = r
+	}
+	r.readers++
+	return r, r.version
+}
+
+func (c *TieredCache) endRead(key string, r *read) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r.readers--
+	if r.readers == 0 {
+		delete(c.reads, key)
+	}
+}
+
+func (c *TieredCache) publish(ctx context.Context, msg invalidation) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.l2.client.Publish(ctx, c.config.InvalidationChannel, payload).Err()
+}
+
+// listen applies invalidations from other instances until Close
+func (c *TieredCache) listen() {
+	ch := c.pubsub.Channel()
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				lf := logger.NewFields("TieredCache.listen")
+				lf.Append(logger.Any("error", err.Error()))
+				logger.Error("Invalid cache invalidation message", lf)
+				continue
+			}
+			if inv.Origin == c.origin {
+				continue
+			}
+
+			if inv.Flush {
+				c.flushLocal()
+				continue
+			}
+			c.evict(inv.Keys)
+		}
+	}
+}
+
+func ratio(hits, misses int64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+// Account holds password reset and email verification configuration
+type Account struct {
+	TokenSecret             string        `mapstructure:"ACCOUNT_TOKEN_SECRET"`              // Signs password reset and email verification tokens
+	PasswordResetExpiry     time.Duration `mapstructure:"ACCOUNT_PASSWORD_RESET_EXPIRY"`     // How long a password reset link works
+	EmailVerificationExpiry time.Duration `mapstructure:"ACCOUNT_EMAIL_VERIFICATION_EXPIRY"` // How long an email verification link works
+	LinkBaseURL             string        `mapstructure:"ACCOUNT_LINK_BASE_URL"`             // Frontend URL the emailed links point to
+}
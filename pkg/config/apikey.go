@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+// APIKey holds API key configuration
+type APIKey struct {
+	CacheTTL      time.Duration `mapstructure:"APIKEY_CACHE_TTL"`      // How long resolved keys are cached, revocation waits for it unless the cache is shared
+	RotationGrace time.Duration `mapstructure:"APIKEY_ROTATION_GRACE"` // How long a rotated key keeps working, 0 revokes it at once
+}
@@ -1,6 +1,25 @@
 package config
 
+import "time"
+
 type App struct {
-	Name string `mapstructure:"name"`
-	Port string `mapstructure:"port"`
+	Name            string        `mapstructure:"name"`
+	Port            string        `mapstructure:"port"`
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"` // Max time to drain in-flight requests
+	ShutdownDelay   time.Duration `mapstructure:"SHUTDOWN_DELAY"`   // Time readiness reports failing before draining starts
+
+	// Error responses use the response envelope unless ErrorFormat is "problem" or the client
+	// accepts application/problem+json
+	ErrorFormat        string `mapstructure:"ERROR_FORMAT"`
+	ProblemTypeBaseURL string `mapstructure:"PROBLEM_TYPE_BASE_URL"` // Prefixed to error codes to build problem type URIs
+
+	// Routes moved under /api/v1 are also served at their unversioned paths, marked deprecated,
+	// until this is set once clients have moved
+	LegacyRoutesDisabled bool `mapstructure:"LEGACY_ROUTES_DISABLED"`
 }
+
+// Error response formats
+const (
+	ErrorFormatEnvelope = "envelope"
+	ErrorFormatProblem  = "problem"
+)
@@ -0,0 +1,6 @@
+package config
+
+// Authz holds authorization policy configuration
+type Authz struct {
+	PolicyFile string `mapstructure:"AUTHZ_POLICY_FILE"` // JSON role to permission policy, the built-in policy is used when empty
+}
@@ -1,10 +1,18 @@
 package config
 
+import "time"
+
 // Cache holds cache configuration
 type Cache struct {
-	Driver   string `mapstructure:"CACHE_DRIVER"`   // redis, memory
-	Host     string `mapstructure:"CACHE_HOST"`     // Redis host
-	Port     int    `mapstructure:"CACHE_PORT"`     // Redis port
-	Password string `mapstructure:"CACHE_PASSWORD"` // Redis password
-	DB       int    `mapstructure:"CACHE_DB"`       // Redis database number
+	Driver   string        `mapstructure:"CACHE_DRIVER"`   // redis, tiered, memory
+	Host     string        `mapstructure:"CACHE_HOST"`     // Redis host
+	Port     int           `mapstructure:"CACHE_PORT"`     // Redis port
+	Password string        `mapstructure:"CACHE_PASSWORD"` // Redis password
+	DB       int           `mapstructure:"CACHE_DB"`       // Redis database number
+	L1Size   int           `mapstructure:"CACHE_L1_SIZE"`  // Keys kept in process by the tiered driver
+	L1TTL    time.Duration `mapstructure:"CACHE_L1_TTL"`   // How long the tiered driver serves a key from process
+
+	MaxEntries     int    `mapstructure:"CACHE_MAX_ENTRIES"`     // Memory driver key limit, 0 is unbounded
+	MaxBytes       int64  `mapstructure:"CACHE_MAX_BYTES"`       // Memory driver size limit, 0 is unbounded
+	EvictionPolicy string `mapstructure:"CACHE_EVICTION_POLICY"` // Memory driver eviction, lru or lfu
 }
@@ -16,6 +16,14 @@ type Config struct {
 	Cache      `mapstructure:",squash"`
 	HTTPClient `mapstructure:",squash"`
 	Queue      `mapstructure:",squash"`
+	Health     `mapstructure:",squash"`
+	Pagination `mapstructure:",squash"`
+	Authz      `mapstructure:",squash"`
+	Login      `mapstructure:",squash"`
+	Account    `mapstructure:",squash"`
+	MFA        `mapstructure:",squash"`
+	OIDC       `mapstructure:",squash"`
+	APIKey     `mapstructure:",squash"`
 }
 
 func LoadAllConfigs() (*Config, error) {
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+// Health holds liveness/readiness probe configuration
+type Health struct {
+	CheckTimeout time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"` // Per-check timeout
+	CacheTTL     time.Duration `mapstructure:"HEALTH_CACHE_TTL"`     // How long an aggregate result is reused
+}
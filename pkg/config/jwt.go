@@ -5,6 +5,13 @@ import "time"
 // JWT holds JWT configuration
 type JWT struct {
 	SecretKey string        `mapstructure:"JWT_SECRET_KEY"` // Secret key for signing JWT
+	KeysFile  string        `mapstructure:"JWT_KEYS_FILE"`  // Key manifest for RS256/ES256/EdDSA signing, replaces SecretKey when set
 	Issuer    string        `mapstructure:"JWT_ISSUER"`     // Token issuer
 	Expiry    time.Duration `mapstructure:"JWT_EXPIRY"`     // Token expiry in seconds (will be converted to duration)
+
+	RefreshExpiry time.Duration `mapstructure:"JWT_REFRESH_EXPIRY"` // Refresh token expiry, rotated on every use
+
+	Audience         []string      `mapstructure:"JWT_AUDIENCE"`          // Comma separated aud of issued tokens
+	ExpectedAudience string        `mapstructure:"JWT_EXPECTED_AUDIENCE"` // Audience this service accepts, empty accepts any
+	Leeway           time.Duration `mapstructure:"JWT_LEEWAY"`            // Clock skew tolerated when validating exp, nbf and iat
 }
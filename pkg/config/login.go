@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+// Login holds credential login configuration
+type Login struct {
+	MaxAttempts     int           `mapstructure:"LOGIN_MAX_ATTEMPTS"`     // Failed attempts before the account is locked, 0 disables lockout
+	LockoutDuration time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"` // How long failures are counted and how long the lock lasts
+
+	MFAChallengeExpiry time.Duration `mapstructure:"LOGIN_MFA_CHALLENGE_EXPIRY"` // How long the password step of a two-factor login stays valid
+}
@@ -0,0 +1,7 @@
+package config
+
+// MFA holds TOTP two-factor authentication configuration
+type MFA struct {
+	Issuer        string `mapstructure:"MFA_ISSUER"`         // Account issuer shown in authenticator apps
+	RecoveryCodes int    `mapstructure:"MFA_RECOVERY_CODES"` // Recovery codes issued when MFA is enabled
+}
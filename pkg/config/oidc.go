@@ -0,0 +1,6 @@
+package config
+
+// OIDC holds external identity provider configuration
+type OIDC struct {
+	ProvidersFile string `mapstructure:"OIDC_PROVIDERS_FILE"` // JSON list of OpenID Connect providers, sign in with providers is disabled when empty
+}
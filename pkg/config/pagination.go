@@ -0,0 +1,6 @@
+package config
+
+// Pagination holds cursor pagination configuration
+type Pagination struct {
+	CursorSecret string `mapstructure:"PAGINATION_CURSOR_SECRET"` // Secret for signing cursor tokens
+}
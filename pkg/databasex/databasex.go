@@ -17,4 +17,6 @@ type Database interface {
 	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	Transact(ctx context.Context, iso sql.IsolationLevel, txFunc func(database Database) error) (err error)
 	InTransaction() bool
+	Ping(ctx context.Context) error
+	Close() error
 }
@@ -65,6 +65,14 @@ func (m *mockDB) InTransaction() bool {
 	return m.inTransaction
 }
 
+func (m *mockDB) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (m *mockDB) Close() error {
+	return nil
+}
+
 // Helper functions to convert between maps and structs
 func mapToStruct(data map[string]interface{}, dst interface{}) error {
 	v := reflect.ValueOf(dst)
@@ -116,6 +116,18 @@ func (m *MySql) InTransaction() bool {
 	return m.tx != nil
 }
 
+func (m *MySql) Ping(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+// Close closes the connection pool, transaction-bound copies share the pool and are not closed
+func (m *MySql) Close() error {
+	if m.InTransaction() {
+		return nil
+	}
+	return m.db.Close()
+}
+
 func NewMySql(cfg *config.Config) (Database, error) {
 	db, err := sqlx.Connect("mysql", fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
 		cfg.Database.Username, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name))
@@ -92,6 +92,18 @@ func (p *Postgres) InTransaction() bool {
 	return p.tx != nil
 }
 
+func (p *Postgres) Ping(ctx context.Context) error {
+	return p.db.PingContext(ctx)
+}
+
+// Close closes the connection pool, transaction-bound copies share the pool and are not closed
+func (p *Postgres) Close() error {
+	if p.InTransaction() {
+		return nil
+	}
+	return p.db.Close()
+}
+
 func NewPostgres(cfg *config.Config) (Database, error) {
 	// PostgreSQL connection string format
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
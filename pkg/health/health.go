@@ -0,0 +1,260 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Status is the state of a single check or of the aggregate report
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded" // only non-critical checks are failing
+	StatusDown     Status = "down"
+)
+
+var ErrCheckTimeout = errors.New("check timed out")
+
+// Checker probes a single dependency, returning nil when healthy
+type Checker func(ctx context.Context) error
+
+// Health aggregates liveness and readiness checks
+type Health interface {
+	// Register adds a readiness check for a dependency
+	Register(name string, checker Checker, opts ...Option)
+
+	// RegisterLiveness adds a liveness check, these should only fail when the process must be restarted
+	RegisterLiveness(name string, checker Checker, opts ...Option)
+
+	// Readiness runs readiness checks, reusing the last report within the cache TTL
+	Readiness(ctx context.Context) *Report
+
+	// Liveness runs liveness checks, reusing the last report within the cache TTL
+	Liveness(ctx context.Context) *Report
+}
+
+// Report is the aggregate result of a set of checks
+type Report struct {
+	Status    Status                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status   Status `json:"status"`
+	Latency  string `json:"latency"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Config holds health registry configuration
+type Config struct {
+	Timeout  time.Duration // Default per-check timeout
+	CacheTTL time.Duration // How long an aggregate report is reused
+}
+
+// Option customizes a registered check
+type Option func(c *check)
+
+// WithTimeout overrides the default timeout for a check
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// NonCritical marks a check whose failure degrades but does not fail the report
+func NonCritical() Option {
+	return func(c *check) {
+		c.critical = false
+	}
+}
+
+type check struct {
+	name     string
+	checker  Checker
+	timeout  time.Duration
+	critical bool
+}
+
+// health implements Health interface
+type health struct {
+	config    Config
+	readiness *group
+	liveness  *group
+}
+
+// NewHealth creates a new health registry
+func NewHealth(config Config) Health {
+	if config.Timeout == 0 {
+		config.Timeout = 2 * time.Second
+	}
+
+	return &health{
+		config:    config,
+		readiness: &group{cacheTTL: config.CacheTTL},
+		liveness:  &group{cacheTTL: config.CacheTTL},
+	}
+}
+
+// Register adds a readiness check
+func (h *health) Register(name string, checker Checker, opts ...Option) {
+	h.readiness.add(h.newCheck(name, checker, opts))
+}
+
+// RegisterLiveness adds a liveness check
+func (h *health) RegisterLiveness(name string, checker Checker, opts ...Option) {
+	h.liveness.add(h.newCheck(name, checker, opts))
+}
+
+// Readiness runs readiness checks
+func (h *health) Readiness(ctx context.Context) *Report {
+	return h.readiness.run(ctx)
+}
+
+// Liveness runs liveness checks
+func (h *health) Liveness(ctx context.Context) *Report {
+	return h.liveness.run(ctx)
+}
+
+func (h *health) newCheck(name string, checker Checker, opts []Option) check {
+	c := check{
+		name:     name,
+		checker:  checker,
+		timeout:  h.config.Timeout,
+		critical: true,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// group is a set of checks sharing one cached report
+type group struct {
+	mu       sync.Mutex
+	checks   []check
+	cacheTTL time.Duration
+
+	runMu  sync.Mutex // only one evaluation at a time, waiters reuse its report
+	last   *Report
+	lastAt time.Time
+}
+
+func (g *group) add(c check) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.checks = append(g.checks, c)
+	g.last = nil
+}
+
+func (g *group) cached() *Report {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.last != nil && time.Since(g.lastAt) < g.cacheTTL {
+		return g.last
+	}
+	return nil
+}
+
+func (g *group) run(ctx context.Context) *Report {
+	if report := g.cached(); report != nil {
+		return report
+	}
+
+	g.runMu.Lock()
+	defer g.runMu.Unlock()
+
+	// Another caller may have refreshed the report while we waited
+	if report := g.cached(); report != nil {
+		return report
+	}
+
+	g.mu.Lock()
+	checks := make([]check, len(g.checks))
+	copy(checks, g.checks)
+	g.mu.Unlock()
+
+	// The report is shared with other callers, so one caller disconnecting must not fail it
+	ctx = context.WithoutCancel(ctx)
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status:    StatusUp,
+		Checks:    make(map[string]CheckResult, len(checks)),
+		CheckedAt: time.Now(),
+	}
+
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.name] = result
+
+		if result.Status == StatusUp {
+			continue
+		}
+
+		if c.critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+
+	g.mu.Lock()
+	g.last = report
+	g.lastAt = report.CheckedAt
+	g.mu.Unlock()
+
+	return report
+}
+
+// runCheck executes a checker bounded by its timeout, even if the checker ignores ctx
+func runCheck(ctx context.Context, c check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.checker(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ErrCheckTimeout
+	}
+
+	result := CheckResult{
+		Status:   StatusUp,
+		Latency:  time.Since(start).String(),
+		Critical: c.critical,
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness_AggregatesStatus(t *testing.T) {
+	h := NewHealth(Config{})
+	h.Register("database", func(ctx context.Context) error { return nil })
+	h.Register("storage", func(ctx context.Context) error { return errors.New("unreachable") }, NonCritical())
+
+	report := h.Readiness(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, StatusUp, report.Checks["database"].Status)
+	assert.Equal(t, "unreachable", report.Checks["storage"].Error)
+
+	h.Register("cache", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	report = h.Readiness(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.True(t, report.Checks["cache"].Critical)
+}
+
+func TestReadiness_TimesOutSlowCheck(t *testing.T) {
+	h := NewHealth(Config{Timeout: 10 * time.Millisecond})
+	block := make(chan struct{})
+	defer close(block)
+
+	// Ignores ctx on purpose, the registry must still enforce the timeout
+	h.Register("slow", func(ctx context.Context) error {
+		<-block
+		return nil
+	})
+
+	report := h.Readiness(context.Background())
+	require.Contains(t, report.Checks, "slow")
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, ErrCheckTimeout.Error(), report.Checks["slow"].Error)
+}
+
+func TestReadiness_CachesReport(t *testing.T) {
+	h := NewHealth(Config{CacheTTL: time.Minute})
+
+	var calls atomic.Int32
+	h.Register("database", func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	})
+
+	first := h.Readiness(context.Background())
+	second := h.Readiness(context.Background())
+	assert.Same(t, first, second)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Liveness has its own report
+	assert.Equal(t, StatusUp, h.Liveness(context.Background()).Status)
+	assert.Empty(t, h.Liveness(context.Background()).Checks)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
@@ -140,13 +141,27 @@ func (c *standardClient) Do(ctx context.Context, req *Request) (*Response, error
 // doRequest executes a single HTTP request
 func (c *standardClient) doRequest(ctx context.Context, req *Request) (*Response, error) {
 	// Prepare request body
-	var bodyReader io.Reader
-	if req.Body != nil {
-		jsonData, err := json.Marshal(req.Body)
+	var payload []byte
+	contentType := "application/json"
+	switch body := req.Body.(type) {
+	case nil:
+	case url.Values:
+		// OAuth2 token endpoints only accept form encoded bodies
+		payload = []byte(body.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case []byte:
+		payload = body
+	default:
+		jsonData, err := json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(jsonData)
+		payload = jsonData
+	}
+
+	var bodyReader io.Reader
+	if req.Body != nil {
+		bodyReader = bytes.NewReader(payload)
 	}
 
 	// Create HTTP request
@@ -167,7 +182,14 @@ func (c *standardClient) doRequest(ctx context.Context, req *Request) (*Response
 
 	// Set Content-Type if body is present and not set
 	if req.Body != nil && httpReq.Header.Get("Content-Type") == "" {
-		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+
+	// Signed last so the signature covers the final headers and body, and each retry gets a fresh one
+	if c.config.Signer != nil {
+		if err := c.config.Signer.Sign(httpReq, payload); err != nil {
+			return nil, fmt.Errorf("failed to sign request: %w", err)
+		}
 	}
 
 	// Execute request
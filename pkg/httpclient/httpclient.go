@@ -33,7 +33,7 @@ type Request struct {
 	Method  string
 	URL     string
 	Headers map[string]string
-	Body    interface{}
+	Body    interface{} // Encoded as JSON, except url.Values which is sent form encoded and []byte which is sent as is
 	Timeout time.Duration
 }
 
@@ -74,6 +74,7 @@ type Config struct {
 	DefaultHeaders  map[string]string // Default headers for all requests
 	FollowRedirects bool              // Follow redirects
 	BaseURL         string            // Base URL for relative paths
+	Signer          Signer            // Signs every attempt, e.g. NewHMACSigner for outbound webhooks
 }
 
 // DefaultConfig returns default HTTP client configuration
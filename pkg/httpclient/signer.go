@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"crypto/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/signature"
+)
+
+// Signer adds authentication to a request right before it is sent, body is the exact payload
+type Signer interface {
+	Sign(req *http.Request, body []byte) error
+}
+
+// hmacSigner signs requests in the format middleware.HMACAuth verifies
+type hmacSigner struct {
+	scheme signature.Scheme
+	secret string
+}
+
+// NewHMACSigner creates a signer for outbound webhooks, set it as Config.Signer:
+//
+//	client := httpclient.NewHTTPClient(httpclient.Config{
+//		Signer: httpclient.NewHMACSigner(signature.Default, secret),
+//	})
+//
+// Every attempt gets a fresh timestamp and nonce, so retries are not rejected as replays
+func NewHMACSigner(scheme signature.Scheme, secret string) Signer {
+	return &hmacSigner{
+		scheme: scheme,
+		secret: secret,
+	}
+}
+
+func (s *hmacSigner) Sign(req *http.Request, body []byte) error {
+	signed := signature.Request{
+		Method:    req.Method,
+		Path:      req.URL.EscapedPath(),
+		Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		Body:      body,
+	}
+	if s.scheme.NonceHeader != "" {
+		signed.Nonce = rand.Text()
+	}
+
+	for key, value := range s.scheme.Headers(s.secret, signed) {
+		req.Header.Set(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package jwt
+
+import (
+	"encoding/json"
+	"strings"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+// CustomClaims is implemented by any struct embedding Claims, so services can add their own members:
+//
+//	type OrderClaims struct {
+//		jwt.Claims
+//		TenantID string `json:"tenant_id"`
+//	}
+//
+// Token type, family, scope and registered claims keep working through the embedded Claims
+type CustomClaims interface {
+	jwtlib.Claims
+	Base() *Claims
+}
+
+// Base returns the standard claims, promoted to every struct embedding Claims
+func (c *Claims) Base() *Claims {
+	return c
+}
+
+// GenerateAs generates an access token from custom claims
+func GenerateAs[T any, P interface {
+	*T
+	CustomClaims
+}](j JWT, claims *T) (string, error) {
+	return j.GenerateClaims(P(claims))
+}
+
+// ParseAs parses an access token into custom claims
+func ParseAs[T any, P interface {
+	*T
+	CustomClaims
+}](j JWT, tokenString string) (*T, error) {
+	claims := P(new(T))
+	if err := j.ParseClaims(tokenString, claims); err != nil {
+		return nil, err
+	}
+	return (*T)(claims), nil
+}
+
+// Scopes splits the space separated scope claim (RFC 8693)
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasScope checks if the token was granted scope
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopes checks if the token was granted every scope
+func (c *Claims) HasScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !c.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// Authentication methods (RFC 8176) recorded in the amr claim
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+
+	// AMRFederated is not registered in RFC 8176, it marks a sign in through an external OpenID Connect provider
+	AMRFederated = "fed"
+
+	// AMRRecovery is not registered in RFC 8176, it marks a second factor passed with a single use recovery code
+	AMRRecovery = "recovery"
+)
+
+// AuthenticatedWith checks if the user authenticated with method, e.g. AMROTP after two-factor login
+func (c *Claims) AuthenticatedWith(method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// rotatedClaims keeps every member of a refresh token, so custom claims survive rotation
+// even though Refresh does not know the struct they were generated from
+type rotatedClaims struct {
+	Claims
+	raw map[string]json.RawMessage
+}
+
+func (c *rotatedClaims) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &c.Claims); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.raw)
+}
+
+func (c rotatedClaims) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(c.Claims)
+	if err != nil {
+		return nil, err
+	}
+
+	var merged map[string]json.RawMessage
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+
+	// Standard members were just re-issued, everything else is copied as is
+	for k, v := range c.raw {
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+
+	return json.Marshal(merged)
+}
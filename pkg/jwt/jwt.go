@@ -1,10 +1,15 @@
 package jwt
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"strconv"
 	"time"
 
 	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
 )
 
 var (
@@ -12,140 +17,369 @@ var (
 	ErrTokenExpired      = errors.New("token expired")
 	ErrInvalidSignMethod = errors.New("invalid signing method")
 	ErrMissingClaims     = errors.New("missing claims")
+	ErrTokenRevoked      = errors.New("token revoked")
+	ErrTokenReused       = errors.New("refresh token reused, token family revoked")
+)
+
+// Token types, a refresh token is never accepted where an access token is expected and vice versa
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
 )
 
 // JWT handles JWT token operations
 type JWT interface {
-	// Generate generates a new JWT token with claims
+	// Generate generates a new access token with claims, without a refresh token
 	Generate(claims Claims) (string, error)
 
-	// Parse parses and validates a JWT token
+	// GenerateClaims generates a new access token from custom claims, see GenerateAs
+	GenerateClaims(claims CustomClaims) (string, error)
+
+	// GeneratePair generates an access and refresh token starting a new token family
+	// Custom claims are carried over when the refresh token is rotated
+	GeneratePair(claims CustomClaims) (*TokenPair, error)
+
+	// Parse parses and validates an access token, it does not consult the revocation store
 	Parse(tokenString string) (*Claims, error)
 
-	// Refresh refreshes an existing token with new expiry
-	Refresh(tokenString string) (string, error)
+	// ParseClaims parses and validates an access token into custom claims, see ParseAs
+	ParseClaims(tokenString string, claims CustomClaims) error
+
+	// Refresh rotates a refresh token into a new pair, presenting a refresh token twice revokes its family
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+
+	// Revoke denies the token until it expires along with every token of its family
+	Revoke(ctx context.Context, claims *Claims) error
+
+	// RevokeUser denies every token issued to the user so far, e.g. after a password reset
+	// Tokens issued within the same second are denied too, iat has second precision
+	RevokeUser(ctx context.Context, userID int64) error
+
+	// IsRevoked reports whether the token, its family or its user was revoked
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
 
 	// Validate validates a token without parsing claims
 	Validate(tokenString string) error
+
+	// JWKS returns the public keys verifiers need, empty when tokens are signed with a shared secret
+	JWKS() JWKS
 }
 
 // Claims represents JWT claims
+// RegisteredClaims.ID carries the jti, every issued token gets a unique one
 type Claims struct {
-	UserID   int64             `json:"user_id"`
-	Username string            `json:"username"`
-	Email    string            `json:"email"`
-	Role     string            `json:"role"`
-	Extra    map[string]string `json:"extra,omitempty"`
+	UserID    int64             `json:"user_id"`
+	Username  string            `json:"username"`
+	Email     string            `json:"email"`
+	Role      string            `json:"role"`
+	Extra     map[string]string `json:"extra,omitempty"`
+	Scope     string            `json:"scope,omitempty"` // Space separated granted scopes, e.g. "campaigns:read campaigns:write"
+	TokenType string            `json:"token_type,omitempty"`
+	Family    string            `json:"family_id,omitempty"` // Shared by every token rotated from the same login
+	AMR       []string          `json:"amr,omitempty"`       // How the user authenticated, e.g. ["pwd", "otp"] (RFC 8176)
+	IssuedUs  int64             `json:"iat_us,omitempty"`    // iat in microseconds, iat itself only has whole seconds
 	jwtlib.RegisteredClaims
 }
 
+// TokenPair is an access token with the refresh token that renews it
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
 // jwtImpl implements JWT interface
 type jwtImpl struct {
-	secretKey []byte
-	issuer    string
-	expiry    time.Duration
+	secretKey     []byte
+	keys          *keySet // nil when signing with secretKey
+	issuer        string
+	audience      []string
+	expiry        time.Duration
+	refreshExpiry time.Duration
+	store         RevocationStore
+	parser        *jwtlib.Parser
 }
 
 // Config holds JWT configuration
 type Config struct {
-	SecretKey string        // Secret key for signing
-	Issuer    string        // Token issuer
-	Expiry    time.Duration // Token expiry duration
+	SecretKey        string          // Secret key for HS256 signing, used when Keys is empty
+	Keys             []Key           // Asymmetric keys, tokens are signed RS256/ES256/EdDSA and carry a kid
+	Issuer           string          // Token issuer, tokens from any other issuer are rejected
+	Audience         []string        // Audiences of generated tokens, e.g. every service that accepts them
+	ExpectedAudience string          // Audience this service accepts, tokens not listing it are rejected, empty accepts any
+	Leeway           time.Duration   // Clock skew tolerated when checking exp, nbf and iat
+	Expiry           time.Duration   // Access token expiry duration
+	RefreshExpiry    time.Duration   // Refresh token expiry duration
+	Store            RevocationStore // Revoked and used token ids, defaults to an in-memory store
 }
 
 // NewJWT creates a new JWT instance
 func NewJWT(config Config) (JWT, error) {
-	if config.SecretKey == "" {
-		return nil, errors.New("secret key is required")
+	if config.SecretKey == "" && len(config.Keys) == 0 {
+		return nil, errors.New("secret key or signing keys are required")
+	}
+
+	var keys *keySet
+	if len(config.Keys) > 0 {
+		ks, err := newKeySet(config.Keys)
+		if err != nil {
+			return nil, err
+		}
+		keys = ks
 	}
 
 	if config.Expiry == 0 {
 		config.Expiry = 24 * time.Hour // Default 24 hours
 	}
 
+	if config.RefreshExpiry == 0 {
+		config.RefreshExpiry = 7 * 24 * time.Hour // Default 7 days
+	}
+
 	if config.Issuer == "" {
 		config.Issuer = "hanif-skeleton"
 	}
 
+	if config.Store == nil {
+		// Only correct for a single instance, revocations are not shared
+		config.Store = NewRevocationStore(cache.NewMemoryCache())
+	}
+
+	options := []jwtlib.ParserOption{
+		jwtlib.WithIssuer(config.Issuer),
+		jwtlib.WithExpirationRequired(),
+		jwtlib.WithIssuedAt(),
+		jwtlib.WithLeeway(config.Leeway),
+	}
+	if config.ExpectedAudience != "" {
+		options = append(options, jwtlib.WithAudience(config.ExpectedAudience))
+	}
+
 	return &jwtImpl{
-		secretKey: []byte(config.SecretKey),
-		issuer:    config.Issuer,
-		expiry:    config.Expiry,
+		secretKey:     []byte(config.SecretKey),
+		keys:          keys,
+		issuer:        config.Issuer,
+		audience:      config.Audience,
+		expiry:        config.Expiry,
+		refreshExpiry: config.RefreshExpiry,
+		store:         config.Store,
+		parser:        jwtlib.NewParser(options...),
 	}, nil
 }
 
-// Generate generates a new JWT token
+// Generate generates a new access token
 func (j *jwtImpl) Generate(claims Claims) (string, error) {
+	return j.GenerateClaims(&claims)
+}
+
+// GenerateClaims generates a new access token from custom claims
+func (j *jwtImpl) GenerateClaims(claims CustomClaims) (string, error) {
+	token, _, err := j.sign(claims, TokenTypeAccess, j.expiry)
+	return token, err
+}
+
+// GeneratePair generates an access and refresh token in a new family
+func (j *jwtImpl) GeneratePair(claims CustomClaims) (*TokenPair, error) {
+	base := claims.Base()
+	family := base.Family
+	defer func() { base.Family = family }()
+
+	base.Family = newTokenID()
+	return j.pair(claims)
+}
+
+func (j *jwtImpl) pair(claims CustomClaims) (*TokenPair, error) {
+	access, accessExp, err := j.sign(claims, TokenTypeAccess, j.expiry)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, refreshExp, err := j.sign(claims, TokenTypeRefresh, j.refreshExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		AccessExpiresAt:  accessExp,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+// sign issues a token of tokenType with a fresh jti, the caller's claims are left untouched
+func (j *jwtImpl) sign(claims CustomClaims, tokenType string, expiry time.Duration) (string, time.Time, error) {
 	now := time.Now()
+	expiresAt := now.Add(expiry)
+
+	base := claims.Base()
+	original := *base
+	defer func() { *base = original }()
 
 	// Set registered claims
-	claims.Issuer = j.issuer
-	claims.IssuedAt = jwtlib.NewNumericDate(now)
-	claims.ExpiresAt = jwtlib.NewNumericDate(now.Add(j.expiry))
-	claims.NotBefore = jwtlib.NewNumericDate(now)
+	base.TokenType = tokenType
+	base.ID = newTokenID()
+	base.Issuer = j.issuer
+	base.IssuedAt = jwtlib.NewNumericDate(now)
+	base.IssuedUs = now.UnixMicro()
+	base.ExpiresAt = jwtlib.NewNumericDate(expiresAt)
+	base.NotBefore = jwtlib.NewNumericDate(now)
+	if len(j.audience) > 0 {
+		base.Audience = j.audience
+	}
+
+	if j.keys == nil {
+		// Create token with claims
+		token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims)
 
-	// Create token with claims
-	token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, claims)
+		// Sign token
+		signed, err := token.SignedString(j.secretKey)
+		return signed, expiresAt, err
+	}
 
-	// Sign token
-	tokenString, err := token.SignedString(j.secretKey)
+	// The newest active key signs, its kid tells verifiers which public key to use
+	key, err := j.keys.signing(now)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 
-	return tokenString, nil
+	token := jwtlib.NewWithClaims(jwtlib.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(key.PrivateKey)
+	return signed, expiresAt, err
 }
 
-// Parse parses and validates a JWT token
+// Parse parses and validates an access token
 func (j *jwtImpl) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if err := j.parse(tokenString, claims, TokenTypeAccess); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ParseClaims parses and validates an access token into custom claims
+func (j *jwtImpl) ParseClaims(tokenString string, claims CustomClaims) error {
+	return j.parse(tokenString, claims, TokenTypeAccess)
+}
+
+// parse validates signature, issuer, audience, expiry and token type
+// Tokens issued before token types existed carry none and are treated as access tokens
+func (j *jwtImpl) parse(tokenString string, claims CustomClaims, tokenType string) error {
 	// Parse token
-	token, err := jwtlib.ParseWithClaims(tokenString, &Claims{}, func(token *jwtlib.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwtlib.SigningMethodHMAC); !ok {
-			return nil, ErrInvalidSignMethod
-		}
-		return j.secretKey, nil
-	})
+	token, err := j.parser.ParseWithClaims(tokenString, claims, j.keyFunc)
 
 	if err != nil {
 		if errors.Is(err, jwtlib.ErrTokenExpired) {
-			return nil, ErrTokenExpired
+			return ErrTokenExpired
 		}
-		return nil, ErrInvalidToken
+		return ErrInvalidToken
 	}
 
-	// Extract claims
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, ErrInvalidToken
+	if !token.Valid {
+		return ErrInvalidToken
 	}
 
-	return claims, nil
+	actual := claims.Base().TokenType
+	if actual == "" {
+		actual = TokenTypeAccess
+	}
+	if actual != tokenType {
+		return ErrInvalidToken
+	}
+
+	return nil
 }
 
-// Refresh refreshes an existing token with new expiry
-func (j *jwtImpl) Refresh(tokenString string) (string, error) {
-	// Parse existing token
-	claims, err := j.Parse(tokenString)
+// Refresh rotates a refresh token, each refresh token can be used exactly once
+func (j *jwtImpl) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	// Expired refresh tokens are rejected, the user has to log in again
+	rotated := &rotatedClaims{}
+	if err := j.parse(refreshToken, rotated, TokenTypeRefresh); err != nil {
+		return nil, err
+	}
+	claims := &rotated.Claims
+
+	revoked, err := j.IsRevoked(ctx, claims)
 	if err != nil {
-		// If token is expired, we can still refresh it
-		if !errors.Is(err, ErrTokenExpired) {
-			return "", err
-		}
-		// Parse without validation for refresh
-		token, _ := jwtlib.ParseWithClaims(tokenString, &Claims{}, func(token *jwtlib.Token) (interface{}, error) {
-			return j.secretKey, nil
-		})
-		claims, _ = token.Claims.(*Claims)
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
 	}
 
-	// Generate new token with same claims
-	newToken, err := j.Generate(*claims)
+	first, err := j.store.Use(ctx, claims.ID, remaining(claims))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if !first {
+		// Either the client or an attacker holds a stolen copy, revoke the family so neither can continue
+		if err := j.store.Revoke(ctx, familyID(claims.Family), j.refreshExpiry); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReused
+	}
+
+	// Rotation keeps the family so a later reuse still revokes every descendant
+	return j.pair(rotated)
+}
+
+// Revoke denies the token and its family, used on logout
+func (j *jwtImpl) Revoke(ctx context.Context, claims *Claims) error {
+	if claims.ID != "" {
+		if err := j.store.Revoke(ctx, tokenID(claims.ID), remaining(claims)); err != nil {
+			return err
+		}
+	}
+
+	// Any token of the family expires at most refreshExpiry after the last rotation
+	if claims.Family != "" {
+		if err := j.store.Revoke(ctx, familyID(claims.Family), j.refreshExpiry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RevokeUser denies every token of the user issued up to now
+func (j *jwtImpl) RevokeUser(ctx context.Context, userID int64) error {
+	// Every earlier token expires at most refreshExpiry from now, rotating them is denied as well
+	return j.store.RevokeBefore(ctx, userSubject(userID), time.Now(), j.refreshExpiry)
+}
+
+// IsRevoked checks the token, its family and its user against the revocation store
+func (j *jwtImpl) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if claims.ID != "" {
+		revoked, err := j.store.IsRevoked(ctx, tokenID(claims.ID))
+		if err != nil || revoked {
+			return revoked, err
+		}
 	}
 
-	return newToken, nil
+	if claims.Family != "" {
+		revoked, err := j.store.IsRevoked(ctx, familyID(claims.Family))
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	if claims.UserID != 0 && claims.IssuedAt != nil {
+		before, err := j.store.RevokedBefore(ctx, userSubject(claims.UserID))
+		if err != nil || before.IsZero() {
+			return false, err
+		}
+		// A login right after RevokeUser usually falls in the same second, only iat_us tells it apart
+		issued := claims.IssuedAt.Time
+		if claims.IssuedUs != 0 {
+			issued = time.UnixMicro(claims.IssuedUs)
+		}
+		return !issued.After(before), nil
+	}
+
+	return false, nil
 }
 
 // Validate validates a token without parsing claims
@@ -154,6 +388,75 @@ func (j *jwtImpl) Validate(tokenString string) error {
 	return err
 }
 
+// JWKS returns the public keys of every key not yet expired, scheduled keys included
+func (j *jwtImpl) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	if j.keys == nil {
+		return jwks
+	}
+
+	for _, k := range j.keys.published(time.Now()) {
+		if jwk, ok := toJWK(k); ok {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
+
+// keyFunc resolves the verification key, the algorithm must match the key so tokens cannot downgrade
+func (j *jwtImpl) keyFunc(token *jwtlib.Token) (interface{}, error) {
+	if j.keys == nil {
+		// Validate signing method
+		if _, ok := token.Method.(*jwtlib.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidSignMethod
+		}
+		return j.secretKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, err := j.keys.verification(kid, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrInvalidSignMethod
+	}
+
+	return key.PublicKey, nil
+}
+
+// remaining returns how long a token stays valid, revocations only need to outlive it
+func remaining(claims *Claims) time.Duration {
+	if claims.ExpiresAt == nil {
+		return time.Second
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl > time.Second {
+		return ttl
+	}
+	return time.Second
+}
+
+// newTokenID returns a random jti or family id
+func newTokenID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func tokenID(jti string) string {
+	return "jti:" + jti
+}
+
+func familyID(family string) string {
+	return "family:" + family
+}
+
+func userSubject(userID int64) string {
+	return "user:" + strconv.FormatInt(userID, 10)
+}
+
 // GetUserID extracts user ID from claims
 func (c *Claims) GetUserID() int64 {
 	return c.UserID
@@ -175,6 +478,8 @@ func (c *Claims) GetRole() string {
 }
 
 // IsAdmin checks if user is admin
+//
+// Deprecated: Check a permission with authz.Authorizer, which roles are admins is up to the policy
 func (c *Claims) IsAdmin() bool {
 	return c.Role == "admin" || c.Role == "superadmin"
 }
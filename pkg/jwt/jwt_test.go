@@ -0,0 +1,470 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJWT(t *testing.T, keys ...Key) JWT {
+	t.Helper()
+	j, err := NewJWT(Config{Keys: keys, Issuer: "test", Expiry: time.Hour})
+	require.NoError(t, err)
+	return j
+}
+
+func headerOf(t *testing.T, token string) map[string]interface{} {
+	t.Helper()
+	parsed, _, err := jwtlib.NewParser().ParseUnverified(token, &Claims{})
+	require.NoError(t, err)
+	return parsed.Header
+}
+
+func TestHS256(t *testing.T) {
+	j, err := NewJWT(Config{SecretKey: "secret", Issuer: "test", Expiry: time.Hour})
+	require.NoError(t, err)
+
+	token, err := j.Generate(Claims{UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "HS256", headerOf(t, token)["alg"])
+
+	claims, err := j.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+	assert.Empty(t, j.JWKS().Keys)
+}
+
+func TestAsymmetricAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  Key
+		alg  string
+		kty  string
+	}{
+		{"rsa", Key{ID: "rsa", PrivateKey: rsaKey}, "RS256", "RSA"},
+		{"ecdsa", Key{ID: "ec", PrivateKey: ecKey}, "ES256", "EC"},
+		{"ed25519", Key{ID: "ed", PrivateKey: edKey}, "EdDSA", "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := newJWT(t, tt.key)
+
+			token, err := j.Generate(Claims{UserID: 7, Role: "admin"})
+			require.NoError(t, err)
+
+			header := headerOf(t, token)
+			assert.Equal(t, tt.alg, header["alg"])
+			assert.Equal(t, tt.key.ID, header["kid"])
+
+			claims, err := j.Parse(token)
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), claims.UserID)
+
+			jwks := j.JWKS()
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.alg, jwks.Keys[0].Alg)
+			assert.Equal(t, tt.key.ID, jwks.Keys[0].Kid)
+
+			// Published keys decode back to the signing key
+			pub, err := jwks.Keys[0].PublicKey()
+			require.NoError(t, err)
+			assert.True(t, tt.key.PrivateKey.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(pub))
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	now := time.Now()
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	nextKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// Tokens signed before the rotation stay valid during the grace period
+	before := newJWT(t, Key{ID: "old", PrivateKey: oldKey})
+	oldToken, err := before.Generate(Claims{UserID: 1})
+	require.NoError(t, err)
+
+	j := newJWT(t,
+		Key{ID: "old", PrivateKey: oldKey, NotBefore: now.Add(-48 * time.Hour), NotAfter: now.Add(time.Hour)},
+		Key{ID: "new", PrivateKey: newKey, NotBefore: now.Add(-time.Minute)},
+		Key{ID: "next", PrivateKey: nextKey, NotBefore: now.Add(24 * time.Hour)},
+	)
+
+	token, err := j.Generate(Claims{UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "new", headerOf(t, token)["kid"], "newest active key signs, scheduled keys wait")
+
+	_, err = j.Parse(oldToken)
+	assert.NoError(t, err)
+
+	kids := []string{}
+	for _, k := range j.JWKS().Keys {
+		kids = append(kids, k.Kid)
+	}
+	assert.ElementsMatch(t, []string{"old", "new", "next"}, kids, "scheduled keys are published ahead of use")
+
+	// Once the grace period is over the old key is gone
+	expired := newJWT(t,
+		Key{ID: "old", PrivateKey: oldKey, NotAfter: now.Add(-time.Minute)},
+		Key{ID: "new", PrivateKey: newKey},
+	)
+	_, err = expired.Parse(oldToken)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Len(t, expired.JWKS().Keys, 1)
+}
+
+func TestVerifyOnly(t *testing.T) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer := newJWT(t, Key{ID: "k1", PrivateKey: private})
+	token, err := signer.Generate(Claims{UserID: 3})
+	require.NoError(t, err)
+
+	verifier := newJWT(t, Key{ID: "k1", PublicKey: &private.PublicKey})
+	claims, err := verifier.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), claims.UserID)
+
+	_, err = verifier.Generate(Claims{UserID: 3})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func TestRejectsForgedTokens(t *testing.T) {
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	j := newJWT(t, Key{ID: "k1", PrivateKey: private})
+
+	t.Run("unknown kid", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		token, err := newJWT(t, Key{ID: "k2", PrivateKey: other}).Generate(Claims{UserID: 1})
+		require.NoError(t, err)
+
+		_, err = j.Parse(token)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("hmac signed with the public key", func(t *testing.T) {
+		pub, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+		require.NoError(t, err)
+
+		token := jwtlib.NewWithClaims(jwtlib.SigningMethodHS256, &Claims{UserID: 1})
+		token.Header["kid"] = "k1"
+		forged, err := token.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+		require.NoError(t, err)
+
+		_, err = j.Parse(forged)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("refresh verifies the signature", func(t *testing.T) {
+		pair, err := j.GeneratePair(&Claims{UserID: 1})
+		require.NoError(t, err)
+
+		parts := strings.Split(pair.RefreshToken, ".")
+		tampered := parts[0] + "." + parts[1] + ".AAAA"
+
+		_, err = j.Refresh(context.Background(), tampered)
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestNewKeySetValidation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, err = newKeySet([]Key{{ID: "k1", PrivateKey: rsaKey, Algorithm: "ES256"}})
+	assert.Error(t, err, "algorithm must match the key type")
+
+	_, err = newKeySet([]Key{{ID: "k1", PrivateKey: rsaKey}, {ID: "k1", PrivateKey: rsaKey}})
+	assert.Error(t, err, "kid must be unique")
+
+	_, err = newKeySet([]Key{{PrivateKey: rsaKey}})
+	assert.Error(t, err, "kid is required")
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "k1.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	manifest := `[{"kid": "k1", "private_key_file": "k1.pem", "not_before": "2024-01-01T00:00:00Z"}]`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.json"), []byte(manifest), 0o600))
+
+	keys, err := LoadKeys(filepath.Join(dir, "keys.json"))
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "k1", keys[0].ID)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), keys[0].NotBefore)
+
+	j := newJWT(t, keys...)
+	token, err := j.Generate(Claims{UserID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", headerOf(t, token)["alg"])
+}
+
+func TestTokenTypes(t *testing.T) {
+	j, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+
+	pair, err := j.GeneratePair(&Claims{UserID: 1})
+	require.NoError(t, err)
+
+	_, err = j.Parse(pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "refresh tokens are not access tokens")
+
+	_, err = j.Refresh(context.Background(), pair.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidToken, "access tokens cannot be refreshed")
+
+	access, err := j.Parse(pair.AccessToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, access.ID)
+	assert.NotEmpty(t, access.Family)
+	assert.True(t, pair.RefreshExpiresAt.After(pair.AccessExpiresAt))
+}
+
+func TestRefreshRotation(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+
+	pair, err := j.GeneratePair(&Claims{UserID: 1, AMR: []string{AMRPassword, AMROTP}})
+	require.NoError(t, err)
+
+	rotated, err := j.Refresh(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, pair.RefreshToken, rotated.RefreshToken)
+
+	claims, err := j.Parse(rotated.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), claims.UserID)
+	assert.True(t, claims.AuthenticatedWith(AMROTP), "a two-factor login stays two-factor across rotation")
+
+	// Presenting the old refresh token again kills the whole family
+	_, err = j.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenReused)
+
+	_, err = j.Refresh(ctx, rotated.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	revoked, err := j.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked, "access tokens of the family are revoked too")
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+
+	pair, err := j.GeneratePair(&Claims{UserID: 1})
+	require.NoError(t, err)
+	other, err := j.GeneratePair(&Claims{UserID: 1})
+	require.NoError(t, err)
+
+	claims, err := j.Parse(pair.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, j.Revoke(ctx, claims))
+
+	revoked, err := j.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = j.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked, "logout revokes the refresh token of the same login")
+
+	otherClaims, err := j.Parse(other.AccessToken)
+	require.NoError(t, err)
+	revoked, err = j.IsRevoked(ctx, otherClaims)
+	require.NoError(t, err)
+	assert.False(t, revoked, "other sessions are unaffected")
+}
+
+func TestRevokeUser(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+
+	pair, err := j.GeneratePair(&Claims{UserID: 1})
+	require.NoError(t, err)
+	other, err := j.GeneratePair(&Claims{UserID: 2})
+	require.NoError(t, err)
+
+	require.NoError(t, j.RevokeUser(ctx, 1))
+
+	claims, err := j.Parse(pair.AccessToken)
+	require.NoError(t, err)
+	revoked, err := j.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked, "access tokens of the user are revoked")
+
+	_, err = j.Refresh(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, ErrTokenRevoked, "refresh tokens of the user can no longer rotate")
+
+	otherClaims, err := j.Parse(other.AccessToken)
+	require.NoError(t, err)
+	revoked, err = j.IsRevoked(ctx, otherClaims)
+	require.NoError(t, err)
+	assert.False(t, revoked, "other users are unaffected")
+}
+
+func TestRevokeUserWithinTheSameSecond(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+
+	// Start at a second boundary so the tokens and the revocation share their iat second
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	before, err := j.GeneratePair(&Claims{UserID: 1})
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	require.NoError(t, j.RevokeUser(ctx, 1))
+	time.Sleep(time.Millisecond)
+	after, err := j.GeneratePair(&Claims{UserID: 1})
+	require.NoError(t, err)
+
+	claims, err := j.Parse(before.AccessToken)
+	require.NoError(t, err)
+	revoked, err := j.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.True(t, revoked, "tokens issued before RevokeUser are revoked")
+
+	issuedBefore := claims.IssuedAt.Unix()
+	claims, err = j.Parse(after.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, issuedBefore, claims.IssuedAt.Unix(), "both tokens have the same iat")
+	revoked, err = j.IsRevoked(ctx, claims)
+	require.NoError(t, err)
+	assert.False(t, revoked, "a login right after RevokeUser is not revoked")
+
+	_, err = j.Refresh(ctx, after.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestClaimsValidation(t *testing.T) {
+	issuer, err := NewJWT(Config{SecretKey: "secret", Issuer: "auth", Audience: []string{"orders", "billing"}})
+	require.NoError(t, err)
+
+	token, err := issuer.Generate(Claims{UserID: 1})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		config Config
+		err    error
+	}{
+		{"audience listed", Config{SecretKey: "secret", Issuer: "auth", ExpectedAudience: "orders"}, nil},
+		{"any audience", Config{SecretKey: "secret", Issuer: "auth"}, nil},
+		{"audience not listed", Config{SecretKey: "secret", Issuer: "auth", ExpectedAudience: "inventory"}, ErrInvalidToken},
+		{"other issuer", Config{SecretKey: "secret", Issuer: "someone-else", ExpectedAudience: "orders"}, ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewJWT(tt.config)
+			require.NoError(t, err)
+
+			_, err = verifier.Parse(token)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLeeway(t *testing.T) {
+	expired, err := NewJWT(Config{SecretKey: "secret", Expiry: -10 * time.Second})
+	require.NoError(t, err)
+	token, err := expired.Generate(Claims{UserID: 1})
+	require.NoError(t, err)
+
+	strict, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+	_, err = strict.Parse(token)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	tolerant, err := NewJWT(Config{SecretKey: "secret", Leeway: time.Minute})
+	require.NoError(t, err)
+	_, err = tolerant.Parse(token)
+	assert.NoError(t, err)
+}
+
+func TestScopes(t *testing.T) {
+	claims := Claims{Scope: "campaigns:read  campaigns:write"}
+
+	assert.Equal(t, []string{"campaigns:read", "campaigns:write"}, claims.Scopes())
+	assert.True(t, claims.HasScope("campaigns:read"))
+	assert.True(t, claims.HasScopes("campaigns:read", "campaigns:write"))
+	assert.False(t, claims.HasScopes("campaigns:read", "users:write"))
+}
+
+type tenantClaims struct {
+	Claims
+	TenantID    string   `json:"tenant_id"`
+	Permissions []string `json:"permissions"`
+}
+
+func TestCustomClaims(t *testing.T) {
+	ctx := context.Background()
+	j, err := NewJWT(Config{SecretKey: "secret"})
+	require.NoError(t, err)
+
+	claims := &tenantClaims{Claims: Claims{UserID: 1, Scope: "orders:read"}, TenantID: "acme", Permissions: []string{"orders.view"}}
+
+	token, err := GenerateAs(j, claims)
+	require.NoError(t, err)
+	assert.Empty(t, claims.ID, "claims passed in are not modified")
+
+	parsed, err := ParseAs[tenantClaims](j, token)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", parsed.TenantID)
+	assert.Equal(t, []string{"orders.view"}, parsed.Permissions)
+	assert.True(t, parsed.HasScope("orders:read"))
+
+	// Custom members survive refresh token rotation
+	pair, err := j.GeneratePair(claims)
+	require.NoError(t, err)
+	rotated, err := j.Refresh(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+
+	parsed, err = ParseAs[tenantClaims](j, rotated.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", parsed.TenantID)
+	assert.Equal(t, TokenTypeAccess, parsed.TokenType)
+	assert.NotEmpty(t, parsed.Family)
+}
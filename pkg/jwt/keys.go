@@ -0,0 +1,267 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoSigningKey       = errors.New("no active signing key")
+	ErrUnknownKey         = errors.New("unknown key id")
+	ErrUnsupportedKeyType = errors.New("unsupported key type")
+)
+
+// Key is an asymmetric key identified by kid
+// Keys without a private key only verify, e.g. a retired key whose private half was destroyed
+type Key struct {
+	ID         string
+	Algorithm  string // RS256, ES256, ES384, ES512 or EdDSA, inferred from the key when empty
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	NotBefore  time.Time // Signs new tokens from this time, zero means immediately
+	NotAfter   time.Time // Tokens are no longer accepted after this time, zero means never
+}
+
+// keySet picks the signing key and resolves verification keys by kid
+type keySet struct {
+	keys []Key // Sorted by NotBefore, newest first
+	byID map[string]Key
+}
+
+func newKeySet(keys []Key) (*keySet, error) {
+	ks := &keySet{byID: make(map[string]Key, len(keys))}
+
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("key id is required")
+		}
+		if _, exists := ks.byID[k.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+
+		if k.PublicKey == nil && k.PrivateKey != nil {
+			k.PublicKey = k.PrivateKey.Public()
+		}
+		if k.PublicKey == nil {
+			return nil, fmt.Errorf("key %q has no public key", k.ID)
+		}
+
+		if k.Algorithm == "" {
+			alg, err := algorithmFor(k.PublicKey)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.ID, err)
+			}
+			k.Algorithm = alg
+		}
+		if jwtlib.GetSigningMethod(k.Algorithm) == nil {
+			return nil, fmt.Errorf("key %q: unknown algorithm %q", k.ID, k.Algorithm)
+		}
+		if !compatible(k.Algorithm, k.PublicKey) {
+			return nil, fmt.Errorf("key %q: algorithm %s does not match the key type", k.ID, k.Algorithm)
+		}
+
+		ks.keys = append(ks.keys, k)
+		ks.byID[k.ID] = k
+	}
+
+	sort.SliceStable(ks.keys, func(i, j int) bool {
+		return ks.keys[i].NotBefore.After(ks.keys[j].NotBefore)
+	})
+
+	return ks, nil
+}
+
+// signing returns the newest key that holds a private key and is active at now
+// Keys scheduled for the future are already published, so verifiers know them before the switch
+func (ks *keySet) signing(now time.Time) (Key, error) {
+	for _, k := range ks.keys {
+		if k.PrivateKey == nil || now.Before(k.NotBefore) || k.expired(now) {
+			continue
+		}
+		return k, nil
+	}
+	return Key{}, ErrNoSigningKey
+}
+
+// verification returns the key a token names in its kid header, expired keys are rejected
+func (ks *keySet) verification(kid string, now time.Time) (Key, error) {
+	k, ok := ks.byID[kid]
+	if !ok || k.expired(now) {
+		return Key{}, ErrUnknownKey
+	}
+	return k, nil
+}
+
+// published returns every key verifiers should know, including scheduled ones
+func (ks *keySet) published(now time.Time) []Key {
+	keys := make([]Key, 0, len(ks.keys))
+	for _, k := range ks.keys {
+		if !k.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (k Key) expired(now time.Time) bool {
+	return !k.NotAfter.IsZero() && now.After(k.NotAfter)
+}
+
+func algorithmFor(pub crypto.PublicKey) (string, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return "RS256", nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return "ES256", nil
+		case elliptic.P384():
+			return "ES384", nil
+		case elliptic.P521():
+			return "ES512", nil
+		}
+	case ed25519.PublicKey:
+		return "EdDSA", nil
+	}
+	return "", ErrUnsupportedKeyType
+}
+
+// compatible reports whether alg can be used with the key type, so an RSA key never signs as ES256
+func compatible(alg string, pub crypto.PublicKey) bool {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") || strings.HasPrefix(alg, "PS")
+	case *ecdsa.PublicKey:
+		inferred, err := algorithmFor(pub)
+		return err == nil && alg == inferred
+	case ed25519.PublicKey:
+		return alg == "EdDSA"
+	}
+	return false
+}
+
+// keyFileEntry is one key in a key manifest
+type keyFileEntry struct {
+	ID             string    `json:"kid"`
+	Algorithm      string    `json:"algorithm,omitempty"`
+	PrivateKeyFile string    `json:"private_key_file,omitempty"`
+	PublicKeyFile  string    `json:"public_key_file,omitempty"`
+	NotBefore      time.Time `json:"not_before,omitempty"`
+	NotAfter       time.Time `json:"not_after,omitempty"`
+}
+
+// LoadKeys reads a JSON key manifest, key file paths are relative to the manifest:
+//
+//	[
+//	  {"kid": "2024-01", "private_key_file": "2024-01.pem", "not_after": "2024-07-02T00:00:00Z"},
+//	  {"kid": "2024-07", "private_key_file": "2024-07.pem", "not_before": "2024-07-01T00:00:00Z"}
+//	]
+//
+// Private keys are PKCS#8 PEM, public keys PKIX PEM
+func LoadKeys(path string) ([]Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key manifest: %w", err)
+	}
+
+	var entries []keyFileEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse key manifest: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	keys := make([]Key, 0, len(entries))
+
+	for _, e := range entries {
+		k := Key{
+			ID:        e.ID,
+			Algorithm: e.Algorithm,
+			NotBefore: e.NotBefore,
+			NotAfter:  e.NotAfter,
+		}
+
+		if e.PrivateKeyFile != "" {
+			k.PrivateKey, err = readPrivateKey(resolve(dir, e.PrivateKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", e.ID, err)
+			}
+		}
+
+		if e.PublicKeyFile != "" {
+			k.PublicKey, err = readPublicKey(resolve(dir, e.PublicKeyFile))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", e.ID, err)
+			}
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+func resolve(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKeyType
+	}
+	return signer, nil
+}
+
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,85 @@
+package jwt
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+)
+
+// RevocationStore keeps revoked token ids and consumed refresh tokens until the tokens expire on their own
+type RevocationStore interface {
+	// Revoke denies id for ttl
+	Revoke(ctx context.Context, id string, ttl time.Duration) error
+
+	// IsRevoked reports whether id was revoked
+	IsRevoked(ctx context.Context, id string) (bool, error)
+
+	// Use marks a single use id as consumed for ttl, reports false when it was already used
+	Use(ctx context.Context, id string, ttl time.Duration) (bool, error)
+
+	// RevokeBefore denies every token of subject issued at or before at, for ttl
+	RevokeBefore(ctx context.Context, subject string, at time.Time, ttl time.Duration) error
+
+	// RevokedBefore returns the time set by RevokeBefore for subject, zero when there is none
+	RevokedBefore(ctx context.Context, subject string) (time.Time, error)
+}
+
+// cacheRevocationStore implements RevocationStore on top of cache.Cache
+type cacheRevocationStore struct {
+	cache   cache.Cache
+	revoked *cache.CacheKey
+	used    *cache.CacheKey
+	before  *cache.CacheKey
+}
+
+// NewRevocationStore creates a revocation store, use a shared cache such as Redis when running several instances
+func NewRevocationStore(c cache.Cache) RevocationStore {
+	return &cacheRevocationStore{
+		cache:   c,
+		revoked: cache.NewCacheKey("jwt:revoked"),
+		used:    cache.NewCacheKey("jwt:used"),
+		before:  cache.NewCacheKey("jwt:revoked_before"),
+	}
+}
+
+func (s *cacheRevocationStore) Revoke(ctx context.Context, id string, ttl time.Duration) error {
+	return s.cache.Set(ctx, s.revoked.Build(id), 1, ttl)
+}
+
+func (s *cacheRevocationStore) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return s.cache.Exists(ctx, s.revoked.Build(id))
+}
+
+func (s *cacheRevocationStore) Use(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	// SetNX is atomic on every driver, only the first caller sets the key and its expiry
+	return s.cache.SetNX(ctx, s.used.Build(id), 1, ttl)
+}
+
+func (s *cacheRevocationStore) RevokeBefore(ctx context.Context, subject string, at time.Time, ttl time.Duration) error {
+	// Microseconds, as iat_us of the tokens it is compared with
+	return s.cache.Set(ctx, s.before.Build(subject), at.UnixMicro(), ttl)
+}
+
+func (s *cacheRevocationStore) RevokedBefore(ctx context.Context, subject string) (time.Time, error) {
+	key := s.before.Build(subject)
+
+	exists, err := s.cache.Exists(ctx, key)
+	if err != nil || !exists {
+		return time.Time{}, err
+	}
+
+	value, err := s.cache.Get(ctx, key)
+	if err != nil {
+		// Expired between both calls
+		return time.Time{}, nil
+	}
+
+	at, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid revocation time: %w", err)
+	}
+	return time.UnixMicro(at), nil
+}
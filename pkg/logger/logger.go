@@ -110,6 +110,11 @@ func Setup() {
 	)
 }
 
+// SetupNop discards all logs, for commands whose stdout is their output
+func SetupNop() {
+	log = zap.NewNop()
+}
+
 // Cleanup shuts down the logger and flushes any buffered logs
 func Cleanup() {
 	if log != nil {
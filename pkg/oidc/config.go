@@ -0,0 +1,43 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// LoadProviders reads a JSON list of providers, ${VAR} references are expanded so secrets can stay in the environment:
+//
+//	[
+//	  {
+//	    "name": "google",
+//	    "issuer": "https://accounts.google.com",
+//	    "client_id": "xxx.apps.googleusercontent.com",
+//	    "client_secret": "${GOOGLE_CLIENT_SECRET}",
+//	    "redirect_url": "http://localhost:3000/auth/callback/google"
+//	  }
+//	]
+func LoadProviders(path string) ([]Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read providers: %w", err)
+	}
+
+	var providers []Config
+	if err := json.Unmarshal([]byte(os.ExpandEnv(string(data))), &providers); err != nil {
+		return nil, fmt.Errorf("failed to parse providers: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, p := range providers {
+		if p.Name == "" || p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			return nil, fmt.Errorf("provider %q: name, issuer, client_id and redirect_url are required", p.Name)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("provider %q is configured twice", p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	return providers, nil
+}
@@ -0,0 +1,324 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	jwtlib "github.com/golang-jwt/jwt/v5"
+	"github.com/hanifkf12/hanif_skeleton/pkg/httpclient"
+	"github.com/hanifkf12/hanif_skeleton/pkg/jwt"
+)
+
+// leeway tolerates clock skew between us and the provider when validating exp and iat
+const leeway = time.Minute
+
+var (
+	ErrDiscovery      = errors.New("oidc discovery failed")
+	ErrExchange       = errors.New("oidc code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid id token")
+)
+
+// Config describes a provider registered with us as a client
+type Config struct {
+	Name         string   `json:"name"`   // Used in routes, e.g. /auth/oidc/google
+	Issuer       string   `json:"issuer"` // Discovery is read from <issuer>/.well-known/openid-configuration
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"` // "openid" is always requested, defaults to openid, email and profile
+}
+
+// Token is the response of the token endpoint
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+}
+
+// Identity is the verified user the provider vouches for
+type Identity struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+	AMR               []string
+}
+
+// Provider is an OpenID Connect provider used for sign in, e.g. Google or Keycloak
+// Plain OAuth2 providers without discovery and ID tokens, like GitHub, are not supported
+type Provider interface {
+	// Name returns the configured name
+	Name() string
+
+	// AuthCodeURL returns the URL the user is sent to, codeChallenge is the S256 PKCE challenge
+	AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error)
+
+	// Exchange redeems the authorization code with the PKCE verifier
+	Exchange(ctx context.Context, code string, codeVerifier string) (*Token, error)
+
+	// VerifyIDToken checks signature, issuer, audience, expiry and nonce of an ID token
+	VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Identity, error)
+}
+
+// discovery is the part of the provider metadata we use (OpenID Connect Discovery 1.0)
+type discovery struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	SigningAlgorithms     []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// provider discovers endpoints and keys lazily, so a provider being down does not stop the service from starting
+type provider struct {
+	client httpclient.HTTPClient
+	cfg    Config
+
+	mu        sync.Mutex
+	metadata  *discovery
+	keys      jwt.JWKS
+	keysFetch time.Time
+}
+
+// NewProvider creates a provider, requests are made through client so tests can point it at a stand-in provider
+func NewProvider(client httpclient.HTTPClient, cfg Config) Provider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+
+	return &provider{
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+func (p *provider) Exchange(ctx context.Context, code string, codeVerifier string) (*Token, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	resp, err := p.client.Post(ctx, meta.TokenEndpoint, form, map[string]string{"Accept": "application/json"})
+	if resp == nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if !resp.IsSuccess() {
+		// Token endpoints answer errors as {"error": "invalid_grant", "error_description": "..."}
+		var body struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = resp.JSON(&body)
+		return nil, fmt.Errorf("%w: status %d %s %s", ErrExchange, resp.StatusCode, body.Error, body.Description)
+	}
+
+	var token Token
+	if err := resp.JSON(&token); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchange, err)
+	}
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response, is the openid scope granted?", ErrExchange)
+	}
+
+	return &token, nil
+}
+
+func (p *provider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider lists its algorithms, only asymmetric ones are accepted since we do not share a key
+	algorithms := []string{"RS256"}
+	if len(meta.SigningAlgorithms) > 0 {
+		algorithms = meta.SigningAlgorithms
+	}
+	algorithms = slices.DeleteFunc(slices.Clone(algorithms), func(alg string) bool {
+		return alg == "none" || strings.HasPrefix(alg, "HS")
+	})
+
+	parser := jwtlib.NewParser(
+		jwtlib.WithValidMethods(algorithms),
+		jwtlib.WithIssuer(meta.Issuer),
+		jwtlib.WithAudience(p.cfg.ClientID),
+		jwtlib.WithExpirationRequired(),
+		jwtlib.WithIssuedAt(),
+		jwtlib.WithLeeway(leeway),
+	)
+
+	var claims idTokenClaims
+	_, err = parser.ParseWithClaims(rawIDToken, &claims, func(token *jwtlib.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// With several audiences the token must say it was issued to us
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp %q is not the client", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+
+	return &Identity{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+		AMR:               claims.AMR,
+	}, nil
+}
+
+// discover fetches the provider metadata once, failures are retried on the next call
+func (p *provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	endpoint := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	resp, err := p.client.Get(ctx, endpoint, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	var meta discovery
+	if err := resp.JSON(&meta); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	// The issuer must be the one we were configured with, or anyone serving the document could mint identities
+	if strings.TrimSuffix(meta.Issuer, "/") != strings.TrimSuffix(p.cfg.Issuer, "/") {
+		return nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscovery, meta.Issuer, p.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete provider metadata", ErrDiscovery)
+	}
+
+	p.metadata = &meta
+	return p.metadata, nil
+}
+
+// key returns the signing key kid, the key set is refetched when kid is unknown since providers rotate keys
+func (p *provider) key(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if k, ok := p.keys.Key(kid); ok {
+		return k.PublicKey()
+	}
+
+	// Rate limit refetches so tokens with made up kids cannot hammer the provider
+	if time.Since(p.keysFetch) < 10*time.Second {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	p.keysFetch = time.Now()
+
+	resp, err := p.client.Get(ctx, p.metadata.JWKSURI, map[string]string{"Accept": "application/json"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch keys: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch keys: status %d", resp.StatusCode)
+	}
+
+	var keys jwt.JWKS
+	if err := json.Unmarshal(resp.Body, &keys); err != nil {
+		return nil, fmt.Errorf("failed to decode keys: %w", err)
+	}
+	p.keys = keys
+
+	k, ok := p.keys.Key(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	return k.PublicKey()
+}
+
+// idTokenClaims are the ID token members we read (OpenID Connect Core 1.0 section 2 and 5.1)
+type idTokenClaims struct {
+	Nonce             string   `json:"nonce"`
+	AuthorizedParty   string   `json:"azp"`
+	Email             string   `json:"email"`
+	EmailVerified     flexBool `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+	AMR               []string `json:"amr"`
+	jwtlib.RegisteredClaims
+}
+
+// flexBool accepts both true and "true", some providers send email_verified as a string
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	switch strings.Trim(string(data), `"`) {
+	case "true":
+		*b = true
+	default:
+		*b = false
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/httpclient"
+	"github.com/hanifkf12/hanif_skeleton/pkg/logger"
+	"github.com/hanifkf12/hanif_skeleton/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// httpclient logs every request
+	logger.SetupNop()
+	os.Exit(m.Run())
+}
+
+func newTestProvider(t *testing.T) (Provider, *oidctest.Server) {
+	t.Helper()
+
+	server, err := oidctest.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+
+	cfg := httpclient.DefaultConfig()
+	cfg.MaxRetries = 0
+
+	return NewProvider(httpclient.NewHTTPClient(cfg), Config{
+		Name:         "test",
+		Issuer:       server.Issuer,
+		ClientID:     oidctest.ClientID,
+		ClientSecret: oidctest.ClientSecret,
+		RedirectURL:  "http://localhost:3000/auth/callback",
+	}), server
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	ctx := context.Background()
+	provider, server := newTestProvider(t)
+
+	pkce, err := NewPKCE()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state-123", "nonce-456", pkce.Challenge)
+	require.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+	code, state, err := server.Authorize(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "state-123", state)
+
+	token, err := provider.Exchange(ctx, code, pkce.Verifier)
+	require.NoError(t, err)
+
+	identity, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-456")
+	require.NoError(t, err)
+	assert.Equal(t, server.Issuer, identity.Issuer)
+	assert.Equal(t, "248289761001", identity.Subject)
+	assert.Equal(t, "jane.doe@example.com", identity.Email)
+	assert.True(t, identity.EmailVerified)
+	assert.Equal(t, "jane", identity.PreferredUsername)
+
+	// Codes are single use
+	_, err = provider.Exchange(ctx, code, pkce.Verifier)
+	assert.ErrorIs(t, err, ErrExchange)
+}
+
+func TestExchangeRequiresVerifier(t *testing.T) {
+	ctx := context.Background()
+	provider, server := newTestProvider(t)
+
+	pkce, err := NewPKCE()
+	require.NoError(t, err)
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", pkce.Challenge)
+	require.NoError(t, err)
+	code, _, err := server.Authorize(authURL)
+	require.NoError(t, err)
+
+	other, err := NewPKCE()
+	require.NoError(t, err)
+
+	_, err = provider.Exchange(ctx, code, other.Verifier)
+	assert.ErrorIs(t, err, ErrExchange)
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	ctx := context.Background()
+	provider, server := newTestProvider(t)
+	user := oidctest.User{Subject: "42", Email: "a@example.com"}
+
+	valid, err := server.IDToken(user, oidctest.ClientID, "nonce", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	identity, err := provider.VerifyIDToken(ctx, valid, "nonce")
+	require.NoError(t, err)
+	assert.False(t, identity.EmailVerified)
+
+	_, err = provider.VerifyIDToken(ctx, valid, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken, "nonce must match")
+
+	foreign, err := server.IDToken(user, "another-client", "nonce", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, foreign, "nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken, "audience must be the client")
+
+	expired, err := server.IDToken(user, oidctest.ClientID, "nonce", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, expired, "nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken, "expired tokens are rejected")
+
+	// Signed by a different provider with the same kid
+	impostor, err := oidctest.NewServer()
+	require.NoError(t, err)
+	defer impostor.Close()
+	impostor.Issuer = server.Issuer
+	forged, err := impostor.IDToken(user, oidctest.ClientID, "nonce", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, forged, "nonce")
+	assert.ErrorIs(t, err, ErrInvalidIDToken, "signature must verify")
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server, err := oidctest.NewServer()
+	require.NoError(t, err)
+	defer server.Close()
+
+	provider := NewProvider(httpclient.NewHTTPClient(httpclient.DefaultConfig()), Config{
+		Name:     "test",
+		Issuer:   server.Issuer + "/tenant",
+		ClientID: oidctest.ClientID,
+	})
+
+	_, err = provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	assert.ErrorIs(t, err, ErrDiscovery)
+}
+
+func TestLoadProviders(t *testing.T) {
+	t.Setenv("OIDCTEST_SECRET", "s3cret")
+
+	path := filepath.Join(t.TempDir(), "providers.json")
+	data := `[{"name": "google", "issuer": "https://accounts.google.com", "client_id": "id", "client_secret": "${OIDCTEST_SECRET}", "redirect_url": "http://localhost/cb"}]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	providers, err := LoadProviders(path)
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	assert.Equal(t, "s3cret", providers[0].ClientSecret)
+
+	require.NoError(t, os.WriteFile(path, []byte(`[{"name": "google"}]`), 0o600))
+	_, err = LoadProviders(path)
+	assert.ErrorContains(t, err, "required")
+}
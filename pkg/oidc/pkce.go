@@ -0,0 +1,37 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// PKCE is a code verifier with its S256 challenge (RFC 7636)
+type PKCE struct {
+	Verifier  string
+	Challenge string
+}
+
+// NewPKCE creates a random code verifier, the challenge is sent with the authorization request
+func NewPKCE() (PKCE, error) {
+	verifier, err := RandomString()
+	if err != nil {
+		return PKCE{}, err
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	return PKCE{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// RandomString returns 256 random bits, used for code verifiers, states and nonces
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,147 @@
+package onetime
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Purpose separates tokens issued for different flows, a password reset token never verifies an email
+type Purpose string
+
+// Store issues short lived single use tokens, e.g. for password reset links
+//
+// Tokens are random and only their HMAC is stored, so a cache dump cannot be turned into working links
+type Store interface {
+	// Issue creates a token for subject valid for ttl
+	Issue(ctx context.Context, purpose Purpose, subject string, ttl time.Duration) (string, error)
+
+	// Consume returns the subject of token and invalidates it, ErrInvalidToken when unknown, expired or used
+	Consume(ctx context.Context, purpose Purpose, token string) (string, error)
+
+	// Revoke invalidates every token of purpose issued for subject and not consumed yet
+	Revoke(ctx context.Context, purpose Purpose, subject string) error
+}
+
+// consumeScript reads and deletes a token in one step, only one of concurrent consumers gets the subject
+var consumeScript = cache.NewScript(`
+local subject = redis.call('GET', KEYS[1])
+if subject then
+	redis.call('DEL', KEYS[1])
+end
+return subject
+`, func(ctx context.Context, c cache.Cache, keys []string, args []interface{}) (interface{}, error) {
+	exists, err := c.Exists(ctx, keys[0])
+	if err != nil || !exists {
+		return nil, err
+	}
+
+	subject, err := c.Get(ctx, keys[0])
+	if err != nil {
+		return nil, err
+	}
+	return subject, c.Delete(ctx, keys[0])
+})
+
+// cacheStore implements Store on top of cache.Cache
+type cacheStore struct {
+	cache  cache.Cache
+	secret []byte
+	tokens *cache.CacheKey
+	issued *cache.CacheKey // Hash of the digests issued per subject, read by Revoke
+}
+
+// NewStore creates a token store, use a shared cache such as Redis when running several instances
+func NewStore(c cache.Cache, secret string) Store {
+	return &cacheStore{
+		cache:  c,
+		secret: []byte(secret),
+		tokens: cache.NewCacheKey("onetime"),
+		issued: cache.NewCacheKey("onetime:issued"),
+	}
+}
+
+func (s *cacheStore) Issue(ctx context.Context, purpose Purpose, subject string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	digest := s.digest(purpose, token)
+	if err := s.cache.Set(ctx, s.tokens.Build(string(purpose), digest), subject, ttl); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+
+	// The index lives as long as the newest token, tokens of one purpose share a lifetime
+	index := s.issued.Build(string(purpose), subject)
+	if err := s.cache.HSet(ctx, index, map[string]interface{}{digest: 1}); err != nil {
+		return "", fmt.Errorf("failed to index token: %w", err)
+	}
+	if err := s.cache.Expire(ctx, index, ttl); err != nil {
+		return "", fmt.Errorf("failed to index token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *cacheStore) Consume(ctx context.Context, purpose Purpose, token string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+
+	digest := s.digest(purpose, token)
+
+	res, err := s.cache.Eval(ctx, consumeScript, []string{s.tokens.Build(string(purpose), digest)})
+	if err != nil {
+		return "", fmt.Errorf("failed to consume token: %w", err)
+	}
+	subject, ok := res.(string)
+	if !ok {
+		return "", ErrInvalidToken
+	}
+
+	// A stale entry only makes Revoke delete a token that is already gone
+	_ = s.cache.HDel(ctx, s.issued.Build(string(purpose), subject), digest)
+
+	return subject, nil
+}
+
+func (s *cacheStore) Revoke(ctx context.Context, purpose Purpose, subject string) error {
+	index := s.issued.Build(string(purpose), subject)
+
+	digests, err := s.cache.HGetAll(ctx, index)
+	if err != nil {
+		return fmt.Errorf("failed to look up tokens: %w", err)
+	}
+
+	for digest := range digests {
+		if err := s.cache.Delete(ctx, s.tokens.Build(string(purpose), digest)); err != nil {
+			return fmt.Errorf("failed to invalidate token: %w", err)
+		}
+	}
+
+	if err := s.cache.Delete(ctx, index); err != nil {
+		return fmt.Errorf("failed to invalidate token: %w", err)
+	}
+	return nil
+}
+
+// digest signs token for purpose, the same token never matches another purpose
+func (s *cacheStore) digest(purpose Purpose, token string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,129 @@
+package onetime
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	purposeReset  Purpose = "password_reset"
+	purposeVerify Purpose = "email_verification"
+)
+
+func TestStore_SingleUse(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.NewMemoryCache(), "secret")
+
+	token, err := store.Issue(ctx, purposeReset, "42", time.Hour)
+	require.NoError(t, err)
+
+	subject, err := store.Consume(ctx, purposeReset, token)
+	require.NoError(t, err)
+	assert.Equal(t, "42", subject)
+
+	_, err = store.Consume(ctx, purposeReset, token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "tokens work once")
+}
+
+func TestStore_Rejects(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache()
+	store := NewStore(c, "secret")
+
+	token, err := store.Issue(ctx, purposeReset, "42", time.Hour)
+	require.NoError(t, err)
+
+	_, err = store.Consume(ctx, purposeVerify, token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "purpose is part of the token")
+
+	_, err = NewStore(c, "other-secret").Consume(ctx, purposeReset, token)
+	assert.ErrorIs(t, err, ErrInvalidToken, "secret is part of the token")
+
+	_, err = store.Consume(ctx, purposeReset, "")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// The raw token is never used as a key
+	keys, err := c.Keys(ctx, "*")
+	require.NoError(t, err)
+	for _, key := range keys {
+		assert.NotContains(t, key, token)
+	}
+}
+
+func TestStore_Revoke(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.NewMemoryCache(), "secret")
+
+	first, err := store.Issue(ctx, purposeReset, "42", time.Hour)
+	require.NoError(t, err)
+	second, err := store.Issue(ctx, purposeReset, "42", time.Hour)
+	require.NoError(t, err)
+	other, err := store.Issue(ctx, purposeReset, "7", time.Hour)
+	require.NoError(t, err)
+	verify, err := store.Issue(ctx, purposeVerify, "42", time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Revoke(ctx, purposeReset, "42"))
+
+	_, err = store.Consume(ctx, purposeReset, first)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = store.Consume(ctx, purposeReset, second)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	subject, err := store.Consume(ctx, purposeReset, other)
+	require.NoError(t, err, "other subjects keep their tokens")
+	assert.Equal(t, "7", subject)
+
+	subject, err = store.Consume(ctx, purposeVerify, verify)
+	require.NoError(t, err, "other purposes keep their tokens")
+	assert.Equal(t, "42", subject)
+
+	// Nothing issued is fine
+	assert.NoError(t, store.Revoke(ctx, purposeReset, "unknown"))
+}
+
+func TestStore_Expires(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.NewMemoryCache(), "secret")
+
+	token, err := store.Issue(ctx, purposeReset, "42", 50*time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	_, err = store.Consume(ctx, purposeReset, token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestStore_ConcurrentConsume(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore(cache.NewMemoryCache(), "secret")
+
+	token, err := store.Issue(ctx, purposeReset, "42", time.Hour)
+	require.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.Consume(ctx, purposeReset, token); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, successes)
+}
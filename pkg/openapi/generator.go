@@ -0,0 +1,145 @@
+package openapi
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Generator builds a Document, collecting struct schemas as reusable components
+type Generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+}
+
+// NewGenerator creates a generator for an empty document
+func NewGenerator(info Info) *Generator {
+	return &Generator{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas:         map[string]*Schema{},
+				SecuritySchemes: map[string]*SecurityScheme{},
+			},
+		},
+		names: map[reflect.Type]string{},
+	}
+}
+
+// AddServer adds a base URL the API is served from
+func (g *Generator) AddServer(server Server) {
+	g.doc.Servers = append(g.doc.Servers, server)
+}
+
+// AddSecurityScheme registers an authentication method operations can require
+func (g *Generator) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	g.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// AddComponent registers a schema under an explicit name, e.g. a shared response envelope
+func (g *Generator) AddComponent(name string, schema *Schema) *Schema {
+	g.doc.Components.Schemas[name] = schema
+	return Ref(name)
+}
+
+// AddOperation adds an operation, path may use Fiber style :params
+func (g *Generator) AddOperation(method string, routePath string, op *Operation) {
+	p := Path(routePath)
+	if g.doc.Paths[p] == nil {
+		g.doc.Paths[p] = PathItem{}
+	}
+	g.doc.Paths[p][strings.ToLower(method)] = op
+}
+
+// Parameters returns the path, query and header parameters declared by a request struct
+// Path params of routePath not declared by the struct are added as plain strings
+func (g *Generator) Parameters(routePath string, t reflect.Type) []Parameter {
+	var params []Parameter
+	declared := map[string]bool{}
+
+	if t != nil {
+		for _, f := range g.fields(t) {
+			if f.source == "body" {
+				continue
+			}
+
+			declared[f.name] = true
+			params = append(params, Parameter{
+				Name:     f.name,
+				In:       f.source,
+				Required: f.required || f.source == "path",
+				Schema:   f.schema,
+			})
+		}
+	}
+
+	for _, name := range pathParams(routePath) {
+		if declared[name] {
+			continue
+		}
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	return params
+}
+
+// Body returns the JSON body schema of a request struct, or nil when every field is bound elsewhere
+func (g *Generator) Body(t reflect.Type) *Schema {
+	for _, f := range g.fields(t) {
+		if f.source == "body" {
+			return g.Schema(t)
+		}
+	}
+	return nil
+}
+
+// Document returns the generated document
+func (g *Generator) Document() *Document {
+	return g.doc
+}
+
+var fiberParam = regexp.MustCompile(`:([A-Za-z0-9_]+)[?+*]?`)
+
+// Path converts a Fiber route path to an OpenAPI path template, /users/:id becomes /users/{id}
+func Path(routePath string) string {
+	return fiberParam.ReplaceAllString(routePath, "{$1}")
+}
+
+func pathParams(routePath string) []string {
+	var names []string
+	for _, m := range fiberParam.FindAllStringSubmatch(routePath, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+var invalidName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// componentName names a component after its type, qualified by package when two types share a name
+func (g *Generator) componentName(t reflect.Type) string {
+	name := invalidName.ReplaceAllString(t.Name(), "_")
+	if !g.taken(name) {
+		return name
+	}
+
+	qualified := path.Base(t.PkgPath()) + "." + name
+	candidate := qualified
+	for i := 2; g.taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s%d", qualified, i)
+	}
+	return candidate
+}
+
+func (g *Generator) taken(name string) bool {
+	_, ok := g.doc.Components.Schemas[name]
+	return ok
+}
@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type createOrderRequest struct {
+	StoreID  int64     `params:"store_id" validate:"required"`
+	DryRun   bool      `query:"dry_run"`
+	Tenant   string    `reqHeader:"X-Tenant-ID" validate:"required"`
+	Email    string    `json:"email" validate:"required,email"`
+	Quantity int       `json:"quantity" validate:"required,gt=0,lte=100"`
+	Note     string    `json:"note,omitempty" validate:"omitempty,max=140"`
+	Status   string    `json:"status" validate:"oneof=draft placed"`
+	Tags     []string  `json:"tags" validate:"min=1"`
+	DueAt    time.Time `json:"due_at"`
+	Address  address   `json:"address" validate:"required"`
+	Internal string    `json:"-"`
+}
+
+func TestGenerator_RequestSchema(t *testing.T) {
+	g := NewGenerator(Info{Title: "test", Version: "v1"})
+	reqType := reflect.TypeOf(createOrderRequest{})
+
+	body := g.Body(reqType)
+	require.NotNil(t, body)
+	assert.Equal(t, "#/components/schemas/createOrderRequest", body.Ref)
+
+	schema := g.Document().Components.Schemas["createOrderRequest"]
+	require.NotNil(t, schema)
+	assert.ElementsMatch(t, []string{"email", "quantity", "address"}, schema.Required)
+	assert.NotContains(t, schema.Properties, "store_id")
+	assert.NotContains(t, schema.Properties, "Internal")
+
+	assert.Equal(t, "email", schema.Properties["email"].Format)
+	assert.Equal(t, 0.0, *schema.Properties["quantity"].ExclusiveMinimum)
+	assert.Equal(t, 100.0, *schema.Properties["quantity"].Maximum)
+	assert.Equal(t, 140, *schema.Properties["note"].MaxLength)
+	assert.Equal(t, []interface{}{"draft", "placed"}, schema.Properties["status"].Enum)
+	assert.Equal(t, 1, *schema.Properties["tags"].MinItems)
+	assert.Equal(t, "date-time", schema.Properties["due_at"].Format)
+	assert.Equal(t, "#/components/schemas/address", schema.Properties["address"].Ref)
+	assert.Equal(t, []string{"city"}, g.Document().Components.Schemas["address"].Required)
+}
+
+func TestGenerator_Parameters(t *testing.T) {
+	g := NewGenerator(Info{Title: "test", Version: "v1"})
+
+	params := g.Parameters("/stores/:store_id/orders/:order_id", reflect.TypeOf(createOrderRequest{}))
+
+	byName := map[string]Parameter{}
+	for _, p := range params {
+		byName[p.Name] = p
+	}
+
+	require.Len(t, params, 4)
+	assert.Equal(t, "path", byName["store_id"].In)
+	assert.Equal(t, "integer", byName["store_id"].Schema.Type)
+	assert.Equal(t, "query", byName["dry_run"].In)
+	assert.False(t, byName["dry_run"].Required)
+	assert.Equal(t, "header", byName["X-Tenant-ID"].In)
+	assert.True(t, byName["X-Tenant-ID"].Required)
+
+	// Undeclared path params are still documented
+	assert.Equal(t, "string", byName["order_id"].Schema.Type)
+	assert.True(t, byName["order_id"].Required)
+}
+
+func TestPath(t *testing.T) {
+	assert.Equal(t, "/users/{id}", Path("/users/:id"))
+	assert.Equal(t, "/files/{name}/versions/{version}", Path("/files/:name/versions/:version?"))
+	assert.Equal(t, "/health", Path("/health"))
+}
@@ -0,0 +1,329 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema 2020-12 object as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// Struct tags that move a field out of the JSON body, the same tags pkg/binding reads
+const (
+	tagQuery  = "query"
+	tagParams = "params"
+	tagHeader = "reqHeader"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	rawJSONType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// Ref returns a schema referencing a named component
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// field is a struct field resolved against its tags
+type field struct {
+	name     string
+	source   string // "body", "path", "query" or "header"
+	required bool
+	schema   *Schema
+}
+
+// Schema returns the schema for t, named struct types are registered as components and referenced
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == durationType:
+		return &Schema{Type: "string", Description: "Go duration, e.g. 1h30m"}
+	case t == rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		// interface{} and anything else accepts any value
+		return &Schema{}
+	}
+}
+
+// structRef registers a struct as a component once and references it
+func (g *Generator) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return g.objectSchema(t, "body")
+	}
+
+	if name, ok := g.names[t]; ok {
+		return Ref(name)
+	}
+
+	name := g.componentName(t)
+	g.names[t] = name
+
+	// Reserve the name before recursing so self referencing types terminate
+	g.doc.Components.Schemas[name] = &Schema{}
+	*g.doc.Components.Schemas[name] = *g.objectSchema(t, "body")
+
+	return Ref(name)
+}
+
+// objectSchema builds an inline object schema from the fields bound from source
+func (g *Generator) objectSchema(t reflect.Type, source string) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for _, f := range g.fields(t) {
+		if f.source != source {
+			continue
+		}
+
+		schema.Properties[f.name] = f.schema
+		if f.required {
+			schema.Required = append(schema.Required, f.name)
+		}
+	}
+
+	return schema
+}
+
+// fields resolves the exported fields of a struct, flattening embedded structs like encoding/json
+func (g *Generator) fields(t reflect.Type) []field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		if sf.Anonymous && sf.Tag.Get("json") == "" {
+			fields = append(fields, g.fields(sf.Type)...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		f := field{source: "body"}
+		switch {
+		case tagName(sf, tagParams) != "":
+			f.source, f.name = "path", tagName(sf, tagParams)
+		case tagName(sf, tagQuery) != "":
+			f.source, f.name = "query", tagName(sf, tagQuery)
+		case tagName(sf, tagHeader) != "":
+			f.source, f.name = "header", tagName(sf, tagHeader)
+		default:
+			jsonTag := sf.Tag.Get("json")
+			if jsonTag == "-" {
+				continue
+			}
+			f.name = tagName(sf, "json")
+			if f.name == "" {
+				f.name = sf.Name
+			}
+		}
+
+		f.schema = g.Schema(sf.Type)
+		f.required = applyValidate(f.schema, sf.Type, sf.Tag.Get("validate"))
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+func tagName(sf reflect.StructField, tag string) string {
+	name := strings.Split(sf.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// applyValidate translates go-playground/validator rules into schema keywords
+// and reports whether the field is required
+func applyValidate(schema *Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Constraints cannot be added next to a $ref in every tool, keep the reference untouched
+	constrainable := schema.Ref == ""
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+
+		// Rules after dive apply to elements, which are documented by their own schema
+		if name == "dive" {
+			break
+		}
+
+		if name == "required" {
+			required = true
+		}
+
+		if !constrainable {
+			continue
+		}
+
+		switch name {
+		case "email":
+			schema.Format = "email"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, enumValue(t, v))
+			}
+		case "len":
+			setBound(schema, t, param, true, true)
+		case "min":
+			setBound(schema, t, param, true, false)
+		case "max":
+			setBound(schema, t, param, false, true)
+		case "gte":
+			if param != "" && isNumber(t) {
+				schema.Minimum = parseFloat(param)
+			}
+		case "lte":
+			if param != "" && isNumber(t) {
+				schema.Maximum = parseFloat(param)
+			}
+		case "gt":
+			if param != "" && isNumber(t) {
+				schema.ExclusiveMinimum = parseFloat(param)
+			}
+		case "lt":
+			if param != "" && isNumber(t) {
+				schema.ExclusiveMaximum = parseFloat(param)
+			}
+		}
+	}
+
+	return required
+}
+
+// setBound applies min/max/len, which mean length for strings, item count for slices and value for numbers
+func setBound(schema *Schema, t reflect.Type, param string, lower, upper bool) {
+	switch {
+	case t.Kind() == reflect.String:
+		n := parseInt(param)
+		if lower {
+			schema.MinLength = n
+		}
+		if upper {
+			schema.MaxLength = n
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map:
+		n := parseInt(param)
+		if lower {
+			schema.MinItems = n
+		}
+		if upper {
+			schema.MaxItems = n
+		}
+	case isNumber(t):
+		f := parseFloat(param)
+		if lower {
+			schema.Minimum = f
+		}
+		if upper {
+			schema.Maximum = f
+		}
+	}
+}
+
+func isNumber(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func enumValue(t reflect.Type, v string) interface{} {
+	if isNumber(t) {
+		if f := parseFloat(v); f != nil {
+			return *f
+		}
+	}
+	return v
+}
+
+func parseFloat(s string) *float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+func parseInt(s string) *int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
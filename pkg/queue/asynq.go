@@ -117,6 +117,11 @@ func (q *asynqClient) EnqueueWithOptions(ctx context.Context, jobType string, pa
 	return nil
 }
 
+// Ping checks if the Redis broker is reachable
+func (q *asynqClient) Ping(ctx context.Context) error {
+	return q.client.Ping()
+}
+
 // Close closes the Asynq client
 func (q *asynqClient) Close() error {
 	return q.client.Close()
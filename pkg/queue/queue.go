@@ -20,6 +20,9 @@ type Queue interface {
 	// EnqueueWithOptions enqueues a job with custom options
 	EnqueueWithOptions(ctx context.Context, jobType string, payload interface{}, opts *EnqueueOptions) error
 
+	// Ping checks if the queue broker is reachable
+	Ping(ctx context.Context) error
+
 	// Close closes the queue client
 	Close() error
 }
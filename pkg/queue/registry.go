@@ -2,7 +2,11 @@ package queue
 
 import (
 	"context"
+	"fmt"
 	"sync"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/apperror"
+	"github.com/hibiken/asynq"
 )
 
 // jobRegistry implements JobRegistry interface
@@ -46,11 +50,17 @@ func NewAsynqServer(registry JobRegistry) *asynqServer {
 }
 
 // ProcessTask processes a task by delegating to registered handler
+// Errors apperror reports as not retryable skip the remaining retries and go straight to the archive
 func (s *asynqServer) ProcessTask(ctx context.Context, jobType string, payload []byte) error {
 	handler, exists := s.registry.Get(jobType)
 	if !exists {
 		return nil // Skip unknown jobs
 	}
 
-	return handler(ctx, payload)
+	err := handler(ctx, payload)
+	if err != nil && !apperror.Retryable(err) {
+		return fmt.Errorf("%w: %w", err, asynq.SkipRetry)
+	}
+
+	return err
 }
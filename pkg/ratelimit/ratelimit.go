@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+)
+
+const (
+	// AlgorithmSlidingWindow approximates a rolling window using the weighted
+	// counts of the current and previous fixed windows
+	AlgorithmSlidingWindow = "sliding_window"
+
+	// AlgorithmTokenBucket refills tokens at a constant rate up to a burst capacity
+	AlgorithmTokenBucket = "token_bucket"
+)
+
+var (
+	ErrInvalidLimit  = errors.New("limit must be greater than zero")
+	ErrInvalidWindow = errors.New("window must be greater than zero")
+)
+
+// Limiter decides whether a request identified by key may proceed
+type Limiter interface {
+	// Allow consumes one unit of quota for key and reports the outcome
+	Allow(ctx context.Context, key string) (*Result, error)
+}
+
+// Result holds the outcome of a single Allow call
+type Result struct {
+	Allowed    bool          // Whether the request is within the limit
+	Limit      int           // Maximum requests per window (or bucket capacity)
+	Remaining  int           // Requests left before the limit is reached
+	ResetAfter time.Duration // Time until the quota is fully restored
+	RetryAfter time.Duration // Time to wait before retrying, zero when allowed
+}
+
+// Config holds rate limiter configuration
+type Config struct {
+	Algorithm string        // sliding_window (default), token_bucket
+	Limit     int           // Maximum requests per window
+	Window    time.Duration // Window size (refill period for token bucket)
+	Burst     int           // Token bucket capacity, defaults to Limit
+	Prefix    string        // Cache key prefix, defaults to "ratelimit"
+}
+
+// New creates a Limiter backed by the given cache store
+func New(store cache.Cache, config Config) (Limiter, error) {
+	if config.Limit <= 0 {
+		return nil, ErrInvalidLimit
+	}
+
+	if config.Window <= 0 {
+		return nil, ErrInvalidWindow
+	}
+
+	if config.Prefix == "" {
+		config.Prefix = "ratelimit"
+	}
+
+	switch config.Algorithm {
+	case AlgorithmTokenBucket:
+		if config.Burst <= 0 {
+			config.Burst = config.Limit
+		}
+		return newTokenBucket(store, config), nil
+	default:
+		return newSlidingWindow(store, config), nil
+	}
+}
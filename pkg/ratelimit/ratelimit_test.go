@@ -0,0 +1,135 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindow_AllowsUpToLimit(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newSlidingWindow(store, Config{Limit: 3, Window: time.Minute, Prefix: "test"})
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		res, err := limiter.Allow(ctx, "client")
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2-i, res.Remaining)
+	}
+
+	res, err := limiter.Allow(ctx, "client")
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, time.Minute, res.RetryAfter)
+
+	// Other keys are unaffected
+	res, err = limiter.Allow(ctx, "other")
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestSlidingWindow_WeightsPreviousWindow(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newSlidingWindow(store, Config{Limit: 4, Window: time.Minute, Prefix: "test"})
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		_, err := limiter.Allow(ctx, "client")
+		require.NoError(t, err)
+	}
+
+	// Halfway into the next window the previous 4 requests count as 2
+	now = now.Add(90 * time.Second)
+	for i := 0; i < 2; i++ {
+		res, err := limiter.Allow(ctx, "client")
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := limiter.Allow(ctx, "client")
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Greater(t, res.RetryAfter, time.Duration(0))
+}
+
+func TestTokenBucket_RefillsOverTime(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTokenBucket(store, Config{Limit: 60, Window: time.Minute, Burst: 2, Prefix: "test"})
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		res, err := limiter.Allow(ctx, "client")
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+	}
+
+	res, err := limiter.Allow(ctx, "client")
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	// One token per second at 60 per minute
+	now = now.Add(time.Second)
+	res, err = limiter.Allow(ctx, "client")
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+}
+
+func TestTokenBucket_ConcurrentRequestsShareTokens(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newTokenBucket(store, Config{Limit: 60, Window: time.Minute, Burst: 5, Prefix: "test"})
+	limiter.now = func() time.Time { return now }
+
+	// Each token is spent once, however the requests interleave
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := limiter.Allow(context.Background(), "client")
+			assert.NoError(t, err)
+			if err == nil && res.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(5), allowed.Load())
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	store := cache.NewMemoryCache()
+	defer store.Close()
+
+	_, err := New(store, Config{Limit: 0, Window: time.Minute})
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+
+	_, err = New(store, Config{Limit: 1})
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+}
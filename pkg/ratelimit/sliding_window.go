@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+)
+
+// slidingWindow implements Limiter using the sliding window counter algorithm.
+// Each fixed window is a counter updated with atomic IncrByWithExpiry, so it is safe
+// across instances sharing the same Redis and never left without an expiry.
+type slidingWindow struct {
+	store  cache.Cache
+	config Config
+	now    func() time.Time
+}
+
+func newSlidingWindow(store cache.Cache, config Config) *slidingWindow {
+	return &slidingWindow{
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Allow consumes one request from the current window
+func (s *slidingWindow) Allow(ctx context.Context, key string) (*Result, error) {
+	window := s.config.Window
+	now := s.now()
+
+	currentStart := now.Truncate(window)
+	elapsed := now.Sub(currentStart)
+
+	currentKey := s.windowKey(key, currentStart)
+	previousKey := s.windowKey(key, currentStart.Add(-window))
+
+	// The counter must outlive its own window so it can be weighted as the previous one
+	current, err := s.store.IncrByWithExpiry(ctx, currentKey, 1, 2*window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to increment window counter: %w", err)
+	}
+
+	previous := s.previousCount(ctx, previousKey)
+
+	weight := float64(window-elapsed) / float64(window)
+	estimated := float64(previous)*weight + float64(current)
+	limit := float64(s.config.Limit)
+
+	result := &Result{
+		Limit:      s.config.Limit,
+		ResetAfter: window - elapsed,
+	}
+
+	if estimated <= limit {
+		result.Allowed = true
+		result.Remaining = int(math.Max(0, math.Floor(limit-estimated)))
+		return result, nil
+	}
+
+	// Rejected requests do not consume quota
+	if _, err := s.store.Decrement(ctx, currentKey); err != nil {
+		return nil, fmt.Errorf("failed to decrement window counter: %w", err)
+	}
+	current--
+
+	result.RetryAfter = s.retryAfter(previous, current, elapsed)
+	return result, nil
+}
+
+// retryAfter estimates when the weighted count drops enough to admit one more request
+func (s *slidingWindow) retryAfter(previous, current int64, elapsed time.Duration) time.Duration {
+	window := s.config.Window
+	free := float64(s.config.Limit) - float64(current) - 1
+
+	// The current window alone is exhausted, wait for it to become the previous one
+	if free < 0 || previous == 0 {
+		return window - elapsed
+	}
+
+	// Solve previous*(window-t)/window <= free for t
+	target := time.Duration(float64(window) * (1 - free/float64(previous)))
+	if target <= elapsed {
+		return time.Second
+	}
+
+	return target - elapsed
+}
+
+// previousCount reads the previous window counter, treating a missing key as zero
+func (s *slidingWindow) previousCount(ctx context.Context, key string) int64 {
+	val, err := s.store.Get(ctx, key)
+	if err != nil {
+		return 0
+	}
+
+	count, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+func (s *slidingWindow) windowKey(key string, start time.Time) string {
+	return cache.NewCacheKey(s.config.Prefix).Build("sw", key, strconv.FormatInt(start.UnixNano(), 10))
+}
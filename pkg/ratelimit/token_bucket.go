@@ -0,0 +1,167 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/cache"
+)
+
+// takeScript refills the bucket, takes a token if one is left and saves the state, all in one step
+// so concurrent requests, from this or another instance, never spend the same token
+// ARGV: capacity, tokens per second, now in unix nanoseconds, window in milliseconds
+// Returns {1 when allowed, tokens left}, tokens as a string since Redis truncates numbers to integers
+var takeScript = cache.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local tokens, last = capacity, now
+local state = redis.call('GET', KEYS[1])
+if state then
+	local t, l = string.match(state, '^([^:]+):(%d+)$')
+	if t and l then
+		tokens, last = tonumber(t), tonumber(l)
+	end
+end
+
+-- Clocks of instances drift, a bucket saved in the future refills from now
+if last < now then
+	tokens = math.min(capacity, tokens + (now - last) / 1e9 * rate)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+-- Keep idle buckets around only as long as they need to refill
+local expiry = math.ceil((capacity - tokens) / rate * 1000) + tonumber(ARGV[4])
+redis.call('SET', KEYS[1], string.format('%.17g', tokens) .. ':' .. ARGV[3], 'PX', expiry)
+
+return {allowed, string.format('%.17g', tokens)}
+`, func(ctx context.Context, c cache.Cache, keys []string, args []interface{}) (interface{}, error) {
+	capacity, rate := args[0].(float64), args[1].(float64)
+	now, window := args[2].(int64), args[3].(int64)
+
+	tokens, last := capacity, now
+	if state, err := c.Get(ctx, keys[0]); err == nil {
+		tokens, last = parseBucket(state, capacity, now)
+	}
+
+	if last < now {
+		tokens = math.Min(capacity, tokens+float64(now-last)/1e9*rate)
+	}
+
+	allowed := int64(0)
+	if tokens >= 1 {
+		tokens--
+		allowed = 1
+	}
+
+	expiry := time.Duration(math.Ceil((capacity-tokens)/rate*1000)+float64(window)) * time.Millisecond
+	state := strconv.FormatFloat(tokens, 'g', 17, 64) + ":" + strconv.FormatInt(now, 10)
+	if err := c.Set(ctx, keys[0], state, expiry); err != nil {
+		return nil, err
+	}
+
+	return []interface{}{allowed, strconv.FormatFloat(tokens, 'g', 17, 64)}, nil
+})
+
+// tokenBucket implements Limiter using the token bucket algorithm.
+// Bucket state is stored as "tokens:unixnano" and updated by takeScript, atomically on Redis and memory alike.
+type tokenBucket struct {
+	store  cache.Cache
+	config Config
+	rate   float64 // tokens per second
+	now    func() time.Time
+}
+
+func newTokenBucket(store cache.Cache, config Config) *tokenBucket {
+	return &tokenBucket{
+		store:  store,
+		config: config,
+		rate:   float64(config.Limit) / config.Window.Seconds(),
+		now:    time.Now,
+	}
+}
+
+// Allow takes one token from the bucket if available
+func (b *tokenBucket) Allow(ctx context.Context, key string) (*Result, error) {
+	bucketKey := cache.NewCacheKey(b.config.Prefix).Build("tb", key)
+	capacity := float64(b.config.Burst)
+
+	res, err := b.store.Eval(ctx, takeScript, []string{bucketKey},
+		capacity, b.rate, b.now().UnixNano(), b.config.Window.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update bucket: %w", err)
+	}
+
+	allowed, tokens, err := parseTake(res)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Limit: b.config.Burst, Allowed: allowed}
+	if !allowed {
+		result.RetryAfter = b.secondsToDuration((1 - tokens) / b.rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = b.secondsToDuration((capacity - tokens) / b.rate)
+
+	return result, nil
+}
+
+// parseBucket reads bucket state, starting with a full bucket when it is malformed
+func parseBucket(state string, capacity float64, now int64) (float64, int64) {
+	parts := strings.SplitN(state, ":", 2)
+	if len(parts) != 2 {
+		return capacity, now
+	}
+
+	tokens, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return capacity, now
+	}
+
+	last, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return capacity, now
+	}
+
+	return tokens, last
+}
+
+// parseTake reads the {allowed, tokens} reply of takeScript
+func parseTake(res interface{}) (bool, float64, error) {
+	reply, ok := res.([]interface{})
+	if !ok || len(reply) != 2 {
+		return false, 0, fmt.Errorf("unexpected bucket reply: %v", res)
+	}
+
+	allowed, ok := reply[0].(int64)
+	if !ok {
+		return false, 0, fmt.Errorf("unexpected bucket reply: %v", res)
+	}
+
+	remaining, ok := reply[1].(string)
+	if !ok {
+		return false, 0, fmt.Errorf("unexpected bucket reply: %v", res)
+	}
+
+	tokens, err := strconv.ParseFloat(remaining, 64)
+	if err != nil {
+		return false, 0, fmt.Errorf("unexpected bucket reply: %w", err)
+	}
+
+	return allowed == 1, tokens, nil
+}
+
+func (b *tokenBucket) secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
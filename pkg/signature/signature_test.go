@@ -0,0 +1,102 @@
+package signature
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func headerFunc(headers map[string]string) func(string) string {
+	h := http.Header{}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	return h.Get
+}
+
+func TestRoundTrip(t *testing.T) {
+	req := Request{
+		Method:    "POST",
+		Path:      "/webhooks/orders",
+		Timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		Nonce:     "delivery-1",
+		Body:      []byte(`{"id":1}`),
+	}
+
+	for name, scheme := range map[string]Scheme{"default": Default, "github": GitHub, "stripe": Stripe} {
+		t.Run(name, func(t *testing.T) {
+			signed, err := scheme.Parse(headerFunc(scheme.Headers("new-secret", req)))
+			require.NoError(t, err)
+
+			received := req
+			received.Timestamp = signed.Timestamp
+			received.Nonce = signed.Nonce
+
+			assert.True(t, scheme.Verify(received, signed.Signatures, []string{"old-secret", "new-secret"}), "any active secret is accepted")
+			assert.False(t, scheme.Verify(received, signed.Signatures, []string{"old-secret"}))
+
+			tampered := received
+			tampered.Body = []byte(`{"id":2}`)
+			assert.False(t, scheme.Verify(tampered, signed.Signatures, []string{"new-secret"}))
+		})
+	}
+}
+
+func TestDefaultIsCompatible(t *testing.T) {
+	// Without a nonce the payload is the one HMACAuth signed before nonces existed
+	req := Request{Method: "POST", Path: "/campaigns", Timestamp: "1700000000", Body: []byte(`{"name":"test"}`)}
+	assert.Equal(t, `POST/campaigns1700000000{"name":"test"}`, string(Default.Canonical(req)))
+}
+
+func TestReplayKey(t *testing.T) {
+	req := Request{Method: "POST", Path: "/webhooks", Timestamp: "1700000000", Nonce: "a", Body: []byte(`{"id":1}`)}
+
+	// Default signs the nonce, a new nonce is a new request
+	other := req
+	other.Nonce = "b"
+	assert.NotEqual(t, Default.ReplayKey(req), Default.ReplayKey(other))
+
+	// GitHub does not, a resent body is the same request whatever its delivery id
+	assert.Equal(t, GitHub.ReplayKey(req), GitHub.ReplayKey(other))
+}
+
+func TestStripeHeader(t *testing.T) {
+	// Several v1 signatures are sent while Stripe rolls a secret
+	signed, err := Stripe.Parse(headerFunc(map[string]string{
+		"Stripe-Signature": "t=1492774577,v1=aaa,v1=bbb,v0=ccc",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "1492774577", signed.Timestamp)
+	assert.Equal(t, []string{"aaa", "bbb"}, signed.Signatures)
+
+	_, err = Stripe.Parse(headerFunc(map[string]string{"Stripe-Signature": "v1=aaa"}))
+	assert.ErrorIs(t, err, ErrMissingTimestamp)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Default.Parse(headerFunc(nil))
+	assert.ErrorIs(t, err, ErrMissingSignature)
+
+	_, err = Default.Parse(headerFunc(map[string]string{"X-Signature": "abc"}))
+	assert.ErrorIs(t, err, ErrMissingTimestamp)
+
+	_, err = GitHub.Parse(headerFunc(map[string]string{"X-Hub-Signature-256": "sha1=abc"}))
+	assert.ErrorIs(t, err, ErrMissingSignature, "the prefix is required")
+}
+
+func TestCheckTimestamp(t *testing.T) {
+	now := time.Now()
+	ts := func(d time.Duration) string {
+		return strconv.FormatInt(now.Add(d).Unix(), 10)
+	}
+
+	assert.NoError(t, CheckTimestamp(ts(-4*time.Minute), now, 5*time.Minute))
+	assert.NoError(t, CheckTimestamp(ts(4*time.Minute), now, 5*time.Minute))
+	assert.ErrorIs(t, CheckTimestamp(ts(-6*time.Minute), now, 5*time.Minute), ErrTimestampExpired)
+	assert.ErrorIs(t, CheckTimestamp(ts(6*time.Minute), now, 5*time.Minute), ErrTimestampExpired)
+	assert.ErrorIs(t, CheckTimestamp("yesterday", now, 5*time.Minute), ErrInvalidTimestamp)
+}
@@ -0,0 +1,345 @@
+package sqlbuilder
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrNoSortKeys    = errors.New("cursor pagination requires at least one sort key")
+)
+
+// SortKey is a column keyset pagination orders by
+// Columns must be NOT NULL and the last key must be unique, e.g. the primary key, so rows never tie
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Asc orders by column ascending
+func Asc(column string) SortKey {
+	return SortKey{Column: column}
+}
+
+// Desc orders by column descending
+func Desc(column string) SortKey {
+	return SortKey{Column: column, Desc: true}
+}
+
+// Cursor is a position in a keyset ordered result, the sort key values of the row it points past
+type Cursor struct {
+	Keys     string        `json:"k"` // Sort keys the cursor was created for
+	Values   []interface{} `json:"v"`
+	Backward bool          `json:"b,omitempty"` // Page towards the start, set on prev cursors
+}
+
+// CursorPage holds the cursors around a fetched page
+type CursorPage struct {
+	Next  *Cursor // nil on the last page
+	Prev  *Cursor // nil on the first page
+	Limit int
+}
+
+// CursorResult is the client facing form of a CursorPage with opaque tokens
+type CursorResult struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	Limit      int    `json:"limit"`
+}
+
+// GetWithCursor executes the query with keyset pagination instead of OFFSET, a nil cursor fetches the first page
+// Keys replace any ORDER BY on the query. Unlike GetWithPagination no COUNT query is run, so deep pages
+// cost the same as the first one
+func (m *Model) GetWithCursor(ctx context.Context, dest interface{}, cursor *Cursor, limit int, keys ...SortKey) (*CursorPage, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSortKeys
+	}
+
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return nil, fmt.Errorf("destination must be a pointer to a slice")
+	}
+
+	signature := keysSignature(keys)
+	if cursor != nil && (cursor.Keys != signature || len(cursor.Values) != len(keys)) {
+		return nil, ErrInvalidCursor
+	}
+
+	if limit < 1 {
+		limit = 10
+	}
+
+	backward := cursor != nil && cursor.Backward
+
+	if cursor != nil {
+		condition, args := keysetCondition(keys, cursor.Values, backward)
+		m.builder.Where(condition, args...)
+	}
+
+	// Walk backwards by flipping the order, rows are reversed again after fetching
+	m.builder.orderBy = []string{}
+	for _, key := range keys {
+		dir := "ASC"
+		if key.Desc != backward {
+			dir = "DESC"
+		}
+		m.builder.OrderBy(key.Column, dir)
+	}
+
+	// One extra row tells whether another page exists
+	m.builder.Limit(limit + 1)
+
+	if err := m.GetAll(ctx, dest); err != nil {
+		return nil, fmt.Errorf("failed to get data: %w", err)
+	}
+
+	rows := rv.Elem()
+	hasMore := rows.Len() > limit
+	if hasMore {
+		rows.Set(rows.Slice(0, limit))
+	}
+	if backward {
+		reverse(rows)
+	}
+
+	page := &CursorPage{Limit: limit}
+	if rows.Len() == 0 {
+		return page, nil
+	}
+
+	first, err := cursorAt(rows.Index(0), keys, signature, true)
+	if err != nil {
+		return nil, err
+	}
+	last, err := cursorAt(rows.Index(rows.Len()-1), keys, signature, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if backward {
+		// Coming back from a later page, so there always is a next page
+		page.Next = last
+		if hasMore {
+			page.Prev = first
+		}
+	} else {
+		if hasMore {
+			page.Next = last
+		}
+		if cursor != nil {
+			page.Prev = first
+		}
+	}
+
+	return page, nil
+}
+
+// keysetCondition builds (k1 > ?) OR (k1 = ? AND k2 > ?) ..., which works with mixed directions on every driver
+func keysetCondition(keys []SortKey, values []interface{}, backward bool) (string, []interface{}) {
+	clauses := make([]string, 0, len(keys))
+	args := []interface{}{}
+
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = ?", keys[j].Column))
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if key.Desc != backward {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", key.Column, op))
+		args = append(args, values[i])
+
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(clauses, " OR ") + ")", args
+}
+
+func keysSignature(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		dir := "asc"
+		if key.Desc {
+			dir = "desc"
+		}
+		parts[i] = key.Column + ":" + dir
+	}
+	return strings.Join(parts, ",")
+}
+
+// cursorAt reads the sort key values of a row, table qualified columns are matched by their db tag
+func cursorAt(row reflect.Value, keys []SortKey, signature string, backward bool) (*Cursor, error) {
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		column := key.Column
+		if idx := strings.LastIndex(column, "."); idx >= 0 {
+			column = column[idx+1:]
+		}
+
+		value, err := GetColumnValue(row.Interface(), column)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sort key: %w", err)
+		}
+		values[i] = value
+	}
+
+	return &Cursor{Keys: signature, Values: values, Backward: backward}, nil
+}
+
+func reverse(rows reflect.Value) {
+	swap := reflect.Swapper(rows.Interface())
+	for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
+
+// CursorCodec turns cursors into opaque tokens signed with HMAC-SHA256, so clients cannot forge positions
+type CursorCodec interface {
+	// Encode returns "" for a nil cursor
+	Encode(cursor *Cursor) (string, error)
+	// Decode returns nil for an empty token and ErrInvalidCursor for tampered or malformed tokens
+	Decode(token string) (*Cursor, error)
+}
+
+type cursorCodec struct {
+	secret []byte
+}
+
+// cursorPayload is the signed form of a cursor, Times lists the values that were time.Time
+// JSON carries them as RFC 3339 strings, they are parsed back so drivers compare them as timestamps
+type cursorPayload struct {
+	Cursor
+	Times []int `json:"t,omitempty"`
+}
+
+// NewCursorCodec creates a codec signing tokens with secret
+func NewCursorCodec(secret string) CursorCodec {
+	return &cursorCodec{
+		secret: []byte(secret),
+	}
+}
+
+func (c *cursorCodec) Encode(cursor *Cursor) (string, error) {
+	if cursor == nil {
+		return "", nil
+	}
+
+	wire := cursorPayload{Cursor: *cursor}
+	wire.Values = make([]interface{}, len(cursor.Values))
+	for i, v := range cursor.Values {
+		if t, ok := v.(*time.Time); ok && t != nil {
+			v = *t
+		}
+		if _, ok := v.(time.Time); ok {
+			wire.Times = append(wire.Times, i)
+		}
+		wire.Values[i] = v
+	}
+
+	payload, err := json.Marshal(wire)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+func (c *cursorCodec) Decode(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if !hmac.Equal(sig, c.sign(payload)) {
+		return nil, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var wire cursorPayload
+	if err := decoder.Decode(&wire); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := wire.Cursor
+
+	// Keep integer keys integers, JSON would otherwise turn them into floats
+	for i, v := range cursor.Values {
+		if n, ok := v.(json.Number); ok {
+			if iv, err := n.Int64(); err == nil {
+				cursor.Values[i] = iv
+			} else if fv, err := n.Float64(); err == nil {
+				cursor.Values[i] = fv
+			}
+		}
+	}
+
+	for _, i := range wire.Times {
+		if i < 0 || i >= len(cursor.Values) {
+			return nil, ErrInvalidCursor
+		}
+		s, ok := cursor.Values[i].(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		cursor.Values[i] = t
+	}
+
+	return &cursor, nil
+}
+
+func (c *cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// NewCursorResult encodes the cursors of a page into tokens
+func NewCursorResult(codec CursorCodec, page *CursorPage) (*CursorResult, error) {
+	next, err := codec.Encode(page.Next)
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := codec.Encode(page.Prev)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CursorResult{
+		NextCursor: next,
+		PrevCursor: prev,
+		Limit:      page.Limit,
+	}, nil
+}
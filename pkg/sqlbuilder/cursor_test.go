@@ -0,0 +1,136 @@
+package sqlbuilder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hanifkf12/hanif_skeleton/pkg/databasex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSetter interface {
+	SetMockData(query string, data []map[string]interface{})
+}
+
+type cursorRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func cursorRows(ids ...int64) []map[string]interface{} {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, map[string]interface{}{"ID": id, "CreatedAt": base.Add(time.Duration(id) * time.Hour)})
+	}
+	return rows
+}
+
+func TestGetWithCursor_FirstPage(t *testing.T) {
+	db := databasex.NewMockDB()
+	db.(mockSetter).SetMockData("SELECT * FROM items ORDER BY created_at DESC, id DESC LIMIT 3", cursorRows(5, 4, 3))
+
+	var rows []cursorRow
+	page, err := NewModel(db, &cursorRow{}).Table("items").
+		GetWithCursor(context.Background(), &rows, nil, 2, Desc("created_at"), Desc("id"))
+	require.NoError(t, err)
+
+	assert.Len(t, rows, 2)
+	assert.Nil(t, page.Prev)
+	require.NotNil(t, page.Next)
+	assert.Equal(t, "created_at:desc,id:desc", page.Next.Keys)
+	assert.Equal(t, int64(4), page.Next.Values[1])
+	assert.False(t, page.Next.Backward)
+}
+
+func TestGetWithCursor_BuildsKeysetCondition(t *testing.T) {
+	model := NewModel(databasex.NewMockDB(), &cursorRow{}).Table("items").Where("status = ?", "active")
+	cursor := &Cursor{Keys: "created_at:desc,id:desc", Values: []interface{}{"2024-01-01T04:00:00Z", int64(4)}}
+
+	var rows []cursorRow
+	page, err := model.GetWithCursor(context.Background(), &rows, cursor, 2, Desc("created_at"), Desc("id"))
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+	assert.Nil(t, page.Next)
+
+	query, args := model.ToSQL()
+	assert.Equal(t, "SELECT * FROM items WHERE status = ? AND ((created_at < ?) OR (created_at = ? AND id < ?)) ORDER BY created_at DESC, id DESC LIMIT 3", query)
+	assert.Equal(t, []interface{}{"active", "2024-01-01T04:00:00Z", "2024-01-01T04:00:00Z", int64(4)}, args)
+}
+
+func TestGetWithCursor_Backward(t *testing.T) {
+	db := databasex.NewMockDB()
+	// Walking backwards flips the order, the rows come back nearest first
+	db.(mockSetter).SetMockData("SELECT * FROM items WHERE ((id > ?)) ORDER BY id ASC LIMIT 3", cursorRows(3, 4, 5))
+
+	cursor := &Cursor{Keys: "id:desc", Values: []interface{}{int64(2)}, Backward: true}
+
+	var rows []cursorRow
+	page, err := NewModel(db, &cursorRow{}).Table("items").
+		GetWithCursor(context.Background(), &rows, cursor, 2, Desc("id"))
+	require.NoError(t, err)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(4), rows[0].ID)
+	assert.Equal(t, int64(3), rows[1].ID)
+	require.NotNil(t, page.Prev)
+	assert.True(t, page.Prev.Backward)
+	assert.Equal(t, int64(4), page.Prev.Values[0])
+	require.NotNil(t, page.Next)
+	assert.Equal(t, int64(3), page.Next.Values[0])
+}
+
+func TestGetWithCursor_RejectsCursorForOtherKeys(t *testing.T) {
+	cursor := &Cursor{Keys: "name:asc", Values: []interface{}{"a"}}
+
+	var rows []cursorRow
+	_, err := NewModel(databasex.NewMockDB(), &cursorRow{}).Table("items").
+		GetWithCursor(context.Background(), &rows, cursor, 2, Desc("id"))
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	cursor := &Cursor{Keys: "created_at:desc,id:desc", Values: []interface{}{"2024-01-01T04:00:00Z", int64(42)}, Backward: true}
+
+	token, err := codec.Encode(cursor)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(token)
+	require.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+
+	_, err = NewCursorCodec("other-secret").Decode(token)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = codec.Decode("bm90LWEtY3Vyc29y")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	decoded, err = codec.Decode("")
+	assert.NoError(t, err)
+	assert.Nil(t, decoded)
+}
+
+func TestCursorCodec_Times(t *testing.T) {
+	codec := NewCursorCodec("secret")
+	createdAt := time.Date(2024, 1, 1, 4, 0, 0, 123456789, time.UTC)
+	cursor := &Cursor{Keys: "created_at:desc,id:desc", Values: []interface{}{createdAt, int64(42)}}
+
+	token, err := codec.Encode(cursor)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode(token)
+	require.NoError(t, err)
+	require.IsType(t, time.Time{}, decoded.Values[0], "timestamps are compared as timestamps, not strings")
+	assert.True(t, createdAt.Equal(decoded.Values[0].(time.Time)))
+	assert.Equal(t, int64(42), decoded.Values[1])
+
+	// Pointers to a time are encoded the same way
+	token, err = codec.Encode(&Cursor{Keys: "created_at:desc", Values: []interface{}{&createdAt}})
+	require.NoError(t, err)
+	decoded, err = codec.Decode(token)
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(decoded.Values[0].(time.Time)))
+}
@@ -0,0 +1,51 @@
+package totp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+// recoveryAlphabet leaves out characters that are easily confused when typed from paper, e.g. 0/o and 1/l
+const recoveryAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// GenerateRecoveryCodes creates n single use codes formatted as "xxxxx-xxxxx", store only their hashes
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b, err := randomChars(10)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+	}
+	return codes, nil
+}
+
+// randomChars draws n characters of recoveryAlphabet, bytes past the last full multiple are rejected to avoid bias
+func randomChars(n int) ([]byte, error) {
+	limit := byte(256 - 256%len(recoveryAlphabet))
+	out := make([]byte, 0, n)
+	buf := make([]byte, n)
+
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		for _, b := range buf {
+			if b < limit && len(out) < n {
+				out = append(out, recoveryAlphabet[int(b)%len(recoveryAlphabet)])
+			}
+		}
+	}
+	return out, nil
+}
+
+// NormalizeRecoveryCode accepts codes typed in upper case or without the dash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 10 {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}